@@ -1,18 +1,329 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/ifrunruhin12/tasktime/internal/client"
+	"github.com/ifrunruhin12/tasktime/internal/models"
 )
 
 func main() {
 	serverURL := flag.String("server", "http://localhost:8080", "TaskTime server URL")
+	trashDays := flag.Int("trash-retention-days", 30, "Days deleted personal tasks stay in the trash (0 keeps them forever)")
 	flag.Parse()
 
-	c := client.New(*serverURL)
+	switch flag.Arg(0) {
+	case "login":
+		if err := login(*serverURL, flag.Arg(1)); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "logout":
+		if err := client.DeleteToken(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "report":
+		if err := report(*serverURL, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "estimates":
+		if err := estimates(*serverURL, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "export":
+		if err := export(*serverURL, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "calendar":
+		if err := calendar(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "import":
+		if err := importFile(*serverURL, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "invoice":
+		if err := invoice(*serverURL, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "rate":
+		if err := rate(*serverURL, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	c := client.New(*serverURL, time.Duration(*trashDays)*24*time.Hour)
 	if err := c.Start(); err != nil {
 		log.Fatal(err)
 	}
 }
+
+// login checks an API token against the server and saves it for later
+// runs. Without an argument the token is read from stdin, which keeps it
+// out of the shell history.
+func login(serverURL, token string) error {
+	if token == "" {
+		fmt.Fprint(os.Stderr, "API token: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return err
+		}
+		token = strings.TrimSpace(line)
+	}
+	if token == "" {
+		return errors.New("usage: tasktime login [TOKEN]")
+	}
+
+	user, err := client.CheckToken(serverURL, token)
+	if err != nil {
+		return err
+	}
+	if err := client.SaveToken(token); err != nil {
+		return err
+	}
+
+	fmt.Printf("Logged in to %s as %s\n", serverURL, user.Name)
+	return nil
+}
+
+// report prints personal and team time grouped by day, week, month,
+// project or user. Without dates it covers the range the TUI's report
+// screen starts with.
+func report(serverURL string, args []string) error {
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	by := flags.String("by", "day", "Group by "+strings.Join(models.ReportGroups, ", "))
+	from := flags.String("from", "", "First day, YYYY-MM-DD")
+	to := flags.String("to", "", "Last day, YYYY-MM-DD")
+	user := flags.String("user", "", "Only this user's team time")
+	flags.Parse(args)
+
+	query := models.ReportQuery{GroupBy: *by, From: *from, To: *to, User: *user}
+	if query.From == "" && query.To == "" {
+		query.From, query.To = client.ReportRange(query.GroupBy, time.Now())
+	}
+	if err := query.Validate(); err != nil {
+		return err
+	}
+
+	return client.New(serverURL, 0).PrintReport(os.Stdout, query)
+}
+
+// estimates prints how long done personal and team tasks took against
+// their estimates, per project or user.
+func estimates(serverURL string, args []string) error {
+	flags := flag.NewFlagSet("estimates", flag.ExitOnError)
+	by := flags.String("by", "project", "Group by "+strings.Join(models.EstimateGroups, " or "))
+	flags.Parse(args)
+
+	if err := models.CheckEstimateGroup(*by); err != nil {
+		return err
+	}
+	return client.New(serverURL, 0).PrintEstimates(os.Stdout, *by)
+}
+
+// export writes personal or team tasks with their time entries to a file
+// or stdout.
+func export(serverURL string, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	team := flags.Bool("team", false, "Export the server's tasks instead of personal ones")
+	format := flags.String("format", "", "json or csv (default: from the file name, else json)")
+	output := flags.String("o", "", "File to write (default stdout)")
+	flags.Parse(args)
+
+	kind := exportFormat(*format, *output)
+	if err := models.CheckExportFormat(kind); err != nil {
+		return err
+	}
+
+	c := client.New(serverURL, 0)
+	if *output == "" {
+		return c.Export(os.Stdout, *team, kind)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := c.Export(f, *team, kind); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// calendar writes the personal time entries and due dates as an
+// iCalendar file, for calendar apps to import.
+func calendar(args []string) error {
+	flags := flag.NewFlagSet("calendar", flag.ExitOnError)
+	output := flags.String("o", "", "File to write, e.g. tasktime.ics (default stdout)")
+	flags.Parse(args)
+
+	c := client.New("", 0)
+	if *output == "" {
+		return c.WriteCalendar(os.Stdout)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := c.WriteCalendar(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// importFile adds the tasks of an export file to the personal or team
+// tasks. "-" reads stdin.
+func importFile(serverURL string, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	team := flags.Bool("team", false, "Import into the server's tasks instead of personal ones")
+	format := flags.String("format", "", "json or csv (default: from the file name, else json)")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errors.New("usage: tasktime import [-team] [-format json|csv] FILE")
+	}
+
+	name := flags.Arg(0)
+	kind := exportFormat(*format, name)
+	if err := models.CheckExportFormat(kind); err != nil {
+		return err
+	}
+
+	in := os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	result, err := client.New(serverURL, 0).Import(in, *team, kind)
+	if result != nil {
+		fmt.Printf("Tasks: %d imported, %d already there\n", result.TasksCreated, result.TasksSkipped)
+		fmt.Printf("Time entries: %d imported, %d already there\n", result.EntriesCreated, result.EntriesSkipped)
+		if result.DependenciesAdded > 0 {
+			fmt.Printf("Dependencies: %d linked\n", result.DependenciesAdded)
+		}
+	}
+	return err
+}
+
+// invoice writes a client's invoice for a period to a file or stdout.
+func invoice(serverURL string, args []string) error {
+	flags := flag.NewFlagSet("invoice", flag.ExitOnError)
+	clientName := flags.String("client", "", "Client whose projects to bill")
+	from := flags.String("from", "", "First day, YYYY-MM-DD")
+	to := flags.String("to", "", "Last day, YYYY-MM-DD")
+	currency := flags.String("currency", "", "Currency to show amounts in, e.g. EUR")
+	format := flags.String("format", "", strings.Join(models.InvoiceFormats, ", ")+" (default: from the file name, else markdown)")
+	output := flags.String("o", "", "File to write (default stdout)")
+	flags.Parse(args)
+
+	query := models.InvoiceQuery{Client: *clientName, From: *from, To: *to, Currency: *currency}
+	if err := query.Validate(); err != nil {
+		return err
+	}
+	kind := invoiceFormat(*format, *output)
+	if err := models.CheckInvoiceFormat(kind); err != nil {
+		return err
+	}
+
+	c := client.New(serverURL, 0)
+	if *output == "" {
+		return c.WriteInvoice(os.Stdout, query, kind)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := c.WriteInvoice(f, query, kind); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// invoiceFormat is the format flag, or failing that the one the file name
+// ends in, or markdown.
+func invoiceFormat(flagValue, fileName string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".html", ".htm":
+		return "html"
+	case ".csv":
+		return "csv"
+	case ".json":
+		return "json"
+	}
+	return "markdown"
+}
+
+const rateUsage = `usage: tasktime rate list
+       tasktime rate set [-project NAME] [-user NAME] HOURLY_RATE
+       tasktime rate rm [-project NAME] [-user NAME]`
+
+// rate lists, sets or removes the team's hourly rates. A rate without a
+// project or user applies to all of them.
+func rate(serverURL string, args []string) error {
+	if len(args) == 0 {
+		return errors.New(rateUsage)
+	}
+
+	flags := flag.NewFlagSet("rate "+args[0], flag.ExitOnError)
+	project := flags.String("project", "", "Project the rate is for (default any)")
+	user := flags.String("user", "", "User the rate is for (default anyone)")
+	flags.Parse(args[1:])
+
+	c := client.New(serverURL, 0)
+	switch args[0] {
+	case "list":
+		return c.PrintRates(os.Stdout)
+	case "set":
+		if flags.NArg() != 1 {
+			return errors.New(rateUsage)
+		}
+		cents, err := models.ParseCents(flags.Arg(0))
+		if err != nil {
+			return fmt.Errorf("invalid hourly rate: %w", err)
+		}
+		return c.SetRate(models.Rate{Project: *project, User: *user, HourlyRateCents: cents})
+	case "rm":
+		return c.DeleteRate(models.Rate{Project: *project, User: *user})
+	}
+	return errors.New(rateUsage)
+}
+
+// exportFormat is the format flag, or failing that the one the file name
+// ends in, or json.
+func exportFormat(flagValue, fileName string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if strings.EqualFold(filepath.Ext(fileName), ".csv") {
+		return "csv"
+	}
+	return "json"
+}
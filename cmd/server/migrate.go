@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/ifrunruhin12/tasktime/internal/storage"
+)
+
+const migrateUsage = "usage: tasktime-server migrate status|up|down|to N"
+
+// runMigrate implements the migrate subcommand. It only applies to the
+// postgres store, the other backends have no schema.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	store, err := storage.OpenPostgresStore(databaseURL())
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	switch args[0] {
+	case "status":
+		statuses, err := store.MigrationStatus()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, applied)
+		}
+		return w.Flush()
+
+	case "up":
+		return store.MigrateUp()
+
+	case "down":
+		return store.MigrateDown()
+
+	case "to":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid migration version %q", args[1])
+		}
+		return store.MigrateTo(version)
+
+	default:
+		return errors.New(migrateUsage)
+	}
+}
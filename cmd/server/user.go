@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ifrunruhin12/tasktime/internal/storage"
+)
+
+const userUsage = "usage: tasktime-server user add NAME|token NAME|lead NAME|unlead NAME|list"
+
+// runUser implements the user subcommand, which manages the accounts of an
+// -auth server and who leads, reviewing timesheets. New tokens are printed
+// once and can't be shown again.
+func runUser(kind, dataFile string, args []string) error {
+	if len(args) == 0 {
+		return errors.New(userUsage)
+	}
+	if kind == "memory" {
+		return errors.New("the memory store forgets its users when this command exits; use postgres or json-file")
+	}
+
+	store, err := openStore(kind, dataFile)
+	if err != nil {
+		return err
+	}
+	users, ok := store.(storage.UserStore)
+	if !ok {
+		return fmt.Errorf("the %s store has no users", kind)
+	}
+
+	switch args[0] {
+	case "add":
+		if len(args) != 2 || strings.TrimSpace(args[1]) == "" {
+			return errors.New(userUsage)
+		}
+		user, err := users.CreateUser(args[1])
+		if err != nil {
+			return err
+		}
+		token, err := users.CreateToken(user.Name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Created user %s. Their API token, shown only once:\n", user.Name)
+		fmt.Println(token)
+		return nil
+
+	case "token":
+		if len(args) != 2 {
+			return errors.New(userUsage)
+		}
+		token, err := users.CreateToken(args[1])
+		if errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("no user named %q", args[1])
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "New API token for %s, shown only once:\n", args[1])
+		fmt.Println(token)
+		return nil
+
+	case "lead", "unlead":
+		if len(args) != 2 {
+			return errors.New(userUsage)
+		}
+		user, err := users.SetLead(args[1], args[0] == "lead")
+		if errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("no user named %q", args[1])
+		}
+		if err != nil {
+			return err
+		}
+		if user.Lead {
+			fmt.Fprintf(os.Stderr, "%s can now review timesheets.\n", user.Name)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s no longer reviews timesheets.\n", user.Name)
+		}
+		return nil
+
+	case "list":
+		list, err := users.GetUsers()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tLEAD\tCREATED")
+		for _, user := range list {
+			lead := ""
+			if user.Lead {
+				lead = "yes"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", user.Name, lead, user.CreatedAt.Format("2006-01-02 15:04:05"))
+		}
+		return w.Flush()
+
+	default:
+		return errors.New(userUsage)
+	}
+}
@@ -3,21 +3,29 @@ package client
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/gorilla/websocket"
 	"github.com/ifrunruhin12/tasktime/internal/models"
+	"github.com/ifrunruhin12/tasktime/internal/storage"
 )
 
+var errNoLocalStore = errors.New("personal task file is not available")
+
 // Personal task operations (local)
 func (m model) loadPersonalTasks() tea.Cmd {
 	return func() tea.Msg {
 		if m.localStore == nil {
 			return personalTasksLoadedMsg([]models.Task{})
 		}
-		
+
 		tasks, err := m.localStore.GetTasks()
 		if err != nil {
 			return personalTasksLoadedMsg([]models.Task{})
@@ -26,13 +34,13 @@ func (m model) loadPersonalTasks() tea.Cmd {
 	}
 }
 
-func (m model) createPersonalTask(title, project string) tea.Cmd {
+func (m model) createPersonalTask(req models.CreateTaskRequest) tea.Cmd {
 	return func() tea.Msg {
 		if m.localStore == nil {
 			return taskCreationFailedMsg{}
 		}
-		
-		_, err := m.localStore.CreateTask(title, project)
+
+		_, err := m.localStore.CreateTask(req)
 		if err != nil {
 			return taskCreationFailedMsg{}
 		}
@@ -40,13 +48,30 @@ func (m model) createPersonalTask(title, project string) tea.Cmd {
 	}
 }
 
-func (m model) updatePersonalTaskStatus(id, status string) tea.Cmd {
+func (m model) updatePersonalTask(task models.Task, changes models.UpdateTaskRequest) tea.Cmd {
+	return func() tea.Msg {
+		if m.localStore == nil {
+			return taskOperationFailedMsg{}
+		}
+
+		if _, err := m.localStore.UpdateTask(task.ID, changes, task.Version); err != nil {
+			return taskOperationFailedMsg{}
+		}
+		return m.loadPersonalTasks()()
+	}
+}
+
+func (m model) updatePersonalTaskStatus(task models.Task, status string) tea.Cmd {
 	return func() tea.Msg {
 		if m.localStore == nil {
 			return taskOperationFailedMsg{}
 		}
-		
-		_, err := m.localStore.UpdateTaskStatus(id, status)
+
+		_, err := m.localStore.UpdateTaskStatus(task.ID, status, task.Version)
+		var transition *models.TransitionError
+		if errors.As(err, &transition) {
+			return noticeMsg(err.Error())
+		}
 		if err != nil {
 			return taskOperationFailedMsg{}
 		}
@@ -54,13 +79,13 @@ func (m model) updatePersonalTaskStatus(id, status string) tea.Cmd {
 	}
 }
 
-func (m model) deletePersonalTask(id string) tea.Cmd {
+func (m model) deletePersonalTask(task models.Task) tea.Cmd {
 	return func() tea.Msg {
 		if m.localStore == nil {
 			return taskOperationFailedMsg{}
 		}
-		
-		err := m.localStore.DeleteTask(id)
+
+		err := m.localStore.DeleteTask(task.ID, task.Version)
 		if err != nil {
 			return taskOperationFailedMsg{}
 		}
@@ -68,13 +93,13 @@ func (m model) deletePersonalTask(id string) tea.Cmd {
 	}
 }
 
-func (m model) startPersonalTimer(id string) tea.Cmd {
+func (m model) startPersonalTimer(task models.Task) tea.Cmd {
 	return func() tea.Msg {
 		if m.localStore == nil {
 			return taskOperationFailedMsg{}
 		}
-		
-		_, err := m.localStore.StartTimer(id)
+
+		_, err := m.localStore.StartTimer(task.ID, "", task.Version)
 		if err != nil {
 			return taskOperationFailedMsg{}
 		}
@@ -82,13 +107,13 @@ func (m model) startPersonalTimer(id string) tea.Cmd {
 	}
 }
 
-func (m model) stopPersonalTimer(id string) tea.Cmd {
+func (m model) stopPersonalTimer(task models.Task) tea.Cmd {
 	return func() tea.Msg {
 		if m.localStore == nil {
 			return taskOperationFailedMsg{}
 		}
-		
-		_, err := m.localStore.StopTimer(id)
+
+		_, err := m.localStore.StopTimer(task.ID, "", task.Version)
 		if err != nil {
 			return taskOperationFailedMsg{}
 		}
@@ -97,112 +122,534 @@ func (m model) stopPersonalTimer(id string) tea.Cmd {
 }
 
 // Team task operations (server API)
+// teamPageSize is how many team tasks one request loads. The list shows
+// the first page right away and asks for the next as the cursor nears the
+// end of what is loaded.
+const teamPageSize = 200
+
+// teamPagePrefetch is how close to the end of the loaded team tasks the
+// cursor gets before the next page is asked for.
+const teamPagePrefetch = 20
+
 func (m model) loadTeamTasks() tea.Cmd {
+	return m.loadTeamTasksPage("")
+}
+
+// loadTeamTasksPage loads the page of team tasks under the current filter
+// that starts at cursor, empty for the first one.
+func (m model) loadTeamTasksPage(cursor string) tea.Cmd {
+	filter := m.teamFilter
 	return func() tea.Msg {
-		resp, err := http.Get(m.client.serverURL + "/api/v1/tasks")
+		query := filter
+		query.Sort, query.Limit, query.Cursor = models.DefaultTaskSort, teamPageSize, cursor
+		var tasks []models.Task
+		header, err := m.teamDo("GET", "/api/v1/tasks?"+query.Values().Encode(), 0, nil, &tasks)
 		if err != nil {
-			return teamTasksLoadedMsg([]models.Task{})
+			var apiErr *apiError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+				return noticeMsg("The team server needs an API token: run `tasktime login`")
+			}
+			if cursor != "" {
+				// Keep the pages we have and ask again on the next move
+				return teamTasksLoadedMsg{cursor: cursor, next: cursor, filter: filter.Values().Encode()}
+			}
+			return teamTasksLoadedMsg{filter: filter.Values().Encode()}
 		}
-		defer resp.Body.Close()
 
-		var tasks []models.Task
-		if err := json.NewDecoder(resp.Body).Decode(&tasks); err != nil {
-			return teamTasksLoadedMsg([]models.Task{})
+		return teamTasksLoadedMsg{tasks: tasks, cursor: cursor, next: header.Get("X-Next-Cursor"), filter: filter.Values().Encode()}
+	}
+}
+
+// loadMoreTeamTasks asks for the next page of team tasks once the cursor
+// nears the end of those loaded, unless all are or a page is on its way.
+func (m *model) loadMoreTeamTasks() tea.Cmd {
+	if m.currentSection != "team" || m.teamNext == "" || m.teamLoading ||
+		m.cursor < len(m.taskRows())-teamPagePrefetch {
+		return nil
+	}
+	m.teamLoading = true
+	return m.loadTeamTasksPage(m.teamNext)
+}
+
+// reloadTeamTask fetches one team task again after a change to it failed,
+// rather than the whole list.
+func (m model) reloadTeamTask(id string) tea.Cmd {
+	return func() tea.Msg {
+		var task models.Task
+		err := m.teamRequest("GET", "/api/v1/tasks/"+id, nil, &task)
+		var apiErr *apiError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return teamTaskReloadedMsg{id: id}
 		}
+		if err != nil {
+			return nil
+		}
+		return teamTaskReloadedMsg{id: id, task: &task}
+	}
+}
 
-		return teamTasksLoadedMsg(tasks)
+// loadProjects fetches the server's projects for autocomplete and colors.
+// Without them, projects are plain text.
+func (m model) loadProjects() tea.Cmd {
+	return func() tea.Msg {
+		var projects []models.Project
+		if err := m.teamRequest("GET", "/api/v1/projects", nil, &projects); err != nil {
+			return projectsLoadedMsg(nil)
+		}
+		return projectsLoadedMsg(projects)
 	}
 }
 
-func (m model) createTeamTask(title, project string) tea.Cmd {
+// loadMe asks the server who we are, which with authentication is the
+// token's user rather than the OS user.
+func (m model) loadMe() tea.Cmd {
 	return func() tea.Msg {
-		reqBody := models.CreateTaskRequest{
-			Title:   title,
-			Project: project,
+		var me models.User
+		if err := m.teamRequest("GET", "/api/v1/me", nil, &me); err != nil {
+			return nil
 		}
+		return meLoadedMsg(me)
+	}
+}
 
-		jsonData, _ := json.Marshal(reqBody)
-		resp, err := http.Post(
-			m.client.serverURL+"/api/v1/tasks",
-			"application/json",
-			bytes.NewBuffer(jsonData),
-		)
+// loadTeamWorkflow fetches the server's status workflow. Older servers
+// don't have one and keep the default.
+func (m model) loadTeamWorkflow() tea.Cmd {
+	return func() tea.Msg {
+		var workflow models.Workflow
+		if err := m.teamRequest("GET", "/api/v1/workflow", nil, &workflow); err != nil {
+			return nil
+		}
+		if workflow.Validate() != nil {
+			return nil
+		}
+		return teamWorkflowLoadedMsg(workflow)
+	}
+}
 
-		if err != nil || resp.StatusCode != 200 {
+func (m model) createTeamTask(req models.CreateTaskRequest) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.teamRequest("POST", "/api/v1/tasks", req, nil); err != nil {
 			return taskCreationFailedMsg{}
 		}
-		defer resp.Body.Close()
 
 		return m.loadTeamTasks()()
 	}
 }
 
-func (m model) updateTeamTaskStatus(id, status string) tea.Cmd {
-	return func() tea.Msg {
-		reqBody := models.UpdateStatusRequest{Status: status}
-		jsonData, _ := json.Marshal(reqBody)
+func (m model) updateTeamTask(task models.Task, changes models.UpdateTaskRequest) tea.Cmd {
+	return m.teamTaskChange("PATCH", "/api/v1/tasks/"+task.ID, changes, task,
+		func(t models.Task) models.Task {
+			changes.Apply(&t)
+			return t
+		},
+		func(theirs models.Task) tea.Cmd { return m.updateTeamTask(theirs, changes) })
+}
 
-		req, _ := http.NewRequest("PUT",
-			m.client.serverURL+"/api/v1/tasks/"+id+"/status",
-			bytes.NewBuffer(jsonData))
-		req.Header.Set("Content-Type", "application/json")
+func (m model) updateTeamTaskStatus(task models.Task, status string) tea.Cmd {
+	reqBody := models.UpdateStatusRequest{Status: status}
+	return m.teamTaskChange("PUT", "/api/v1/tasks/"+task.ID+"/status", reqBody, task,
+		func(t models.Task) models.Task {
+			t.Status = status
+			return t
+		},
+		func(theirs models.Task) tea.Cmd { return m.updateTeamTaskStatus(theirs, status) })
+}
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
+func (m model) deleteTeamTask(task models.Task) tea.Cmd {
+	return m.teamTaskChange("DELETE", "/api/v1/tasks/"+task.ID, nil, task, nil,
+		func(theirs models.Task) tea.Cmd { return m.deleteTeamTask(theirs) })
+}
 
-		if err != nil || resp.StatusCode != 200 {
-			return taskOperationFailedMsg{}
+func (m model) startTeamTimer(task models.Task) tea.Cmd {
+	return m.teamTaskChange("POST", "/api/v1/tasks/"+task.ID+"/time/start", nil, task,
+		func(t models.Task) models.Task {
+			t.IsActive = true
+			return t
+		},
+		func(theirs models.Task) tea.Cmd { return m.startTeamTimer(theirs) })
+}
+
+func (m model) stopTeamTimer(task models.Task) tea.Cmd {
+	return m.teamTaskChange("POST", "/api/v1/tasks/"+task.ID+"/time/stop", nil, task,
+		func(t models.Task) models.Task {
+			t.IsActive = false
+			return t
+		},
+		func(theirs models.Task) tea.Cmd { return m.stopTeamTimer(theirs) })
+}
+
+// teamTaskChange sends a change to a team task, guarded by the version the
+// client last saw. If someone else changed the task in the meantime the
+// server answers 409 and the user gets to pick between their version and
+// ours. apply previews the change on a task (nil for a delete) and retry
+// repeats it on top of their version.
+func (m model) teamTaskChange(method, path string, body interface{}, seen models.Task, apply func(models.Task) models.Task, retry func(theirs models.Task) tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		err := m.teamRequestIfMatch(method, path, seen.Version, body, nil)
+
+		var apiErr *apiError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusLocked {
+			var blocked models.BlockedResponse
+			if json.Unmarshal(apiErr.Body, &blocked) == nil {
+				forced := m.teamTaskChange(method, path+"?force=true", body, seen, apply, retry)
+				return taskBlockedMsg{task: seen, blockers: blocked.BlockedBy, force: forced}
+			}
+		}
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+			var theirs models.Task
+			if json.Unmarshal(apiErr.Body, &theirs) == nil {
+				conflict := taskConflictMsg{theirs: theirs, retry: retry}
+				if apply != nil {
+					mine := apply(theirs)
+					conflict.mine = &mine
+				}
+				return conflict
+			}
+		}
+		// The workflow doesn't allow the move; the body says why
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnprocessableEntity {
+			return noticeMsg(strings.TrimSpace(string(apiErr.Body)))
+		}
+		if err != nil {
+			return m.reloadTeamTask(seen.ID)()
 		}
-		defer resp.Body.Close()
 
 		return nil // WebSocket will handle the update
 	}
 }
 
-func (m model) deleteTeamTask(id string) tea.Cmd {
+// Dependency operations (team only)
+func (m model) addTeamDependency(task models.Task, blockerID string) tea.Cmd {
 	return func() tea.Msg {
-		req, _ := http.NewRequest("DELETE", m.client.serverURL+"/api/v1/tasks/"+id, nil)
-		client := &http.Client{}
-		resp, err := client.Do(req)
+		req := models.DependencyRequest{BlockedBy: blockerID}
+		err := m.teamRequest("POST", "/api/v1/tasks/"+task.ID+"/dependencies", req, nil)
+		return dependencyResult(err)
+	}
+}
 
-		if err != nil || resp.StatusCode != 204 {
-			return taskOperationFailedMsg{}
+func (m model) removeTeamDependency(task models.Task, blockerID string) tea.Cmd {
+	return func() tea.Msg {
+		err := m.teamRequest("DELETE", "/api/v1/tasks/"+task.ID+"/dependencies/"+blockerID, nil, nil)
+		return dependencyResult(err)
+	}
+}
+
+// dependencyResult turns a failed dependency change into a notice. Success
+// needs nothing; the WebSocket brings the updated task.
+func dependencyResult(err error) tea.Msg {
+	if err == nil {
+		return nil
+	}
+
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return noticeMsg(strings.TrimSpace(string(apiErr.Body)))
+	}
+	return noticeMsg(err.Error())
+}
+
+// Trash operations
+func (m model) loadTrash() tea.Cmd {
+	return func() tea.Msg {
+		var tasks []models.Task
+		if m.currentSection == "personal" {
+			if m.localStore == nil {
+				return trashLoadedMsg{}
+			}
+			deleted, err := m.localStore.GetDeletedTasks()
+			if err != nil {
+				return trashLoadedMsg{}
+			}
+			tasks = deleted
+		} else if err := m.teamRequest("GET", "/api/v1/trash", nil, &tasks); err != nil {
+			return trashLoadedMsg{}
 		}
-		defer resp.Body.Close()
 
-		return nil // WebSocket will handle the update
+		return trashLoadedMsg(tasks)
 	}
 }
 
-func (m model) startTeamTimer(id string) tea.Cmd {
+func (m model) restoreTask(id string) tea.Cmd {
 	return func() tea.Msg {
-		resp, err := http.Post(m.client.serverURL+"/api/v1/tasks/"+id+"/time/start", "application/json", nil)
-		if err != nil || resp.StatusCode != 200 {
+		if m.currentSection == "personal" {
+			if m.localStore == nil {
+				return taskOperationFailedMsg{}
+			}
+			if _, err := m.localStore.RestoreTask(id); err != nil {
+				return taskOperationFailedMsg{}
+			}
+		} else if err := m.teamRequest("POST", "/api/v1/tasks/"+id+"/restore", nil, nil); err != nil {
 			return taskOperationFailedMsg{}
 		}
-		defer resp.Body.Close()
 
-		return nil // WebSocket will handle the update
+		return m.loadTrash()()
 	}
 }
 
-func (m model) stopTeamTimer(id string) tea.Cmd {
+// loadReport fetches the time report of both sections.
+func (m model) loadReport(query models.ReportQuery) tea.Cmd {
 	return func() tea.Msg {
-		resp, err := http.Post(m.client.serverURL+"/api/v1/tasks/"+id+"/time/stop", "application/json", nil)
-		if err != nil || resp.StatusCode != 200 {
-			return taskOperationFailedMsg{}
+		personal, team, notes := m.client.timeReports(m.localStore, query)
+		return reportLoadedMsg{query: query, personal: personal, team: team, notes: notes}
+	}
+}
+
+// loadEstimates fetches the estimate report of both sections.
+func (m model) loadEstimates(group string) tea.Cmd {
+	return func() tea.Msg {
+		report, notes := m.client.estimateReport(m.localStore, group)
+		return estimatesLoadedMsg{group: group, report: report, notes: notes}
+	}
+}
+
+// loadTimesheet fetches a user's timesheet for the week day falls in.
+func (m model) loadTimesheet(user string, day time.Time) tea.Cmd {
+	return func() tea.Msg {
+		var sheet models.Timesheet
+		if err := m.teamRequest("GET", timesheetPath(user, models.WeekOf(day)), nil, &sheet); err != nil {
+			return timesheetLoadedMsg{err: err}
 		}
-		defer resp.Body.Close()
+		return timesheetLoadedMsg{sheet: &sheet}
+	}
+}
 
-		return nil // WebSocket will handle the update
+// changeTimesheet submits, approves or rejects a timesheet; action is the
+// last part of the endpoint's path.
+func (m model) changeTimesheet(sheet models.Timesheet, action, comment string) tea.Cmd {
+	return func() tea.Msg {
+		var body interface{}
+		if action != "submit" {
+			body = models.ReviewRequest{Comment: comment}
+		}
+		var changed models.Timesheet
+		if err := m.teamRequest("POST", timesheetPath(sheet.User, sheet.WeekStart)+"/"+action, body, &changed); err != nil {
+			return timesheetLoadedMsg{err: err}
+		}
+		return timesheetLoadedMsg{sheet: &changed}
+	}
+}
+
+// loadReviews fetches the timesheets waiting for a lead.
+func (m model) loadReviews() tea.Cmd {
+	return func() tea.Msg {
+		var sheets []models.Timesheet
+		if err := m.teamRequest("GET", "/api/v1/timesheets?status="+models.TimesheetSubmitted, nil, &sheets); err != nil {
+			return reviewsLoadedMsg{err: err}
+		}
+		return reviewsLoadedMsg{sheets: sheets}
+	}
+}
+
+func timesheetPath(user, week string) string {
+	return "/api/v1/timesheets/" + url.PathEscape(user) + "/" + week
+}
+
+// searchResultsLimit is how many hits a search shows per section.
+const searchResultsLimit = 50
+
+// searchTasks runs a search over the personal tasks and on the team
+// server. A section that can't be searched is left out with a note.
+func (m model) searchTasks(query string) tea.Cmd {
+	return func() tea.Msg {
+		result := searchResultsMsg{query: query}
+
+		if searcher, ok := m.localStore.(storage.SearchStore); ok {
+			personal, err := searcher.SearchTasks(query, searchResultsLimit)
+			if err != nil {
+				result.notes = append(result.notes, "Personal search failed: "+err.Error())
+			}
+			for _, hit := range personal {
+				result.hits = append(result.hits, searchHit{section: "personal", SearchResult: hit})
+			}
+		}
+
+		var team []models.SearchResult
+		path := fmt.Sprintf("/api/v1/search?q=%s&limit=%d", url.QueryEscape(query), searchResultsLimit)
+		err := m.teamRequest("GET", path, nil, &team)
+		var apiErr *apiError
+		if errors.As(err, &apiErr) {
+			result.notes = append(result.notes, "Team search failed: "+err.Error())
+		} else if err != nil {
+			result.notes = append(result.notes, "Team tasks weren't searched: the server is unreachable")
+		}
+		for _, hit := range team {
+			result.hits = append(result.hits, searchHit{section: "team", SearchResult: hit})
+		}
+
+		return result
+	}
+}
+
+// Time entry operations. Personal entries go through the local store and
+// team entries through the server API.
+func (m model) loadTimeEntries(taskID string) tea.Cmd {
+	return func() tea.Msg {
+		var entries []models.TimeEntry
+		var err error
+		if m.currentSection == "personal" {
+			if m.localStore == nil {
+				return timeEntryOperationFailedMsg{errNoLocalStore}
+			}
+			entries, err = m.localStore.GetTimeEntries(taskID)
+		} else {
+			err = m.teamRequest("GET", "/api/v1/tasks/"+taskID+"/time_entries", nil, &entries)
+		}
+
+		if err != nil {
+			return timeEntryOperationFailedMsg{err}
+		}
+		return timeEntriesLoadedMsg{taskID: taskID, entries: entries}
+	}
+}
+
+func (m model) addTimeEntry(taskID string, start, end time.Time) tea.Cmd {
+	return m.timeEntryOperation(taskID, func() error {
+		if m.currentSection == "personal" {
+			_, err := m.localStore.AddTimeEntry(taskID, "", start, end)
+			return err
+		}
+		req := models.TimeEntryRequest{StartTime: start, EndTime: end}
+		return m.teamRequest("POST", "/api/v1/tasks/"+taskID+"/time_entries", req, nil)
+	})
+}
+
+func (m model) updateTimeEntry(id string, start, end time.Time) tea.Cmd {
+	return m.timeEntryOperation(m.entryTask.ID, func() error {
+		if m.currentSection == "personal" {
+			_, err := m.localStore.UpdateTimeEntry(id, start, end)
+			return err
+		}
+		req := models.TimeEntryRequest{StartTime: start, EndTime: end}
+		return m.teamRequest("PUT", "/api/v1/time_entries/"+id, req, nil)
+	})
+}
+
+func (m model) deleteTimeEntry(id string) tea.Cmd {
+	return m.timeEntryOperation(m.entryTask.ID, func() error {
+		if m.currentSection == "personal" {
+			_, err := m.localStore.DeleteTimeEntry(id)
+			return err
+		}
+		return m.teamRequest("DELETE", "/api/v1/time_entries/"+id, nil, nil)
+	})
+}
+
+// splitTimeEntry cuts an entry in two at the given time: the original entry
+// ends there and a new one for the same user covers the rest.
+func (m model) splitTimeEntry(entry models.TimeEntry, at time.Time) tea.Cmd {
+	return m.timeEntryOperation(entry.TaskID, func() error {
+		if m.currentSection == "personal" {
+			_, _, err := m.localStore.SplitTimeEntry(entry.ID, at)
+			return err
+		}
+		req := models.SplitTimeEntryRequest{At: at}
+		return m.teamRequest("POST", "/api/v1/time_entries/"+entry.ID+"/split", req, nil)
+	})
+}
+
+// setEntryBillable marks a team time entry billable or not.
+func (m model) setEntryBillable(id string, billable bool) tea.Cmd {
+	return m.timeEntryOperation(m.entryTask.ID, func() error {
+		return m.teamRequest("PUT", "/api/v1/time_entries/"+id+"/billable", models.BillableRequest{Billable: billable}, nil)
+	})
+}
+
+// timeEntryOperation runs op and then reloads the task's entries.
+func (m model) timeEntryOperation(taskID string, op func() error) tea.Cmd {
+	return func() tea.Msg {
+		if m.currentSection == "personal" && m.localStore == nil {
+			return timeEntryOperationFailedMsg{errNoLocalStore}
+		}
+		if err := op(); err != nil {
+			return timeEntryOperationFailedMsg{err}
+		}
+		return m.loadTimeEntries(taskID)()
+	}
+}
+
+// apiError is a non-2xx answer from the server.
+type apiError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Status, strings.TrimSpace(string(e.Body)))
+}
+
+// teamRequest sends a JSON request to the server and decodes the response
+// into out, if given. Non-2xx responses become an *apiError.
+func (m model) teamRequest(method, path string, body, out interface{}) error {
+	return m.teamRequestIfMatch(method, path, 0, body, out)
+}
+
+// teamRequestIfMatch is teamRequest with an If-Match header for the given
+// task version. Zero sends no header.
+func (m model) teamRequestIfMatch(method, path string, version int, body, out interface{}) error {
+	_, err := m.teamDo(method, path, version, body, out)
+	return err
+}
+
+// teamDo is teamRequestIfMatch that also returns the response headers.
+func (m model) teamDo(method, path string, version int, body, out interface{}) (http.Header, error) {
+	return m.client.request(method, path, version, body, out)
+}
+
+// request sends a JSON request to the server as this client; see
+// teamRequestIfMatch.
+func (c *Client) request(method, path string, version int, body, out interface{}) (http.Header, error) {
+	var reader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequest(method, c.serverURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if version != 0 {
+		req.Header.Set("If-Match", fmt.Sprintf(`"%d"`, version))
 	}
+	if c.user != "" {
+		req.Header.Set("X-Tasktime-User", c.user)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(resp.Body)
+		return resp.Header, &apiError{StatusCode: resp.StatusCode, Status: resp.Status, Body: msg}
+	}
+
+	if out != nil {
+		return resp.Header, json.NewDecoder(resp.Body).Decode(out)
+	}
+	return resp.Header, nil
 }
 
 // WebSocket operations
 func (m model) connectWebSocket() tea.Cmd {
 	return func() tea.Msg {
 		wsURL := "ws" + m.client.serverURL[4:] + "/api/v1/ws"
-		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		header := http.Header{}
+		if m.client.token != "" {
+			header.Set("Authorization", "Bearer "+m.client.token)
+		}
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, header)
 		if err != nil {
 			return wsConnectionFailedMsg{}
 		}
@@ -230,4 +677,4 @@ func (m model) tick() tea.Cmd {
 	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
 		return tickMsg(t)
 	})
-}
\ No newline at end of file
+}
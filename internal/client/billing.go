@@ -0,0 +1,60 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"text/tabwriter"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+// WriteInvoice fetches a client's invoice for a period from the server and
+// writes it to w in the given format.
+func (c *Client) WriteInvoice(w io.Writer, query models.InvoiceQuery, format string) error {
+	invoice := &models.Invoice{}
+	if _, err := c.request("GET", "/api/v1/invoices?"+query.Values().Encode(), 0, nil, invoice); err != nil {
+		return err
+	}
+
+	return models.WriteInvoice(w, format, invoice)
+}
+
+// PrintRates writes the server's hourly rates to w.
+func (c *Client) PrintRates(w io.Writer) error {
+	var rates []models.Rate
+	if _, err := c.request("GET", "/api/v1/rates", 0, nil, &rates); err != nil {
+		return err
+	}
+	if len(rates) == 0 {
+		fmt.Fprintln(w, "No rates set")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROJECT\tUSER\tHOURLY RATE\t")
+	for _, rate := range rates {
+		project, user := rate.Project, rate.User
+		if project == "" {
+			project = "(any)"
+		}
+		if user == "" {
+			user = "(anyone)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t\n", project, user, models.FormatCents(rate.HourlyRateCents))
+	}
+	return tw.Flush()
+}
+
+// SetRate adds or changes the hourly rate for a project and user.
+func (c *Client) SetRate(rate models.Rate) error {
+	_, err := c.request("PUT", "/api/v1/rates", 0, rate, nil)
+	return err
+}
+
+// DeleteRate removes the hourly rate for a project and user.
+func (c *Client) DeleteRate(rate models.Rate) error {
+	values := url.Values{"project": {rate.Project}, "user": {rate.User}}
+	_, err := c.request("DELETE", "/api/v1/rates?"+values.Encode(), 0, nil, nil)
+	return err
+}
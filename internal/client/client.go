@@ -1,6 +1,9 @@
 package client
 
 import (
+	"os"
+	"os/user"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -11,15 +14,32 @@ import (
 )
 
 type Client struct {
-	serverURL string
+	serverURL      string
+	trashRetention time.Duration
+	user           string // sent with team changes for the audit log
+	token          string // API token for a server that requires one
 }
 
-func New(serverURL string) *Client {
+// New creates a client for the given server, authenticating with the token
+// saved by SaveToken if there is one. Personal tasks that have been in the
+// trash longer than trashRetention are purged on startup; zero keeps them
+// forever.
+func New(serverURL string, trashRetention time.Duration) *Client {
 	return &Client{
-		serverURL: serverURL,
+		serverURL:      serverURL,
+		trashRetention: trashRetention,
+		user:           currentUser(),
+		token:          loadToken(),
 	}
 }
 
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
 func (c *Client) Start() error {
 	p := tea.NewProgram(c.initialModel(), tea.WithAltScreen())
 	_, err := p.Run()
@@ -27,7 +47,18 @@ func (c *Client) Start() error {
 }
 
 func (c *Client) initialModel() model {
-	localStore, _ := storage.NewLocalStore()
+	// Keep localStore nil when the file can't be opened so the personal
+	// task commands can detect it.
+	workflow, notice := loadPersonalWorkflow()
+	var localStore storage.TaskStore
+	if store, err := storage.NewLocalStore(); err == nil {
+		store.SetWorkflow(workflow)
+		localStore = store
+		if c.trashRetention > 0 {
+			store.PurgeDeletedTasks(time.Now().Add(-c.trashRetention))
+		}
+	}
+
 	return model{
 		client:         c,
 		personalTasks:  []models.Task{},
@@ -38,7 +69,39 @@ func (c *Client) initialModel() model {
 		height:         24,
 		currentSection: "personal", // Start with personal tasks
 		localStore:     localStore,
+		collapsed:      make(map[string]bool),
+		notice:         notice,
+
+		personalWorkflow: workflow,
+		teamWorkflow:     models.DefaultWorkflow(),
+	}
+}
+
+// loadPersonalWorkflow reads the personal status workflow from
+// ~/.tasktime/workflow.json. Without one the default is used; a broken one
+// falls back to the default too, with a notice saying why.
+func loadPersonalWorkflow() (models.Workflow, string) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return models.DefaultWorkflow(), ""
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".tasktime", "workflow.json"))
+	if err != nil {
+		return models.DefaultWorkflow(), ""
+	}
+	workflow, err := models.ParseWorkflow(data)
+	if err != nil {
+		return models.DefaultWorkflow(), "Ignoring ~/.tasktime/workflow.json: " + err.Error()
+	}
+	return workflow, ""
+}
+
+// workflow is the status workflow of the current section.
+func (m model) workflow() models.Workflow {
+	if m.currentSection == "personal" {
+		return m.personalWorkflow
 	}
+	return m.teamWorkflow
 }
 
 type model struct {
@@ -47,18 +110,87 @@ type model struct {
 	teamTasks      []models.Task
 	cursor         int
 	showInput      bool
-	inputTitle     string
-	inputProject   string
-	inputMode      int
+	inputs         [taskFieldCount]string
+	inputMode      int // index of the field being typed into
+	inputError     string
+	editingTask    models.Task // set when the input screen edits a task instead of creating one
 	ws             *websocket.Conn
 	width          int
 	height         int
 	currentSection string // "personal" or "team"
-	localStore     *storage.LocalStore
+	localStore     storage.TaskStore
+	collapsed      map[string]bool  // tasks whose subtasks are hidden
+	projects       []models.Project // the server's projects, archived ones left out
+	me             string           // the name the server records our changes under
+	lead           bool             // whether we may review others' timesheets
+
+	personalWorkflow models.Workflow // from ~/.tasktime/workflow.json
+	teamWorkflow     models.Workflow // from the server
+
+	// Time entry editor for one task
+	showEntries bool
+	entryTask   models.Task
+	entries     []models.TimeEntry
+	entryCursor int
+	entryForm   string    // "", "add", "edit" or "split"
+	entryInputs [2]string // start and end, or just the split point
+	entryField  int
+	entryError  string
+
+	conflict *taskConflictMsg // pending "theirs vs. mine" prompt
+	blocked  *taskBlockedMsg  // pending "do it anyway?" prompt
+
+	linkingTask models.Task // team task waiting for its blocker to be picked
+	notice      string      // one-off error shown above the help line
+
+	// Trash of the current section
+	showTrash   bool
+	trashTasks  []models.Task
+	trashCursor int
+
+	detailTaskID string // task shown in the detail view, if any
+
+	// Filter and paging of the team list
+	teamFilter     models.TaskQuery // sent with every page, set with f
+	teamFilterText string           // what was typed for teamFilter
+	filtering      bool             // typing into the filter prompt
+	filterInput    string
+	filterError    string
+	teamNext       string // cursor of the next page, empty once all are loaded
+	teamLoading    bool   // the next page is on its way
+
+	// Search over both sections
+	searching    bool   // typing into the search prompt
+	searchQuery  string // the prompt's text, then the query of searchHits
+	showSearch   bool
+	searchHits   []searchHit
+	searchNotes  []string // sections that couldn't be searched
+	searchCursor int
+
+	// Time report of both sections
+	showReport     bool
+	reportQuery    models.ReportQuery
+	reportDay      time.Time // a day in the range shown
+	reportPersonal *models.TimeReport
+	reportTeam     *models.TimeReport
+	reportNotes    []string
+	estimateGroup  string // set while the report compares estimates instead, by "project" or "user"
+	estimates      *models.EstimateReport
+
+	// Weekly timesheets on the team server
+	showTimesheet  bool
+	timesheetUser  string    // whose timesheet is shown
+	timesheetDay   time.Time // a day in the week shown
+	timesheet      *models.Timesheet
+	timesheetError string
+	rejecting      bool   // typing the comment of a rejection
+	rejectComment  string // the comment typed so far
+	showReviews    bool   // the lead's queue of submitted timesheets
+	reviews        []models.Timesheet
+	reviewCursor   int
 }
 
 type personalTasksLoadedMsg []models.Task
-type teamTasksLoadedMsg []models.Task
 type wsConnectedMsg *websocket.Conn
 type tickMsg time.Time
 type taskCreationFailedMsg struct{}
@@ -66,11 +198,95 @@ type wsDisconnectedMsg struct{}
 type wsConnectionFailedMsg struct{}
 type wsRetryMsg struct{}
 type taskOperationFailedMsg struct{}
+type timeEntriesLoadedMsg struct {
+	taskID  string
+	entries []models.TimeEntry
+}
+type timeEntryOperationFailedMsg struct{ err error }
+
+type trashLoadedMsg []models.Task
+type projectsLoadedMsg []models.Project
+type meLoadedMsg models.User
+type teamWorkflowLoadedMsg models.Workflow
+type noticeMsg string
+
+// teamTasksLoadedMsg is a page of team tasks. The first page, which has no
+// cursor, replaces the list and later ones add to it.
+type teamTasksLoadedMsg struct {
+	tasks  []models.Task
+	cursor string // where this page starts
+	next   string // where the next page starts, empty after the last
+	filter string // the encoded teamFilter the page was asked for
+}
+
+// teamTaskReloadedMsg is a team task fetched again, nil if it is gone.
+type teamTaskReloadedMsg struct {
+	id   string
+	task *models.Task
+}
+
+// searchHit is a search result and the section it is in.
+type searchHit struct {
+	section string // "personal" or "team"
+	models.SearchResult
+}
+
+type reportLoadedMsg struct {
+	query    models.ReportQuery
+	personal *models.TimeReport
+	team     *models.TimeReport
+	notes    []string
+}
+
+// estimatesLoadedMsg is the estimate report of both sections.
+type estimatesLoadedMsg struct {
+	group  string
+	report *models.EstimateReport
+	notes  []string
+}
+
+// timesheetLoadedMsg is a timesheet fetched or changed, or the error that
+// stopped it.
+type timesheetLoadedMsg struct {
+	sheet *models.Timesheet
+	err   error
+}
+
+type reviewsLoadedMsg struct {
+	sheets []models.Timesheet
+	err    error
+}
+
+type searchResultsMsg struct {
+	query string
+	hits  []searchHit
+	notes []string
+}
+
+// taskConflictMsg reports that a team task changed on the server after we
+// loaded it. mine is their version with our change applied, or nil when the
+// rejected change was a delete.
+type taskConflictMsg struct {
+	theirs models.Task
+	mine   *models.Task
+	retry  func(theirs models.Task) tea.Cmd
+}
+
+// taskBlockedMsg reports that the server refused a change because the task
+// has open blockers. force repeats the change anyway.
+type taskBlockedMsg struct {
+	task     models.Task
+	blockers []models.Task
+	force    tea.Cmd
+}
 
 func (m model) Init() tea.Cmd {
 	return tea.Batch(
 		m.loadPersonalTasks(),
 		m.loadTeamTasks(),
+		m.loadProjects(),
+		m.loadMe(),
+		m.loadTeamWorkflow(),
 		m.connectWebSocket(),
 		m.tick(),
 	)
@@ -84,9 +300,48 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.conflict != nil {
+			return m.handleConflictKeys(msg)
+		}
+		if m.blocked != nil {
+			return m.handleBlockedKeys(msg)
+		}
 		if m.showInput {
 			return m.handleInputKeys(msg)
 		}
+		if m.searching {
+			return m.handleSearchInputKeys(msg)
+		}
+		if m.filtering {
+			return m.handleFilterInputKeys(msg)
+		}
+		if m.showSearch {
+			return m.handleSearchKeys(msg)
+		}
+		if m.showReport {
+			return m.handleReportKeys(msg)
+		}
+		if m.rejecting {
+			return m.handleRejectKeys(msg)
+		}
+		if m.showTimesheet {
+			return m.handleTimesheetKeys(msg)
+		}
+		if m.showReviews {
+			return m.handleReviewKeys(msg)
+		}
+		if m.entryForm != "" {
+			return m.handleEntryFormKeys(msg)
+		}
+		if m.showTrash {
+			return m.handleTrashKeys(msg)
+		}
+		if m.detailTaskID != "" {
+			return m.handleDetailKeys(msg)
+		}
+		if m.showEntries {
+			return m.handleEntryKeys(msg)
+		}
 		return m.handleNormalKeys(msg)
 
 	case personalTasksLoadedMsg:
@@ -94,7 +349,36 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case teamTasksLoadedMsg:
-		m.teamTasks = []models.Task(msg)
+		if msg.filter != m.teamFilter.Values().Encode() {
+			return m, nil // The filter changed since
+		}
+		if msg.cursor == "" {
+			m.teamTasks = msg.tasks
+		} else {
+			m.teamTasks = appendPage(m.teamTasks, msg.tasks)
+		}
+		m.teamNext, m.teamLoading = msg.next, false
+		cmd := m.loadMoreTeamTasks()
+		return m, cmd
+
+	case teamTaskReloadedMsg:
+		m.teamTasks = replaceTask(m.teamTasks, msg.id, msg.task)
+		if m.currentSection == "team" && m.cursor >= len(m.teamTasks) && len(m.teamTasks) > 0 {
+			m.cursor = len(m.teamTasks) - 1
+		}
+		return m, nil
+
+	case projectsLoadedMsg:
+		m.projects = []models.Project(msg)
+		return m, nil
+
+	case meLoadedMsg:
+		m.me = msg.Name
+		m.lead = msg.Lead
+		return m, nil
+
+	case teamWorkflowLoadedMsg:
+		m.teamWorkflow = models.Workflow(msg)
 		return m, nil
 
 	case wsConnectedMsg:
@@ -136,15 +420,148 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, m.loadPersonalTasks()
 		}
 		return m, m.loadTeamTasks()
+
+	case trashLoadedMsg:
+		m.trashTasks = []models.Task(msg)
+		if m.trashCursor >= len(m.trashTasks) && len(m.trashTasks) > 0 {
+			m.trashCursor = len(m.trashTasks) - 1
+		}
+		// A restored personal task only shows up after a reload
+		if m.currentSection == "personal" {
+			return m, m.loadPersonalTasks()
+		}
+		return m, nil
+
+	case taskConflictMsg:
+		m.conflict = &msg
+		return m, nil
+
+	case taskBlockedMsg:
+		m.blocked = &msg
+		return m, nil
+
+	case searchResultsMsg:
+		if msg.query != m.searchQuery {
+			return m, nil // A newer search is under way
+		}
+		m.searchHits = msg.hits
+		m.searchNotes = msg.notes
+		m.searchCursor = 0
+		m.showSearch = true
+		return m, nil
+
+	case reportLoadedMsg:
+		if msg.query != m.reportQuery || m.estimateGroup != "" {
+			return m, nil // The report was changed since
+		}
+		m.reportPersonal = msg.personal
+		m.reportTeam = msg.team
+		m.reportNotes = msg.notes
+		return m, nil
+
+	case estimatesLoadedMsg:
+		if msg.group != m.estimateGroup {
+			return m, nil
+		}
+		m.estimates = msg.report
+		m.reportNotes = msg.notes
+		return m, nil
+
+	case timesheetLoadedMsg:
+		if msg.err != nil {
+			m.timesheetError = msg.err.Error()
+			return m, nil
+		}
+		if !strings.EqualFold(msg.sheet.User, m.timesheetUser) || msg.sheet.WeekStart != models.WeekOf(m.timesheetDay) {
+			return m, nil // Another week is shown by now
+		}
+		m.timesheet = msg.sheet
+		m.timesheetError = ""
+		return m, nil
+
+	case reviewsLoadedMsg:
+		if msg.err != nil {
+			m.timesheetError = msg.err.Error()
+			return m, nil
+		}
+		m.reviews = msg.sheets
+		if m.reviewCursor >= len(m.reviews) && len(m.reviews) > 0 {
+			m.reviewCursor = len(m.reviews) - 1
+		}
+		return m, nil
+
+	case noticeMsg:
+		m.notice = string(msg)
+		return m, nil
+
+	case timeEntriesLoadedMsg:
+		if !m.showEntries || msg.taskID != m.entryTask.ID {
+			return m, nil
+		}
+		m.entries = msg.entries
+		if m.entryCursor >= len(m.entries) {
+			m.entryCursor = len(m.entries) - 1
+		}
+		if m.entryCursor < 0 {
+			m.entryCursor = 0
+		}
+		// Team totals arrive over the WebSocket, personal ones need a reload
+		if m.currentSection == "personal" {
+			return m, m.loadPersonalTasks()
+		}
+		return m, nil
+
+	case timeEntryOperationFailedMsg:
+		m.entryError = msg.err.Error()
+		return m, m.loadTimeEntries(m.entryTask.ID)
 	}
 
 	return m, nil
 }
 
 func (m model) View() string {
+	if m.conflict != nil {
+		return m.renderConflict()
+	}
+	if m.blocked != nil {
+		return m.renderBlocked()
+	}
 	if m.showInput {
 		return m.renderInputMode()
 	}
+	if m.searching {
+		return m.renderSearchPrompt()
+	}
+	if m.filtering {
+		return m.renderFilterPrompt()
+	}
+	if m.showSearch {
+		return m.renderSearch()
+	}
+	if m.showReport {
+		return m.renderReport()
+	}
+	if m.rejecting {
+		return m.renderRejectPrompt()
+	}
+	if m.showTimesheet {
+		return m.renderTimesheet()
+	}
+	if m.showReviews {
+		return m.renderReviews()
+	}
+	if m.entryForm != "" {
+		return m.renderEntryForm()
+	}
+	if m.showTrash {
+		return m.renderTrash()
+	}
+	if m.detailTaskID != "" {
+		return m.renderDetail()
+	}
+	if m.showEntries {
+		return m.renderEntries()
+	}
 
 	var s strings.Builder
 
@@ -161,7 +578,7 @@ func (m model) View() string {
 	// Section tabs
 	personalTab := "Personal Tasks"
 	teamTab := "Team Tasks"
-	
+
 	if m.currentSection == "personal" {
 		personalTab = "▶ " + personalTab + " ◀"
 		teamTab = "  " + teamTab + "  "
@@ -174,29 +591,35 @@ func (m model) View() string {
 	s.WriteString("   ")
 	s.WriteString(normalStyle.Render(teamTab))
 	s.WriteString("\n\n")
-
-	// Get current tasks based on section
-	currentTasks := m.personalTasks
-	if m.currentSection == "team" {
-		currentTasks = m.teamTasks
+	if m.currentSection == "team" && m.teamFilterText != "" {
+		s.WriteString(helpStyle.Render("Filter: " + m.teamFilterText + " (f: change)"))
+		s.WriteString("\n\n")
 	}
 
-	if len(currentTasks) == 0 {
+	rows := m.taskRows()
+	if len(rows) == 0 && m.currentSection == "team" && m.teamFilterText != "" {
+		s.WriteString("No team tasks match the filter.\n\n")
+	} else if len(rows) == 0 {
 		s.WriteString("No tasks yet. Press 'n' to create one!\n\n")
 	} else {
-		for i, task := range currentTasks {
-			line := m.renderTaskLine(i, task)
-			if m.cursor == i {
-				s.WriteString(selectedStyle.Render(line))
-			} else {
-				s.WriteString(normalStyle.Render(line))
-			}
+		for i, row := range rows {
+			s.WriteString(m.renderTaskLine(i, row))
 			s.WriteString("\n")
 		}
 		s.WriteString("\n")
 	}
 
-	s.WriteString(helpStyle.Render("tab: switch • n: new • d: done • s: timer • x: delete • r: refresh • q: quit"))
+	if m.notice != "" {
+		s.WriteString(errorStyle.Render(m.notice))
+		s.WriteString("\n\n")
+	}
+
+	if m.linkingTask.ID != "" {
+		s.WriteString(helpStyle.Render("Pick the task that blocks \"" + m.linkingTask.Title + "\" • b: link/unlink • esc: cancel"))
+		return s.String()
+	}
+
+	s.WriteString(helpStyle.Render("tab: switch • enter: details • /: search • f: filter team • n: new • a: add subtask • ←/→: fold • b: blocked by • e: edit • d/D: next/prev status • s: timer • t: time entries • R: report • W: timesheet • x: delete • T: trash • r: refresh • q: quit"))
 
 	return s.String()
 }
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+// filterKeys are the fields the team filter prompt takes as key:value.
+var filterKeys = map[string]bool{
+	"project": true, "status": true, "tag": true, "assignee": true,
+	"due_from": true, "due_to": true, "created_from": true, "created_to": true,
+}
+
+// parseTeamFilter reads the team list's filter prompt. Words like
+// project:Web, status:todo,review, tag:ui (repeatable), assignee:alice and
+// due_from:, due_to:, created_from: or created_to: with a date filter on
+// those fields; the other words must appear in the title, description or
+// project. Quote a value with spaces, as in project:"Web site".
+func parseTeamFilter(text string) (models.TaskQuery, error) {
+	values := url.Values{}
+	var words []string
+	for _, field := range splitQuoted(text) {
+		if key, value, ok := strings.Cut(field, ":"); ok && filterKeys[key] {
+			values.Add(key, value)
+			continue
+		}
+		words = append(words, field)
+	}
+	values.Set("q", strings.Join(words, " "))
+	return models.ParseTaskQuery(values)
+}
+
+// splitQuoted splits text at spaces outside double quotes and drops the
+// quotes.
+func splitQuoted(text string) []string {
+	var fields []string
+	var field strings.Builder
+	quoted, started := false, false
+	for _, r := range text {
+		switch {
+		case r == '"':
+			quoted, started = !quoted, true
+		case r == ' ' && !quoted:
+			if started {
+				fields = append(fields, field.String())
+			}
+			field.Reset()
+			started = false
+		default:
+			field.WriteRune(r)
+			started = true
+		}
+	}
+	if started {
+		fields = append(fields, field.String())
+	}
+	return fields
+}
@@ -2,18 +2,26 @@ package client
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/ifrunruhin12/tasktime/internal/models"
 )
 
 func (m model) handleNormalKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	// Get current tasks based on section
-	currentTasks := m.personalTasks
-	if m.currentSection == "team" {
-		currentTasks = m.teamTasks
+	// The tasks of the current section in the order they are on screen
+	rows := m.taskRows()
+	currentTasks := make([]models.Task, len(rows))
+	for i, row := range rows {
+		currentTasks[i] = row.task
 	}
 
+	m.notice = ""
+
 	switch msg.String() {
 	case "ctrl+c", "q":
 		if m.ws != nil {
@@ -21,7 +29,39 @@ func (m model) handleNormalKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, tea.Quit
 
+	case "esc":
+		m.linkingTask = models.Task{}
+
+	case "b":
+		if m.currentSection != "team" {
+			m.notice = "Dependencies are only available for team tasks"
+			break
+		}
+		if len(currentTasks) == 0 || m.cursor >= len(currentTasks) {
+			break
+		}
+		selected := currentTasks[m.cursor]
+		if m.linkingTask.ID == "" {
+			m.linkingTask = selected
+			break
+		}
+
+		// Second press: link the task picked first to the selected one,
+		// or unlink them if it is already blocked by it
+		task := m.linkingTask
+		m.linkingTask = models.Task{}
+		if selected.ID == task.ID {
+			break
+		}
+		for _, id := range task.BlockedBy {
+			if id == selected.ID {
+				return m, m.removeTeamDependency(task, selected.ID)
+			}
+		}
+		return m, m.addTeamDependency(task, selected.ID)
+
 	case "tab":
+		m.linkingTask = models.Task{}
 		// Switch between personal and team sections
 		if m.currentSection == "personal" {
 			m.currentSection = "team"
@@ -29,6 +69,8 @@ func (m model) handleNormalKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.currentSection = "personal"
 		}
 		m.cursor = 0 // Reset cursor when switching sections
+		cmd := m.loadMoreTeamTasks()
+		return m, cmd
 
 	case "up", "k":
 		if m.cursor > 0 {
@@ -39,43 +81,104 @@ func (m model) handleNormalKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.cursor < len(currentTasks)-1 {
 			m.cursor++
 		}
+		cmd := m.loadMoreTeamTasks()
+		return m, cmd
 
 	case "n":
-		m.showInput = true
-		m.inputTitle = ""
-		m.inputProject = ""
-		m.inputMode = 0
+		m.openTaskForm(models.Task{})
 
-	case "d":
+	case "a":
+		if len(currentTasks) > 0 && m.cursor < len(currentTasks) {
+			m.openTaskForm(models.Task{ParentID: currentTasks[m.cursor].ID})
+		}
+
+	case "left", "h":
+		if len(rows) > 0 && m.cursor < len(rows) {
+			row := rows[m.cursor]
+			if row.children > 0 && !m.collapsed[row.task.ID] {
+				m.collapsed[row.task.ID] = true
+				break
+			}
+			// Already folded or a leaf: go up to the parent
+			for i := m.cursor - 1; i >= 0; i-- {
+				if rows[i].depth < row.depth {
+					m.cursor = i
+					break
+				}
+			}
+		}
+
+	case "right", "l":
+		if len(rows) > 0 && m.cursor < len(rows) {
+			delete(m.collapsed, rows[m.cursor].task.ID)
+		}
+
+	case "e":
+		if len(currentTasks) > 0 && m.cursor < len(currentTasks) {
+			m.openTaskForm(currentTasks[m.cursor])
+		}
+
+	case "enter":
+		if len(currentTasks) > 0 && m.cursor < len(currentTasks) {
+			m.detailTaskID = currentTasks[m.cursor].ID
+		}
+
+	case "/":
+		m.searching = true
+		m.searchQuery = ""
+
+	case "f":
+		if m.currentSection != "team" {
+			m.notice = "Filters are only available for team tasks"
+			break
+		}
+		m.filtering = true
+		m.filterInput = m.teamFilterText
+		m.filterError = ""
+
+	case "R":
+		m.showReport = true
+		m.reportDay = time.Now()
+		return m, m.openReport("day")
+
+	case "W":
+		return m, m.openTimesheet(m.myName(), time.Now())
+
+	case "d", "]", "D", "[":
 		if len(currentTasks) > 0 && m.cursor < len(currentTasks) {
 			task := currentTasks[m.cursor]
-			newStatus := "done"
-			if task.Status == "done" {
-				newStatus = "todo"
+			forward := msg.String() == "d" || msg.String() == "]"
+			newStatus, ok := m.workflow().Next(task.Status)
+			if !forward {
+				newStatus, ok = m.workflow().Previous(task.Status)
 			}
-			
+			if !ok {
+				m.notice = fmt.Sprintf("%q can't move any further", task.Status)
+				return m, nil
+			}
+
 			if m.currentSection == "personal" {
-				return m, m.updatePersonalTaskStatus(task.ID, newStatus)
+				return m, m.updatePersonalTaskStatus(task, newStatus)
 			} else {
-				return m, m.updateTeamTaskStatus(task.ID, newStatus)
+				return m, m.updateTeamTaskStatus(task, newStatus)
 			}
 		}
 
 	case "s":
 		if len(currentTasks) > 0 && m.cursor < len(currentTasks) {
 			task := currentTasks[m.cursor]
-			
+
 			if m.currentSection == "personal" {
 				if task.IsActive {
-					return m, m.stopPersonalTimer(task.ID)
+					return m, m.stopPersonalTimer(task)
 				} else {
-					return m, m.startPersonalTimer(task.ID)
+					return m, m.startPersonalTimer(task)
 				}
 			} else {
-				if task.IsActive {
-					return m, m.stopTeamTimer(task.ID)
+				if m.runsTimer(task) {
+					return m, m.stopTeamTimer(task)
 				} else {
-					return m, m.startTeamTimer(task.ID)
+					return m, m.startTeamTimer(task)
 				}
 			}
 		}
@@ -83,14 +186,30 @@ func (m model) handleNormalKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "x":
 		if len(currentTasks) > 0 && m.cursor < len(currentTasks) {
 			task := currentTasks[m.cursor]
-			
+
 			if m.currentSection == "personal" {
-				return m, m.deletePersonalTask(task.ID)
+				return m, m.deletePersonalTask(task)
 			} else {
-				return m, m.deleteTeamTask(task.ID)
+				return m, m.deleteTeamTask(task)
 			}
 		}
 
+	case "t":
+		if len(currentTasks) > 0 && m.cursor < len(currentTasks) {
+			m.showEntries = true
+			m.entryTask = currentTasks[m.cursor]
+			m.entries = nil
+			m.entryCursor = 0
+			m.entryError = ""
+			return m, m.loadTimeEntries(m.entryTask.ID)
+		}
+
+	case "T":
+		m.showTrash = true
+		m.trashTasks = nil
+		m.trashCursor = 0
+		return m, m.loadTrash()
+
 	case "r":
 		if m.currentSection == "personal" {
 			return m, m.loadPersonalTasks()
@@ -102,39 +221,638 @@ func (m model) handleNormalKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// openTaskForm shows the input screen, filled in from task when editing
+// one. A task without an ID creates a new task under its ParentID, if set.
+func (m *model) openTaskForm(task models.Task) {
+	m.showInput = true
+	m.inputMode = fieldTitle
+	m.inputError = ""
+	m.editingTask = task
+
+	estimate := ""
+	if task.EstimateSeconds > 0 {
+		estimate = formatEstimate(task.EstimateSeconds)
+	}
+	m.inputs = [taskFieldCount]string{
+		fieldTitle:       task.Title,
+		fieldProject:     task.Project,
+		fieldPriority:    task.Priority,
+		fieldDueDate:     task.DueDate,
+		fieldEstimate:    estimate,
+		fieldTags:        strings.Join(task.Tags, ", "),
+		fieldDescription: task.Description,
+	}
+}
+
 func (m model) handleInputKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "ctrl+c", "esc":
+	switch msg.Type {
+	case tea.KeyCtrlC, tea.KeyEsc:
 		m.showInput = false
+		m.inputError = ""
 		return m, nil
 
-	case "enter":
-		if m.inputMode == 0 && m.inputTitle != "" {
-			m.inputMode = 1
+	case tea.KeyTab, tea.KeyDown:
+		m.inputMode = (m.inputMode + 1) % taskFieldCount
+
+	case tea.KeyShiftTab, tea.KeyUp:
+		m.inputMode = (m.inputMode + taskFieldCount - 1) % taskFieldCount
+
+	case tea.KeyEnter:
+		if m.inputMode == fieldTitle && strings.TrimSpace(m.inputs[fieldTitle]) == "" {
 			return m, nil
-		} else if m.inputMode == 1 {
-			m.showInput = false
-			
-			if m.currentSection == "personal" {
-				return m, m.createPersonalTask(m.inputTitle, m.inputProject)
-			} else {
-				return m, m.createTeamTask(m.inputTitle, m.inputProject)
+		}
+		if m.inputMode < taskFieldCount-1 {
+			m.inputMode++
+			return m, nil
+		}
+		return m.submitTaskForm()
+
+	case tea.KeyRight:
+		if m.inputMode == fieldProject {
+			if suggestion := m.projectSuggestion(m.inputs[fieldProject]); suggestion != "" {
+				m.inputs[fieldProject] = suggestion
 			}
 		}
 
-	case "backspace":
-		if m.inputMode == 0 && len(m.inputTitle) > 0 {
-			m.inputTitle = m.inputTitle[:len(m.inputTitle)-1]
-		} else if m.inputMode == 1 && len(m.inputProject) > 0 {
-			m.inputProject = m.inputProject[:len(m.inputProject)-1]
+	case tea.KeyBackspace:
+		input := []rune(m.inputs[m.inputMode])
+		if len(input) > 0 {
+			m.inputs[m.inputMode] = string(input[:len(input)-1])
 		}
 
-	default:
-		if m.inputMode == 0 {
-			m.inputTitle += msg.String()
-		} else {
-			m.inputProject += msg.String()
+	case tea.KeyRunes, tea.KeySpace:
+		m.inputs[m.inputMode] += string(msg.Runes)
+	}
+
+	return m, nil
+}
+
+// submitTaskForm validates the input screen and creates or updates the task.
+// Validation errors keep the form open and are shown under it.
+func (m model) submitTaskForm() (tea.Model, tea.Cmd) {
+	req, err := m.taskFormRequest()
+	if err == nil {
+		err = req.Validate()
+	}
+	if err != nil {
+		m.inputError = err.Error()
+		return m, nil
+	}
+
+	m.showInput = false
+	m.inputError = ""
+
+	if m.editingTask.ID != "" {
+		changes := models.UpdateTaskRequest{
+			Title:           &req.Title,
+			Project:         &req.Project,
+			Description:     &req.Description,
+			Priority:        &req.Priority,
+			DueDate:         &req.DueDate,
+			EstimateSeconds: &req.EstimateSeconds,
+			Tags:            &req.Tags,
+		}
+		if m.currentSection == "personal" {
+			return m, m.updatePersonalTask(m.editingTask, changes)
+		}
+		return m, m.updateTeamTask(m.editingTask, changes)
+	}
+
+	req.ParentID = m.editingTask.ParentID
+	if m.currentSection == "personal" {
+		return m, m.createPersonalTask(req)
+	}
+	return m, m.createTeamTask(req)
+}
+
+// taskFormRequest parses the input screen. The estimate takes Go durations
+// such as "90m" or "1h30m"; tags are separated by commas.
+func (m model) taskFormRequest() (models.CreateTaskRequest, error) {
+	req := models.CreateTaskRequest{
+		Title:       strings.TrimSpace(m.inputs[fieldTitle]),
+		Project:     strings.TrimSpace(m.inputs[fieldProject]),
+		Priority:    strings.ToLower(strings.TrimSpace(m.inputs[fieldPriority])),
+		DueDate:     strings.TrimSpace(m.inputs[fieldDueDate]),
+		Tags:        models.NormalizeTags(strings.Split(m.inputs[fieldTags], ",")),
+		Description: strings.TrimSpace(m.inputs[fieldDescription]),
+	}
+
+	if estimate := strings.TrimSpace(m.inputs[fieldEstimate]); estimate != "" {
+		d, err := time.ParseDuration(estimate)
+		if err != nil || d < 0 {
+			return req, errors.New("estimate must look like 90m or 1h30m")
+		}
+		req.EstimateSeconds = int(d.Seconds())
+	}
+
+	return req, nil
+}
+
+func (m model) handleDetailKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "esc", "q", "enter":
+		m.detailTaskID = ""
+
+	case "e":
+		if task, ok := m.findTask(m.detailTaskID); ok {
+			m.detailTaskID = ""
+			m.openTaskForm(task)
+		}
+	}
+
+	return m, nil
+}
+
+func (m model) handleEntryKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var selected *models.TimeEntry
+	if m.entryCursor < len(m.entries) {
+		selected = &m.entries[m.entryCursor]
+	}
+
+	switch msg.String() {
+	case "ctrl+c", "esc", "q":
+		m.showEntries = false
+		return m, nil
+
+	case "up", "k":
+		if m.entryCursor > 0 {
+			m.entryCursor--
+		}
+
+	case "down", "j":
+		if m.entryCursor < len(m.entries)-1 {
+			m.entryCursor++
+		}
+
+	case "a":
+		now := time.Now()
+		m.openEntryForm("add", now.Add(-time.Hour).Format(entryTimeLayout), now.Format(entryTimeLayout))
+
+	case "e", "enter":
+		if selected != nil {
+			end := ""
+			if selected.EndTime != nil {
+				end = selected.EndTime.Local().Format(entryTimeLayout)
+			}
+			m.openEntryForm("edit", selected.StartTime.Local().Format(entryTimeLayout), end)
+		}
+
+	case "p":
+		if selected != nil && selected.EndTime != nil {
+			middle := selected.StartTime.Add(selected.EndTime.Sub(selected.StartTime) / 2)
+			m.openEntryForm("split", middle.Local().Format(entryTimeLayout), "")
+		}
+
+	case "x":
+		if selected != nil {
+			m.entryError = ""
+			return m, m.deleteTimeEntry(selected.ID)
+		}
+
+	case "b":
+		// Only team time is invoiced
+		if selected != nil && m.currentSection == "team" {
+			m.entryError = ""
+			return m, m.setEntryBillable(selected.ID, selected.NonBillable)
+		}
+	}
+
+	return m, nil
+}
+
+func (m *model) openEntryForm(form, first, second string) {
+	m.entryForm = form
+	m.entryInputs = [2]string{first, second}
+	m.entryField = 0
+	m.entryError = ""
+}
+
+func (m model) handleEntryFormKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	fields := 2
+	if m.entryForm == "split" {
+		fields = 1
+	}
+
+	switch msg.Type {
+	case tea.KeyCtrlC, tea.KeyEsc:
+		m.entryForm = ""
+		m.entryError = ""
+		return m, nil
+
+	case tea.KeyTab, tea.KeyUp, tea.KeyDown:
+		m.entryField = (m.entryField + 1) % fields
+
+	case tea.KeyEnter:
+		if m.entryField < fields-1 {
+			m.entryField++
+			return m, nil
 		}
+		return m.submitEntryForm()
+
+	case tea.KeyBackspace:
+		input := m.entryInputs[m.entryField]
+		if len(input) > 0 {
+			m.entryInputs[m.entryField] = input[:len(input)-1]
+		}
+
+	case tea.KeyRunes, tea.KeySpace:
+		m.entryInputs[m.entryField] += string(msg.Runes)
+	}
+
+	return m, nil
+}
+
+// submitEntryForm validates the form and turns it into a store or API call.
+// Validation errors keep the form open and are shown under it.
+func (m model) submitEntryForm() (tea.Model, tea.Cmd) {
+	first, err := time.ParseInLocation(entryTimeLayout, strings.TrimSpace(m.entryInputs[0]), time.Local)
+	if err != nil {
+		m.entryError = "Times must look like " + entryTimeLayout
+		return m, nil
+	}
+
+	if m.entryForm == "split" {
+		entry := m.entries[m.entryCursor]
+		if !first.After(entry.StartTime) || !first.Before(*entry.EndTime) {
+			m.entryError = "Split point must fall inside the entry"
+			return m, nil
+		}
+		m.entryForm = ""
+		return m, m.splitTimeEntry(entry, first)
+	}
+
+	second, err := time.ParseInLocation(entryTimeLayout, strings.TrimSpace(m.entryInputs[1]), time.Local)
+	if err != nil {
+		m.entryError = "Times must look like " + entryTimeLayout
+		return m, nil
+	}
+	if !second.After(first) {
+		m.entryError = "End must be after start"
+		return m, nil
+	}
+
+	form := m.entryForm
+	m.entryForm = ""
+	if form == "add" {
+		return m, m.addTimeEntry(m.entryTask.ID, first, second)
+	}
+	return m, m.updateTimeEntry(m.entries[m.entryCursor].ID, first, second)
+}
+
+func (m model) handleTrashKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "esc", "q", "T":
+		m.showTrash = false
+
+	case "up", "k":
+		if m.trashCursor > 0 {
+			m.trashCursor--
+		}
+
+	case "down", "j":
+		if m.trashCursor < len(m.trashTasks)-1 {
+			m.trashCursor++
+		}
+
+	case "r", "enter":
+		if m.trashCursor < len(m.trashTasks) {
+			return m, m.restoreTask(m.trashTasks[m.trashCursor].ID)
+		}
+	}
+
+	return m, nil
+}
+
+// openReport shows the report grouped by group over the range around
+// reportDay.
+func (m *model) openReport(group string) tea.Cmd {
+	from, to := ReportRange(group, m.reportDay)
+	m.reportQuery = models.ReportQuery{GroupBy: group, From: from, To: to}
+	m.reportPersonal = nil
+	m.reportTeam = nil
+	m.reportNotes = nil
+	m.estimateGroup = ""
+	return m.loadReport(m.reportQuery)
+}
+
+// openEstimates shows how done tasks went against their estimates, by
+// project or user, in place of the time report.
+func (m *model) openEstimates(group string) tea.Cmd {
+	m.estimateGroup = group
+	m.estimates = nil
+	m.reportNotes = nil
+	return m.loadEstimates(group)
+}
+
+func (m model) handleReportKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "esc", "q", "R":
+		m.showReport = false
+
+	case "d":
+		return m, m.openReport("day")
+	case "w":
+		return m, m.openReport("week")
+	case "m":
+		return m, m.openReport("month")
+	case "p", "u":
+		group := "project"
+		if msg.String() == "u" {
+			group = "user"
+		}
+		if m.estimateGroup != "" {
+			return m, m.openEstimates(group)
+		}
+		return m, m.openReport(group)
+
+	case "e":
+		if m.estimateGroup != "" {
+			return m, m.openReport(m.reportQuery.GroupBy)
+		}
+		if m.reportQuery.GroupBy == "user" {
+			return m, m.openEstimates("user")
+		}
+		return m, m.openEstimates("project")
+
+	case "left", "h", "right", "l", ".":
+		if m.estimateGroup != "" {
+			break // Estimates don't cover a range
+		}
+		switch msg.String() {
+		case ".":
+			m.reportDay = time.Now()
+		case "left", "h":
+			m.reportDay = shiftReportDay(m.reportQuery.GroupBy, m.reportDay, -1)
+		default:
+			m.reportDay = shiftReportDay(m.reportQuery.GroupBy, m.reportDay, 1)
+		}
+		return m, m.openReport(m.reportQuery.GroupBy)
+	}
+
+	return m, nil
+}
+
+// openTimesheet shows a user's timesheet for the week day falls in.
+func (m *model) openTimesheet(user string, day time.Time) tea.Cmd {
+	m.showTimesheet = true
+	m.timesheetUser = user
+	m.timesheetDay = day
+	m.timesheet = nil
+	m.timesheetError = ""
+	return m.loadTimesheet(user, day)
+}
+
+func (m model) handleTimesheetKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	own := strings.EqualFold(m.timesheetUser, m.myName())
+
+	switch msg.String() {
+	case "ctrl+c", "esc", "q", "W":
+		// Back to the review queue if the timesheet was opened from it
+		m.showTimesheet = false
+		if m.showReviews {
+			return m, m.loadReviews()
+		}
+
+	case "left", "h":
+		return m, m.openTimesheet(m.timesheetUser, m.timesheetDay.AddDate(0, 0, -7))
+	case "right", "l":
+		return m, m.openTimesheet(m.timesheetUser, m.timesheetDay.AddDate(0, 0, 7))
+	case ".":
+		return m, m.openTimesheet(m.timesheetUser, time.Now())
+
+	case "s":
+		if own && m.timesheet != nil {
+			return m, m.changeTimesheet(*m.timesheet, "submit", "")
+		}
+	case "a":
+		if m.lead && !own && m.timesheet != nil {
+			return m, m.changeTimesheet(*m.timesheet, "approve", "")
+		}
+	case "x":
+		if m.lead && !own && m.timesheet != nil {
+			m.rejecting = true
+			m.rejectComment = ""
+		}
+
+	case "L":
+		if m.lead {
+			m.showTimesheet = false
+			m.showReviews = true
+			m.timesheetError = ""
+			return m, m.loadReviews()
+		}
+	}
+
+	return m, nil
+}
+
+// handleRejectKeys reads the comment a rejected timesheet goes back with.
+func (m model) handleRejectKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC, tea.KeyEsc:
+		m.rejecting = false
+
+	case tea.KeyEnter:
+		comment := strings.TrimSpace(m.rejectComment)
+		if comment == "" || m.timesheet == nil {
+			return m, nil
+		}
+		m.rejecting = false
+		return m, m.changeTimesheet(*m.timesheet, "reject", comment)
+
+	case tea.KeyBackspace:
+		input := []rune(m.rejectComment)
+		if len(input) > 0 {
+			m.rejectComment = string(input[:len(input)-1])
+		}
+
+	case tea.KeyRunes, tea.KeySpace:
+		m.rejectComment += string(msg.Runes)
+	}
+
+	return m, nil
+}
+
+func (m model) handleReviewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "esc", "q", "L":
+		m.showReviews = false
+
+	case "up", "k":
+		if m.reviewCursor > 0 {
+			m.reviewCursor--
+		}
+
+	case "down", "j":
+		if m.reviewCursor < len(m.reviews)-1 {
+			m.reviewCursor++
+		}
+
+	case "r":
+		return m, m.loadReviews()
+
+	case "enter":
+		if m.reviewCursor < len(m.reviews) {
+			sheet := m.reviews[m.reviewCursor]
+			week, err := time.ParseInLocation(models.DueDateLayout, sheet.WeekStart, time.Local)
+			if err != nil {
+				return m, nil
+			}
+			return m, m.openTimesheet(sheet.User, week)
+		}
+	}
+
+	return m, nil
+}
+
+func (m model) handleSearchInputKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC, tea.KeyEsc:
+		m.searching = false
+
+	case tea.KeyEnter:
+		query := strings.TrimSpace(m.searchQuery)
+		if query == "" {
+			return m, nil
+		}
+		m.searching = false
+		m.searchQuery = query
+		return m, m.searchTasks(query)
+
+	case tea.KeyBackspace:
+		input := []rune(m.searchQuery)
+		if len(input) > 0 {
+			m.searchQuery = string(input[:len(input)-1])
+		}
+
+	case tea.KeyRunes, tea.KeySpace:
+		m.searchQuery += string(msg.Runes)
+	}
+
+	return m, nil
+}
+
+// handleFilterInputKeys edits the team list's filter. Enter applies it and
+// loads the list again from the first page; an empty filter shows all.
+func (m model) handleFilterInputKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC, tea.KeyEsc:
+		m.filtering = false
+
+	case tea.KeyEnter:
+		text := strings.TrimSpace(m.filterInput)
+		filter, err := parseTeamFilter(text)
+		if err != nil {
+			m.filterError = err.Error()
+			return m, nil
+		}
+		m.filtering = false
+		m.teamFilter, m.teamFilterText = filter, text
+		m.teamNext, m.teamLoading = "", false
+		m.cursor = 0
+		return m, m.loadTeamTasks()
+
+	case tea.KeyBackspace:
+		input := []rune(m.filterInput)
+		if len(input) > 0 {
+			m.filterInput = string(input[:len(input)-1])
+		}
+
+	case tea.KeyRunes, tea.KeySpace:
+		m.filterInput += string(msg.Runes)
+	}
+
+	return m, nil
+}
+
+func (m model) handleSearchKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "esc", "q":
+		m.showSearch = false
+
+	case "up", "k":
+		if m.searchCursor > 0 {
+			m.searchCursor--
+		}
+
+	case "down", "j":
+		if m.searchCursor < len(m.searchHits)-1 {
+			m.searchCursor++
+		}
+
+	case "/":
+		m.showSearch = false
+		m.searching = true
+		m.searchQuery = ""
+
+	case "enter":
+		if m.searchCursor < len(m.searchHits) {
+			hit := m.searchHits[m.searchCursor]
+			m.showSearch = false
+			m.jumpTo(hit.section, hit.Task)
+		}
+	}
+
+	return m, nil
+}
+
+// jumpTo switches to a section and puts the cursor on a task, unfolding
+// its parents. A team task the list hasn't loaded yet is added to it.
+func (m *model) jumpTo(section string, task models.Task) {
+	m.currentSection = section
+	if section == "team" {
+		if _, ok := m.findTask(task.ID); !ok {
+			m.teamTasks = replaceTask(m.teamTasks, task.ID, &task)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for parent, ok := m.findTask(task.ParentID); ok && !seen[parent.ID]; parent, ok = m.findTask(parent.ParentID) {
+		seen[parent.ID] = true
+		delete(m.collapsed, parent.ID)
+	}
+
+	for i, row := range m.taskRows() {
+		if row.task.ID == task.ID {
+			m.cursor = i
+			return
+		}
+	}
+	m.notice = "\"" + task.Title + "\" is no longer in the list"
+}
+
+func (m model) handleConflictKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "o":
+		// Overwrite: repeat our change on top of their version
+		retry := m.conflict.retry(m.conflict.theirs)
+		m.conflict = nil
+		return m, retry
+
+	case "ctrl+c", "esc", "t":
+		// Keep theirs: drop our change and show the task as it is now
+		theirs := m.conflict.theirs
+		for i, task := range m.teamTasks {
+			if task.ID == theirs.ID {
+				m.teamTasks[i] = theirs
+				break
+			}
+		}
+		m.conflict = nil
+	}
+
+	return m, nil
+}
+
+func (m model) handleBlockedKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "f":
+		force := m.blocked.force
+		m.blocked = nil
+		return m, force
+
+	case "ctrl+c", "esc", "n":
+		m.blocked = nil
 	}
 
 	return m, nil
@@ -154,11 +872,20 @@ func (m model) handleWebSocketMessage(msg models.WSMessage) (tea.Model, tea.Cmd)
 					break
 				}
 			}
-			if !exists {
+			if !exists && m.teamFilter.Matches(task, nil) {
 				m.teamTasks = append([]models.Task{task}, m.teamTasks...)
 			}
 		}
 
+	case "task.restored":
+		taskBytes, _ := json.Marshal(msg.Payload)
+		var task models.Task
+		// Its time entries aren't sent, so an assignee filter only sees
+		// its timers
+		if json.Unmarshal(taskBytes, &task) == nil && m.teamFilter.Matches(task, nil) {
+			m.teamTasks = insertByCreatedAt(m.teamTasks, task)
+		}
+
 	case "task.updated":
 		taskBytes, _ := json.Marshal(msg.Payload)
 		var updatedTask models.Task
@@ -171,6 +898,25 @@ func (m model) handleWebSocketMessage(msg models.WSMessage) (tea.Model, tea.Cmd)
 			}
 		}
 
+	case "task.over_estimate":
+		overBytes, _ := json.Marshal(msg.Payload)
+		var over models.OverEstimate
+		if json.Unmarshal(overBytes, &over) == nil {
+			m.notice = fmt.Sprintf("%q went over its estimate of %s", over.Task.Title, formatEstimate(over.Task.EstimateSeconds))
+		}
+
+	case "timesheet.submitted", "timesheet.approved", "timesheet.rejected":
+		sheetBytes, _ := json.Marshal(msg.Payload)
+		var sheet models.Timesheet
+		if json.Unmarshal(sheetBytes, &sheet) != nil {
+			break
+		}
+		return m.timesheetChanged(sheet)
+
+	case "project.created", "project.updated", "project.deleted":
+		// Creating, renaming or deleting a project can change tasks too
+		return m, tea.Batch(m.loadProjects(), m.loadTeamTasks(), m.listenWebSocket())
+
 	case "task.deleted":
 		if payload, ok := msg.Payload.(map[string]interface{}); ok {
 			if taskID, ok := payload["id"].(string); ok {
@@ -190,3 +936,82 @@ func (m model) handleWebSocketMessage(msg models.WSMessage) (tea.Model, tea.Cmd)
 
 	return m, m.listenWebSocket()
 }
+
+// timesheetChanged tells us about our timesheets being reviewed and, if we
+// lead, others' being submitted, and refreshes the timesheet screens.
+func (m model) timesheetChanged(sheet models.Timesheet) (tea.Model, tea.Cmd) {
+	own := strings.EqualFold(sheet.User, m.myName())
+	switch {
+	case own && sheet.Status == models.TimesheetApproved:
+		m.notice = fmt.Sprintf("%s approved your timesheet for the week of %s", sheet.ReviewedBy, sheet.WeekStart)
+	case own && sheet.Status == models.TimesheetRejected:
+		m.notice = fmt.Sprintf("%s rejected your timesheet for the week of %s: %s", sheet.ReviewedBy, sheet.WeekStart, sheet.Comment)
+	case !own && m.lead && sheet.Status == models.TimesheetSubmitted:
+		m.notice = fmt.Sprintf("%s submitted their timesheet for the week of %s", sheet.User, sheet.WeekStart)
+	}
+
+	if m.showTimesheet && strings.EqualFold(sheet.User, m.timesheetUser) && sheet.WeekStart == models.WeekOf(m.timesheetDay) {
+		m.timesheet = &sheet
+	}
+	if m.showReviews {
+		return m, tea.Batch(m.loadReviews(), m.listenWebSocket())
+	}
+	return m, m.listenWebSocket()
+}
+
+// insertByCreatedAt puts a task back into a newest-first list at the place
+// it was created, unless the list already has it.
+func insertByCreatedAt(tasks []models.Task, task models.Task) []models.Task {
+	pos := len(tasks)
+	for i, existing := range tasks {
+		if existing.ID == task.ID {
+			return tasks
+		}
+		if pos == len(tasks) && existing.CreatedAt.Before(task.CreatedAt) {
+			pos = i
+		}
+	}
+
+	tasks = append(tasks, models.Task{})
+	copy(tasks[pos+1:], tasks[pos:])
+	tasks[pos] = task
+	return tasks
+}
+
+// appendPage adds a page of tasks to a newest-first list, leaving out those
+// it already has, and sorts the list once.
+func appendPage(tasks, page []models.Task) []models.Task {
+	have := make(map[string]bool, len(tasks)+len(page))
+	for _, task := range tasks {
+		have[task.ID] = true
+	}
+	for _, task := range page {
+		if !have[task.ID] {
+			have[task.ID] = true
+			tasks = append(tasks, task)
+		}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
+	})
+	return tasks
+}
+
+// replaceTask swaps the task with the given ID for task, or removes it when
+// task is nil. A task the list doesn't have yet is inserted.
+func replaceTask(tasks []models.Task, id string, task *models.Task) []models.Task {
+	for i, existing := range tasks {
+		if existing.ID != id {
+			continue
+		}
+		if task == nil {
+			return append(tasks[:i], tasks[i+1:]...)
+		}
+		tasks[i] = *task
+		return tasks
+	}
+	if task == nil {
+		return tasks
+	}
+	return insertByCreatedAt(tasks, *task)
+}
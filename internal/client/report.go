@@ -0,0 +1,304 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+	"github.com/ifrunruhin12/tasktime/internal/storage"
+)
+
+// ReportRange returns the dates a report grouped by group shows by default
+// around day: its week for days, projects and users, the eight weeks up to
+// it for weeks and the twelve months up to it for months.
+func ReportRange(group string, day time.Time) (from, to string) {
+	week := models.WeekStart(day)
+	switch group {
+	case "week":
+		return week.AddDate(0, 0, -7*7).Format(models.DueDateLayout), week.AddDate(0, 0, 6).Format(models.DueDateLayout)
+	case "month":
+		month := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		return month.AddDate(0, -11, 0).Format(models.DueDateLayout), month.AddDate(0, 1, -1).Format(models.DueDateLayout)
+	default:
+		return week.Format(models.DueDateLayout), week.AddDate(0, 0, 6).Format(models.DueDateLayout)
+	}
+}
+
+// shiftReportDay moves a day in a report's range to the next (or, with a
+// negative step, previous) range of ReportRange.
+func shiftReportDay(group string, day time.Time, step int) time.Time {
+	switch group {
+	case "week":
+		return day.AddDate(0, 0, 8*7*step)
+	case "month":
+		return day.AddDate(0, 12*step, 0)
+	default:
+		return day.AddDate(0, 0, 7*step)
+	}
+}
+
+// timeReports adds up personal time from the local task file and team time
+// from the server. A side that can't be reached comes back empty, with a
+// note saying why.
+func (c *Client) timeReports(local storage.TaskStore, query models.ReportQuery) (personal, team *models.TimeReport, notes []string) {
+	personal = models.NewTimeReport(query)
+	if reporter, ok := local.(storage.ReportStore); ok {
+		// Personal entries have no user, they are all ours
+		personalQuery := query
+		personalQuery.User = ""
+		report, err := reporter.TimeReport(personalQuery)
+		if err != nil {
+			notes = append(notes, "Personal time is missing: "+err.Error())
+		} else {
+			personal = report
+		}
+		if query.GroupBy == "user" {
+			for i := range personal.Rows {
+				personal.Rows[i].Key = c.user
+			}
+		}
+	}
+
+	team = models.NewTimeReport(query)
+	if _, err := c.request("GET", "/api/v1/reports/time?"+query.Values().Encode(), 0, nil, team); err != nil {
+		team = models.NewTimeReport(query)
+		notes = append(notes, "Team time is missing: "+err.Error())
+	}
+
+	return personal, team, notes
+}
+
+// reportLine is one row of a report as shown: a group's personal and team
+// time side by side.
+type reportLine struct {
+	label    string
+	personal int
+	team     int
+}
+
+// reportLines merges a personal and a team report. Periods are listed in
+// order, empty ones included; projects and users with the most time come
+// first.
+func reportLines(personal, team *models.TimeReport) []reportLine {
+	merged := models.NewTimeReport(models.ReportQuery{GroupBy: personal.GroupBy})
+	merged.Merge(*personal)
+	merged.Merge(*team)
+
+	keys := periodKeys(personal.GroupBy, personal.From, personal.To)
+	if keys == nil {
+		for _, row := range merged.Rows {
+			keys = append(keys, row.Key)
+		}
+	} else {
+		// Entries outside the range can't show up, but keep them if they do
+		known := make(map[string]bool, len(keys))
+		for _, key := range keys {
+			known[key] = true
+		}
+		for _, row := range merged.Rows {
+			if !known[row.Key] {
+				keys = append(keys, row.Key)
+			}
+		}
+		sort.Strings(keys)
+	}
+
+	lines := make([]reportLine, len(keys))
+	for i, key := range keys {
+		lines[i] = reportLine{
+			label:    reportLabel(personal.GroupBy, key),
+			personal: personal.Seconds(key),
+			team:     team.Seconds(key),
+		}
+	}
+	return lines
+}
+
+// periodKeys lists the report keys of every day, week or month from one
+// date to another, or nil for other groupings or an open range.
+func periodKeys(group, from, to string) []string {
+	start, err := time.ParseInLocation(models.DueDateLayout, from, time.Local)
+	if err != nil {
+		return nil
+	}
+	end, err := time.ParseInLocation(models.DueDateLayout, to, time.Local)
+	if err != nil {
+		return nil
+	}
+
+	var keys []string
+	switch group {
+	case "day":
+		for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+			keys = append(keys, day.Format(models.DueDateLayout))
+		}
+	case "week":
+		for week := models.WeekStart(start); !week.After(end); week = week.AddDate(0, 0, 7) {
+			keys = append(keys, week.Format(models.DueDateLayout))
+		}
+	case "month":
+		for month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.Local); !month.After(end); month = month.AddDate(0, 1, 0) {
+			keys = append(keys, month.Format("2006-01"))
+		}
+	}
+	return keys
+}
+
+// reportLabel turns a report key into something to show.
+func reportLabel(group, key string) string {
+	switch group {
+	case "project":
+		if key == "" {
+			return "(no project)"
+		}
+	case "user":
+		if key == "" {
+			return "(nobody)"
+		}
+	case "day":
+		if day, err := time.Parse(models.DueDateLayout, key); err == nil {
+			return day.Format("Mon 2006-01-02")
+		}
+	case "week":
+		return "Week of " + key
+	case "month":
+		if month, err := time.Parse("2006-01", key); err == nil {
+			return month.Format("Jan 2006")
+		}
+	}
+	return key
+}
+
+// formatHours renders time in a report as hours and minutes, or "-" for
+// none.
+func formatHours(seconds int) string {
+	if seconds == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d:%02d", seconds/3600, seconds%3600/60)
+}
+
+// PrintReport writes a report of personal and team time to w. Personal
+// time comes from the local task file and team time from the server; if
+// either can't be read, the report says so and shows the other.
+func (c *Client) PrintReport(w io.Writer, query models.ReportQuery) error {
+	var local storage.TaskStore
+	if store, err := storage.NewLocalStore(); err == nil {
+		local = store
+	}
+	personal, team, notes := c.timeReports(local, query)
+
+	fmt.Fprintf(w, "Time by %s, %s to %s\n\n", query.GroupBy, orOpen(query.From), orOpen(query.To))
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tPERSONAL\tTEAM\tTOTAL\t\n", reportHeading(query.GroupBy))
+	var totalPersonal, totalTeam int
+	for _, line := range reportLines(personal, team) {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t\n", line.label, formatHours(line.personal), formatHours(line.team), formatHours(line.personal+line.team))
+		totalPersonal += line.personal
+		totalTeam += line.team
+	}
+	fmt.Fprintf(tw, "TOTAL\t%s\t%s\t%s\t\n", formatHours(totalPersonal), formatHours(totalTeam), formatHours(totalPersonal+totalTeam))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, note := range notes {
+		fmt.Fprintln(w, "\n"+note)
+	}
+	return nil
+}
+
+func reportHeading(group string) string {
+	switch group {
+	case "project":
+		return "PROJECT"
+	case "user":
+		return "USER"
+	case "week":
+		return "WEEK"
+	case "month":
+		return "MONTH"
+	default:
+		return "DAY"
+	}
+}
+
+func orOpen(date string) string {
+	if date == "" {
+		return "…"
+	}
+	return date
+}
+
+// estimateReport compares estimates with the time taken on both personal
+// and team tasks, grouped by project or user; personal tasks count as ours.
+// A side that can't be read is left out, with a note saying why.
+func (c *Client) estimateReport(local storage.TaskStore, group string) (report *models.EstimateReport, notes []string) {
+	report = models.NewEstimateReport(group)
+	if local != nil {
+		personal, err := storage.EstimateReport(local, group)
+		if err != nil {
+			notes = append(notes, "Personal tasks are missing: "+err.Error())
+		} else {
+			// Personal entries have no user, they are all ours
+			if group == "user" {
+				for i := range personal.Rows {
+					personal.Rows[i].Key = c.user
+				}
+			}
+			report.Merge(*personal)
+		}
+	}
+
+	team := models.NewEstimateReport(group)
+	if _, err := c.request("GET", "/api/v1/reports/estimates?group_by="+group, 0, nil, team); err != nil {
+		notes = append(notes, "Team tasks are missing: "+err.Error())
+	} else {
+		report.Merge(*team)
+	}
+
+	return report, notes
+}
+
+// formatRatio renders an estimate report's time per estimated hour, or "-"
+// without an estimate.
+func formatRatio(row models.EstimateRow) string {
+	if row.EstimateSeconds == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f×", row.Ratio())
+}
+
+// PrintEstimates writes how long done tasks took against their estimates,
+// per project or user, to w. Personal tasks come from the local task file
+// and team tasks from the server; if either can't be read, the report says
+// so and shows the other.
+func (c *Client) PrintEstimates(w io.Writer, group string) error {
+	var local storage.TaskStore
+	if store, err := storage.NewLocalStore(); err == nil {
+		local = store
+	}
+	report, notes := c.estimateReport(local, group)
+
+	fmt.Fprintf(w, "Estimates of done tasks by %s\n\n", group)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tTASKS\tOVER\tESTIMATED\tTOOK\tRATIO\t\n", reportHeading(group))
+	for _, row := range report.Rows {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\t\n", reportLabel(group, row.Key), row.Tasks, row.Over,
+			formatHours(row.EstimateSeconds), formatHours(row.ActualSeconds), formatRatio(row))
+	}
+	total := report.Total
+	fmt.Fprintf(tw, "TOTAL\t%d\t%d\t%s\t%s\t%s\t\n", total.Tasks, total.Over,
+		formatHours(total.EstimateSeconds), formatHours(total.ActualSeconds), formatRatio(total))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, note := range notes {
+		fmt.Fprintln(w, "\n"+note)
+	}
+	return nil
+}
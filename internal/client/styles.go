@@ -19,4 +19,24 @@ var (
 
 	helpStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("8"))
-)
\ No newline at end of file
+
+	errorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("9"))
+
+	highlightStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("11")).
+			Underline(true)
+)
+
+// projectStyle colors a project tag. color is a project's "#RRGGBB" or ANSI
+// color number.
+func projectStyle(color string) lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(color))
+}
+
+// statusStyle is a status badge on the given workflow color.
+func statusStyle(color string) lipgloss.Style {
+	return lipgloss.NewStyle().
+		Foreground(lipgloss.Color("15")).
+		Background(lipgloss.Color(color))
+}
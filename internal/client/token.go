@@ -0,0 +1,96 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+// tokenEnv overrides the stored API token.
+const tokenEnv = "TASKTIME_TOKEN"
+
+// tokenPath is where `tasktime login` keeps the API token for the team
+// server, readable only by its owner.
+func tokenPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".tasktime", "token"), nil
+}
+
+// loadToken returns the API token from $TASKTIME_TOKEN or the token file,
+// or "" if there is none.
+func loadToken() string {
+	if token := strings.TrimSpace(os.Getenv(tokenEnv)); token != "" {
+		return token
+	}
+
+	path, err := tokenPath()
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// SaveToken stores the API token the client sends to the team server.
+func SaveToken(token string) error {
+	path, err := tokenPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strings.TrimSpace(token)+"\n"), 0600)
+}
+
+// DeleteToken removes the stored API token. Having none is not an error.
+func DeleteToken() error {
+	path, err := tokenPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// CheckToken asks the server who a token belongs to.
+func CheckToken(serverURL, token string) (*models.User, error) {
+	req, err := http.NewRequest("GET", serverURL+"/api/v1/me", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, errors.New("the server rejected this token")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response from server: %s", resp.Status)
+	}
+
+	var user models.User
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+	"github.com/ifrunruhin12/tasktime/internal/storage"
+)
+
+// Export writes the personal tasks, or with team the server's tasks, and
+// their time entries to w as JSON or CSV.
+func (c *Client) Export(w io.Writer, team bool, format string) error {
+	var export *models.Export
+	if team {
+		export = &models.Export{}
+		if _, err := c.request("GET", "/api/v1/export", 0, nil, export); err != nil {
+			return err
+		}
+	} else {
+		store, err := storage.NewLocalStore()
+		if err != nil {
+			return err
+		}
+		if export, err = storage.Export(store); err != nil {
+			return err
+		}
+	}
+
+	return models.WriteExport(w, format, export)
+}
+
+// Import reads an export in the given format from r and adds its tasks to
+// the personal ones, or with team to the server's. Tasks and time entries
+// that are already there are skipped; see storage.Import.
+func (c *Client) Import(r io.Reader, team bool, format string) (*models.ImportResult, error) {
+	export, err := models.ReadExport(r, format)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", format, err)
+	}
+
+	if team {
+		result := &models.ImportResult{}
+		if _, err := c.request("POST", "/api/v1/import", 0, export, result); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	workflow, _ := loadPersonalWorkflow()
+	if err := export.Validate(workflow); err != nil {
+		return nil, err
+	}
+	store, err := storage.NewLocalStore()
+	if err != nil {
+		return nil, err
+	}
+	return storage.Import(store, export)
+}
+
+// WriteCalendar writes the personal time entries and due dates to w as an
+// iCalendar file; the team's come from the server's calendar feed.
+func (c *Client) WriteCalendar(w io.Writer) error {
+	store, err := storage.NewLocalStore()
+	if err != nil {
+		return err
+	}
+	export, err := storage.Export(store)
+	if err != nil {
+		return err
+	}
+
+	return models.WriteCalendar(w, "TaskTime - personal", export.Tasks)
+}
@@ -2,12 +2,47 @@ package client
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/ifrunruhin12/tasktime/internal/models"
 )
 
+// entryTimeLayout is how times are shown and typed in the time entry editor.
+const entryTimeLayout = "2006-01-02 15:04"
+
+// Fields of the task input screen, in the order Enter walks through them.
+const (
+	fieldTitle = iota
+	fieldProject
+	fieldPriority
+	fieldDueDate
+	fieldEstimate
+	fieldTags
+	fieldDescription
+	taskFieldCount
+)
+
+var taskFieldLabels = [taskFieldCount]string{
+	fieldTitle:       "Title",
+	fieldProject:     "Project",
+	fieldPriority:    "Priority",
+	fieldDueDate:     "Due",
+	fieldEstimate:    "Estimate",
+	fieldTags:        "Tags",
+	fieldDescription: "Description",
+}
+
+var taskFieldHints = [taskFieldCount]string{
+	fieldPriority:    strings.Join(models.Priorities, "/"),
+	fieldDueDate:     "YYYY-MM-DD",
+	fieldEstimate:    "e.g. 90m or 1h30m",
+	fieldTags:        "comma separated",
+	fieldDescription: "markdown",
+}
+
 func (m model) renderInputMode() string {
 	var s strings.Builder
 
@@ -15,62 +50,1010 @@ func (m model) renderInputMode() string {
 	if m.currentSection == "team" {
 		sectionName = "Team"
 	}
-	
-	s.WriteString(titleStyle.Render(fmt.Sprintf("Create New %s Task", sectionName)))
+
+	heading := fmt.Sprintf("Create New %s Task", sectionName)
+	if m.editingTask.ID != "" {
+		heading = fmt.Sprintf("Edit %s Task", sectionName)
+	} else if parent, ok := m.findTask(m.editingTask.ParentID); ok {
+		heading = "New Subtask of " + parent.Title
+	}
+	s.WriteString(titleStyle.Render(heading))
 	s.WriteString("\n\n")
 
-	if m.inputMode == 0 {
-		s.WriteString(fmt.Sprintf("Title: %s█\n", m.inputTitle))
-		s.WriteString("Project: \n\n")
-	} else {
-		s.WriteString(fmt.Sprintf("Title: %s\n", m.inputTitle))
-		s.WriteString(fmt.Sprintf("Project: %s█\n\n", m.inputProject))
+	for i, label := range taskFieldLabels {
+		cursor := ""
+		if m.inputMode == i {
+			cursor = "█"
+		}
+		line := fmt.Sprintf("%-12s %s%s", label+":", m.inputs[i], cursor)
+		if m.inputMode == i && i == fieldProject {
+			if suggestion := m.projectSuggestion(m.inputs[i]); suggestion != "" {
+				rest := string([]rune(suggestion)[len([]rune(m.inputs[i])):])
+				line += helpStyle.Render(rest) + "  " + helpStyle.Render("→ "+suggestion)
+			}
+		} else if m.inputMode == i && taskFieldHints[i] != "" {
+			line += "  " + helpStyle.Render(taskFieldHints[i])
+		}
+		s.WriteString(line + "\n")
+	}
+	s.WriteString("\n")
+
+	if m.inputError != "" {
+		s.WriteString(errorStyle.Render(m.inputError))
+		s.WriteString("\n\n")
 	}
 
-	s.WriteString(helpStyle.Render("Enter to continue • Esc to cancel"))
+	s.WriteString(helpStyle.Render("Enter to continue • Tab/↑↓ to switch field • Esc to cancel"))
 	return s.String()
 }
 
-func (m model) renderTaskLine(index int, task models.Task) string {
+// taskRow is one line of the task tree.
+type taskRow struct {
+	task     models.Task
+	depth    int
+	children int
+	seconds  int // the task's and its subtasks' time, running timers included
+}
+
+// taskRows lays out the current section as a tree: top-level tasks in list
+// order, each followed by its subtasks oldest first, unless it is
+// collapsed. Subtasks whose parent isn't in the list show at the top level.
+func (m model) taskRows() []taskRow {
+	tasks := m.personalTasks
+	if m.currentSection == "team" {
+		tasks = m.teamTasks
+	}
+
+	present := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		present[task.ID] = true
+	}
+
+	var roots []models.Task
+	children := make(map[string][]models.Task)
+	for _, task := range tasks {
+		if task.ParentID != "" && present[task.ParentID] {
+			children[task.ParentID] = append(children[task.ParentID], task)
+		} else {
+			roots = append(roots, task)
+		}
+	}
+	for _, list := range children {
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i].CreatedAt.Before(list[j].CreatedAt)
+		})
+	}
+
+	seen := make(map[string]bool)
+	now := time.Now()
+	var seconds func(task models.Task) int
+	seconds = func(task models.Task) int {
+		total := task.LiveSeconds(now)
+		for _, child := range children[task.ID] {
+			total += seconds(child)
+		}
+		return total
+	}
+
+	var rows []taskRow
+	var walk func(task models.Task, depth int)
+	walk = func(task models.Task, depth int) {
+		if seen[task.ID] {
+			return
+		}
+		seen[task.ID] = true
+
+		rows = append(rows, taskRow{
+			task:     task,
+			depth:    depth,
+			children: len(children[task.ID]),
+			seconds:  seconds(task),
+		})
+		if !m.collapsed[task.ID] {
+			for _, child := range children[task.ID] {
+				walk(child, depth+1)
+			}
+		}
+	}
+	for _, task := range roots {
+		walk(task, 0)
+	}
+
+	return rows
+}
+
+// timerUsers lists who has a timer running on a task. Timers nobody owns,
+// such as personal ones, are left out.
+func timerUsers(task models.Task) []string {
+	var users []string
+	for _, timer := range task.Timers {
+		if timer.User != "" {
+			users = append(users, timer.User)
+		}
+	}
+	return users
+}
+
+func (m model) renderTaskLine(index int, row taskRow) string {
+	task := row.task
+
 	cursor := "  "
 	if m.cursor == index {
 		cursor = "▶ "
 	}
 
-	status := "○"
-	if task.Status == "done" {
-		status = "●"
+	// Each part is styled on its own so colored parts keep the row's
+	// background
+	style := normalStyle
+	if m.cursor == index {
+		style = selectedStyle
 	}
 
-	// Calculate total time including current session
-	totalSeconds := task.TotalTimeSeconds
-	if task.IsActive && task.StartTime != nil && !task.StartTime.IsZero() {
-		currentSession := time.Since(*task.StartTime)
-		totalSeconds += int(currentSession.Seconds())
+	fold := ""
+	if row.children > 0 {
+		fold = "▾ "
+		if m.collapsed[task.ID] {
+			fold = fmt.Sprintf("▸ (%d) ", row.children)
+		}
+	}
+	indent := strings.Repeat("  ", row.depth)
+
+	// Total time including current sessions, subtasks' included
+	totalSeconds := row.seconds
+
+	// A task past its estimate turns red from end to end
+	over := task.EstimateSeconds > 0 && totalSeconds > task.EstimateSeconds
+	if over {
+		style = errorStyle.Inherit(style)
 	}
 
 	// Format time display
 	timer := ""
-	if totalSeconds > 0 || task.IsActive {
-		hours := totalSeconds / 3600
-		minutes := (totalSeconds % 3600) / 60
-		seconds := totalSeconds % 60
-
-		if hours > 0 {
-			timer = fmt.Sprintf(" %02d:%02d:%02d", hours, minutes, seconds)
-		} else {
-			timer = fmt.Sprintf(" %02d:%02d", minutes, seconds)
+	if totalSeconds > 0 || task.IsActive || task.EstimateSeconds > 0 {
+		timer = " " + formatDuration(totalSeconds)
+		if task.EstimateSeconds > 0 {
+			timer += " / " + formatEstimate(task.EstimateSeconds) + " " + estimateBar(totalSeconds, task.EstimateSeconds)
 		}
 
 		if task.IsActive {
 			timer += " ▶"
+			if users := timerUsers(task); len(users) > 0 {
+				timer += " " + strings.Join(users, ", ")
+			}
 		}
 	}
 
 	project := ""
 	if task.Project != "" {
-		project = fmt.Sprintf(" [%s]", task.Project)
+		tag := style
+		if color := m.projectColor(task); color != "" && !over {
+			tag = projectStyle(color).Inherit(style)
+		}
+		project = style.Render(" ") + tag.Render("["+task.Project+"]")
+	}
+
+	lock := ""
+	if m.isBlocked(task) {
+		lock = "🔒 "
+	}
+
+	return style.Render(cursor+indent) +
+		m.statusBadge(task.Status, over) +
+		style.Render(" "+fold+lock+priorityMark(task.Priority)+task.Title) +
+		project +
+		style.Render(taskDetails(task)) +
+		style.Render(timer)
+}
+
+// estimateBarWidth is how many cells the bar of a task's estimate takes.
+const estimateBarWidth = 10
+
+// estimateBar shows how much of an estimate is used up, full once it is
+// exceeded.
+func estimateBar(seconds, estimate int) string {
+	filled := estimateBarWidth
+	if seconds < estimate {
+		filled = seconds * estimateBarWidth / estimate
+	}
+	return "[" + strings.Repeat("█", filled) + strings.Repeat("░", estimateBarWidth-filled) + "]"
+}
+
+// statusBadge renders a status in its workflow color, with red text on a
+// task that is over its estimate.
+func (m model) statusBadge(status string, over bool) string {
+	color, ok := m.workflow().Colors[status]
+	if !ok {
+		color = "8"
+	}
+	style := statusStyle(color)
+	if over {
+		style = errorStyle.Inherit(style)
+	}
+	return style.Render(" " + status + " ")
+}
+
+// projectColor returns the color of the project a task belongs to, or ""
+// for none. Personal tasks take the color of the team project with their
+// project's name.
+func (m model) projectColor(task models.Task) string {
+	for _, project := range m.projects {
+		if project.ID == task.ProjectID || strings.EqualFold(project.Name, task.Project) {
+			return project.Color
+		}
+	}
+	return ""
+}
+
+// projectNames lists the projects the task form suggests: the server's,
+// followed for personal tasks by the ones already used on them.
+func (m model) projectNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if key := strings.ToLower(name); name != "" && !seen[key] {
+			seen[key] = true
+			names = append(names, name)
+		}
+	}
+
+	for _, project := range m.projects {
+		add(project.Name)
+	}
+	if m.currentSection == "personal" {
+		var used []string
+		for _, task := range m.personalTasks {
+			used = append(used, task.Project)
+		}
+		sort.Strings(used)
+		for _, name := range used {
+			add(name)
+		}
+	}
+	return names
+}
+
+// projectSuggestion completes a partly typed project name, ignoring case.
+// It returns "" when there is nothing to add.
+func (m model) projectSuggestion(input string) string {
+	if input == "" {
+		return ""
+	}
+	for _, name := range m.projectNames() {
+		if name != input && len([]rune(name)) >= len([]rune(input)) &&
+			strings.HasPrefix(strings.ToLower(name), strings.ToLower(input)) {
+			return name
+		}
+	}
+	return ""
+}
+
+// runsTimer reports whether pressing the timer key on a team task stops a
+// timer: ours, or one nobody owns, which the server stops in its place.
+func (m model) runsTimer(task models.Task) bool {
+	me := m.myName()
+	for _, timer := range task.Timers {
+		if timer.User == me || timer.User == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// myName is the name the server knows us by, or the OS user until it has
+// told us.
+func (m model) myName() string {
+	if m.me == "" {
+		return m.client.user
+	}
+	return m.me
+}
+
+// openBlockers returns the tasks of the current section that block task
+// and aren't done yet.
+func (m model) openBlockers(task models.Task) []models.Task {
+	var blockers []models.Task
+	for _, id := range task.BlockedBy {
+		if blocker, ok := m.findTask(id); ok && blocker.Status != "done" {
+			blockers = append(blockers, blocker)
+		}
+	}
+	return blockers
+}
+
+func (m model) isBlocked(task models.Task) bool {
+	return len(m.openBlockers(task)) > 0
+}
+
+// priorityMark puts one "!" per level above low in front of a title.
+func priorityMark(priority string) string {
+	rank := models.PriorityRank(priority)
+	if rank <= 1 {
+		return ""
+	}
+	return strings.Repeat("!", rank-1) + " "
+}
+
+// taskDetails is the tags and due date part of a task line.
+func taskDetails(task models.Task) string {
+	var details strings.Builder
+	for _, tag := range task.Tags {
+		details.WriteString(" #" + tag)
+	}
+
+	if task.DueDate != "" {
+		details.WriteString(" due " + task.DueDate)
+		if overdue(task) {
+			details.WriteString(" (overdue)")
+		}
+	}
+
+	return details.String()
+}
+
+// overdue reports whether an unfinished task's due date has passed.
+func overdue(task models.Task) bool {
+	if task.DueDate == "" || task.Status == "done" {
+		return false
+	}
+	return task.DueDate < time.Now().Format(models.DueDateLayout)
+}
+
+// findTask looks a task up in the current section.
+func (m model) findTask(id string) (models.Task, bool) {
+	tasks := m.personalTasks
+	if m.currentSection == "team" {
+		tasks = m.teamTasks
+	}
+
+	for _, task := range tasks {
+		if task.ID == id {
+			return task, true
+		}
+	}
+	return models.Task{}, false
+}
+
+func (m model) renderDetail() string {
+	var s strings.Builder
+
+	task, ok := m.findTask(m.detailTaskID)
+	if !ok {
+		s.WriteString(titleStyle.Render("Task Details"))
+		s.WriteString("\n\nThis task no longer exists.\n\n")
+		s.WriteString(helpStyle.Render("esc: back"))
+		return s.String()
+	}
+
+	s.WriteString(titleStyle.Render(task.Title))
+	s.WriteString("\n\n")
+
+	none := helpStyle.Render("-")
+	field := func(label, value string) {
+		if value == "" {
+			value = none
+		}
+		s.WriteString(fmt.Sprintf("  %-10s %s\n", label, value))
+	}
+
+	due := task.DueDate
+	if overdue(task) {
+		due = errorStyle.Render(due + " (overdue)")
+	}
+	estimate := ""
+	if task.EstimateSeconds > 0 {
+		estimate = formatEstimate(task.EstimateSeconds)
+	}
+	tags := ""
+	if len(task.Tags) > 0 {
+		tags = "#" + strings.Join(task.Tags, " #")
+	}
+
+	field("Project", task.Project)
+	field("Status", task.Status)
+	field("Priority", task.Priority)
+	field("Due", due)
+	field("Estimate", estimate)
+	field("Tracked", formatDuration(task.TotalTimeSeconds))
+	if task.RollupTimeSeconds > task.TotalTimeSeconds {
+		field("Rolled up", formatDuration(task.RollupTimeSeconds))
+	}
+	var running []string
+	for _, timer := range task.Timers {
+		since := "since " + timer.StartTime.Local().Format("15:04")
+		if timer.User != "" {
+			since = timer.User + " " + since
+		}
+		running = append(running, since)
+	}
+	if len(running) > 0 {
+		field("Running", "▶ "+strings.Join(running, ", "))
+	}
+	if parent, ok := m.findTask(task.ParentID); ok {
+		field("Parent", parent.Title)
+	}
+	var blockers []string
+	for _, blocker := range m.openBlockers(task) {
+		blockers = append(blockers, blocker.Title)
+	}
+	if len(blockers) > 0 {
+		field("Blocked by", errorStyle.Render("🔒 "+strings.Join(blockers, ", ")))
+	}
+	field("Tags", tags)
+	field("Created", task.CreatedAt.Local().Format(entryTimeLayout))
+	s.WriteString("\n")
+
+	if task.Description != "" {
+		s.WriteString(task.Description)
+		s.WriteString("\n\n")
+	}
+
+	s.WriteString(helpStyle.Render("e: edit • esc: back"))
+	return s.String()
+}
+
+func (m model) renderEntries() string {
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render("Time Entries - " + m.entryTask.Title))
+	s.WriteString("\n\n")
+
+	if len(m.entries) == 0 {
+		s.WriteString("No time entries yet. Press 'a' to add one.\n\n")
+	} else {
+		total := 0
+		for i, entry := range m.entries {
+			cursor := "  "
+			if m.entryCursor == i {
+				cursor = "▶ "
+			}
+
+			end := "running"
+			if entry.EndTime != nil {
+				end = entry.EndTime.Local().Format(entryTimeLayout)
+			}
+
+			line := fmt.Sprintf("%s%s → %s  %s", cursor,
+				entry.StartTime.Local().Format(entryTimeLayout), end,
+				formatDuration(entry.DurationSeconds))
+			if entry.User != "" {
+				line += "  " + entry.User
+			}
+			if entry.NonBillable {
+				line += "  (non-billable)"
+			}
+			if m.entryCursor == i {
+				s.WriteString(selectedStyle.Render(line))
+			} else {
+				s.WriteString(normalStyle.Render(line))
+			}
+			s.WriteString("\n")
+			total += entry.DurationSeconds
+		}
+		s.WriteString(fmt.Sprintf("\n  Total: %s\n\n", formatDuration(total)))
+	}
+
+	if m.entryError != "" {
+		s.WriteString(errorStyle.Render(m.entryError))
+		s.WriteString("\n\n")
+	}
+
+	help := "a: add • e: edit • p: split • x: delete"
+	if m.currentSection == "team" {
+		help += " • b: billable"
+	}
+	s.WriteString(helpStyle.Render(help + " • esc: back"))
+	return s.String()
+}
+
+func (m model) renderEntryForm() string {
+	var s strings.Builder
+
+	titles := map[string]string{
+		"add":   "Add Time Entry",
+		"edit":  "Edit Time Entry",
+		"split": "Split Time Entry",
+	}
+	s.WriteString(titleStyle.Render(titles[m.entryForm] + " - " + m.entryTask.Title))
+	s.WriteString("\n\n")
+
+	labels := []string{"Start", "End"}
+	if m.entryForm == "split" {
+		labels = []string{"Split at"}
+	}
+	for i, label := range labels {
+		cursor := ""
+		if m.entryField == i {
+			cursor = "█"
+		}
+		s.WriteString(fmt.Sprintf("%s: %s%s\n", label, m.entryInputs[i], cursor))
+	}
+	s.WriteString("\n")
+
+	if m.entryError != "" {
+		s.WriteString(errorStyle.Render(m.entryError))
+		s.WriteString("\n\n")
+	}
+
+	s.WriteString(helpStyle.Render("Format " + entryTimeLayout + " • Tab to switch field • Enter to save • Esc to cancel"))
+	return s.String()
+}
+
+func (m model) renderTrash() string {
+	var s strings.Builder
+
+	sectionName := "Personal"
+	if m.currentSection == "team" {
+		sectionName = "Team"
+	}
+	s.WriteString(titleStyle.Render(sectionName + " Trash"))
+	s.WriteString("\n\n")
+
+	if len(m.trashTasks) == 0 {
+		s.WriteString("The trash is empty.\n\n")
+	} else {
+		for i, task := range m.trashTasks {
+			cursor := "  "
+			if m.trashCursor == i {
+				cursor = "▶ "
+			}
+
+			project := ""
+			if task.Project != "" {
+				project = fmt.Sprintf(" [%s]", task.Project)
+			}
+
+			deleted := ""
+			if task.DeletedAt != nil {
+				deleted = " deleted " + task.DeletedAt.Local().Format(entryTimeLayout)
+			}
+
+			line := fmt.Sprintf("%s%s%s%s", cursor, task.Title, project, deleted)
+			if m.trashCursor == i {
+				s.WriteString(selectedStyle.Render(line))
+			} else {
+				s.WriteString(normalStyle.Render(line))
+			}
+			s.WriteString("\n")
+		}
+		s.WriteString("\n")
 	}
 
-	return fmt.Sprintf("%s%s %s%s%s", cursor, status, task.Title, project, timer)
+	s.WriteString(helpStyle.Render("r/enter: restore • esc: back"))
+	return s.String()
+}
+
+func (m model) renderReport() string {
+	if m.estimateGroup != "" {
+		return m.renderEstimates()
+	}
+
+	var s strings.Builder
+
+	query := m.reportQuery
+	s.WriteString(titleStyle.Render(fmt.Sprintf("Time by %s, %s to %s", query.GroupBy, query.From, query.To)))
+	s.WriteString("\n\n")
+
+	if m.reportPersonal == nil || m.reportTeam == nil {
+		s.WriteString("Loading…\n\n")
+	} else {
+		lines := reportLines(m.reportPersonal, m.reportTeam)
+		width, most := len("Total"), 0
+		for _, line := range lines {
+			if w := len([]rune(line.label)); w > width {
+				width = w
+			}
+			if line.personal+line.team > most {
+				most = line.personal + line.team
+			}
+		}
+
+		s.WriteString(helpStyle.Render(fmt.Sprintf("%-*s %9s %9s %9s", width, "", "Personal", "Team", "Total")))
+		s.WriteString("\n")
+		var totalPersonal, totalTeam int
+		for _, line := range lines {
+			total := line.personal + line.team
+			s.WriteString(fmt.Sprintf("%-*s %9s %9s %9s ", width, line.label,
+				formatHours(line.personal), formatHours(line.team), formatHours(total)))
+			if most > 0 {
+				s.WriteString(selectedStyle.Render(strings.Repeat(" ", total*reportBarWidth/most)))
+			}
+			s.WriteString("\n")
+			totalPersonal += line.personal
+			totalTeam += line.team
+		}
+		s.WriteString(fmt.Sprintf("%-*s %9s %9s %9s\n\n", width, "Total",
+			formatHours(totalPersonal), formatHours(totalTeam), formatHours(totalPersonal+totalTeam)))
+	}
+
+	for _, note := range m.reportNotes {
+		s.WriteString(errorStyle.Render(note))
+		s.WriteString("\n\n")
+	}
+
+	s.WriteString(helpStyle.Render("d/w/m: by day/week/month • p/u: by project/user • ←/→: earlier/later • .: now • e: estimates • esc: back"))
+	return s.String()
+}
+
+// renderEstimates shows how long done tasks took against their estimates,
+// with the groups that overran in red.
+func (m model) renderEstimates() string {
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render("Estimates of done tasks by " + m.estimateGroup))
+	s.WriteString("\n\n")
+
+	if m.estimates == nil {
+		s.WriteString("Loading…\n\n")
+	} else {
+		width := len("Total")
+		for _, row := range m.estimates.Rows {
+			if w := len([]rune(reportLabel(m.estimateGroup, row.Key))); w > width {
+				width = w
+			}
+		}
+
+		line := func(label string, row models.EstimateRow) string {
+			text := fmt.Sprintf("%-*s %6d %6d %9s %9s %7s", width, label, row.Tasks, row.Over,
+				formatHours(row.EstimateSeconds), formatHours(row.ActualSeconds), formatRatio(row))
+			if row.Ratio() > 1 {
+				return errorStyle.Render(text) + "\n"
+			}
+			return text + "\n"
+		}
+		s.WriteString(helpStyle.Render(fmt.Sprintf("%-*s %6s %6s %9s %9s %7s", width, "", "Tasks", "Over", "Estimated", "Took", "Ratio")))
+		s.WriteString("\n")
+		for _, row := range m.estimates.Rows {
+			s.WriteString(line(reportLabel(m.estimateGroup, row.Key), row))
+		}
+		s.WriteString(line("Total", m.estimates.Total))
+		s.WriteString("\n")
+	}
+
+	for _, note := range m.reportNotes {
+		s.WriteString(errorStyle.Render(note))
+		s.WriteString("\n\n")
+	}
+
+	s.WriteString(helpStyle.Render("p/u: by project/user • e: time report • esc: back"))
+	return s.String()
+}
+
+// reportBarWidth is how wide the bar of the busiest row of a report is.
+const reportBarWidth = 30
+
+func (m model) renderTimesheet() string {
+	var s strings.Builder
+
+	week := models.WeekStart(m.timesheetDay)
+	s.WriteString(titleStyle.Render(fmt.Sprintf("Timesheet of %s, week of %s", m.timesheetUser, week.Format(models.DueDateLayout))))
+	s.WriteString("\n\n")
+
+	sheet := m.timesheet
+	if sheet == nil {
+		if m.timesheetError == "" {
+			s.WriteString("Loading…\n\n")
+		}
+	} else {
+		s.WriteString("Status: " + m.timesheetStatus(*sheet) + "\n")
+		if sheet.Comment != "" {
+			s.WriteString(errorStyle.Render("Comment: " + sheet.Comment))
+			s.WriteString("\n")
+		}
+		s.WriteString("\n")
+
+		// Every day of the week, with the entries started on it
+		for day := week; day.Before(week.AddDate(0, 0, 7)); day = day.AddDate(0, 0, 1) {
+			var lines []string
+			var seconds int
+			for _, entry := range sheet.Entries {
+				start := entry.StartTime.Local()
+				if start.Year() != day.Year() || start.YearDay() != day.YearDay() {
+					continue
+				}
+				end := ""
+				if entry.EndTime != nil {
+					end = entry.EndTime.Local().Format("15:04")
+				}
+				title := entry.TaskTitle
+				if entry.Project != "" {
+					title += " [" + entry.Project + "]"
+				}
+				lines = append(lines, fmt.Sprintf("    %s–%-5s %6s  %s", start.Format("15:04"), end, formatHours(entry.DurationSeconds), title))
+				seconds += entry.DurationSeconds
+			}
+
+			s.WriteString(selectedStyle.Render(fmt.Sprintf("%-14s %6s", day.Format("Mon 2006-01-02"), formatHours(seconds))))
+			s.WriteString("\n")
+			for _, line := range lines {
+				s.WriteString(normalStyle.Render(line))
+				s.WriteString("\n")
+			}
+		}
+		s.WriteString(fmt.Sprintf("\n%-14s %6s\n\n", "Total", formatHours(sheet.TotalSeconds)))
+	}
+
+	if m.timesheetError != "" {
+		s.WriteString(errorStyle.Render(m.timesheetError))
+		s.WriteString("\n\n")
+	}
+
+	help := "←/→: earlier/later week • .: this week"
+	if strings.EqualFold(m.timesheetUser, m.myName()) {
+		help += " • s: submit"
+	} else if m.lead {
+		help += " • a: approve • x: reject"
+	}
+	if m.lead {
+		help += " • L: review queue"
+	}
+	s.WriteString(helpStyle.Render(help + " • esc: back"))
+	return s.String()
+}
+
+// timesheetStatus describes where a timesheet is and who moved it there.
+func (m model) timesheetStatus(sheet models.Timesheet) string {
+	status := sheet.Status
+	switch {
+	case sheet.ReviewedAt != nil:
+		status += fmt.Sprintf(" by %s on %s", sheet.ReviewedBy, sheet.ReviewedAt.Local().Format(entryTimeLayout))
+	case sheet.SubmittedAt != nil:
+		status += " on " + sheet.SubmittedAt.Local().Format(entryTimeLayout)
+	}
+	return status
+}
+
+func (m model) renderRejectPrompt() string {
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render("Reject Timesheet"))
+	s.WriteString("\n\n")
+	if m.timesheet != nil {
+		s.WriteString(fmt.Sprintf("%s, week of %s, %s tracked\n\n", m.timesheet.User, m.timesheet.WeekStart, formatHours(m.timesheet.TotalSeconds)))
+	}
+	s.WriteString(fmt.Sprintf("Why: %s█\n\n", m.rejectComment))
+	s.WriteString(helpStyle.Render("enter: reject • esc: cancel"))
+	return s.String()
+}
+
+func (m model) renderReviews() string {
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render("Timesheets to Review"))
+	s.WriteString("\n\n")
+
+	if len(m.reviews) == 0 {
+		s.WriteString("Nothing is waiting for review.\n\n")
+	} else {
+		for i, sheet := range m.reviews {
+			cursor := "  "
+			if m.reviewCursor == i {
+				cursor = "▶ "
+			}
+
+			submitted := ""
+			if sheet.SubmittedAt != nil {
+				submitted = " submitted " + sheet.SubmittedAt.Local().Format(entryTimeLayout)
+			}
+
+			line := fmt.Sprintf("%s%s, week of %s  %s%s", cursor, sheet.User, sheet.WeekStart, formatHours(sheet.TotalSeconds), submitted)
+			if m.reviewCursor == i {
+				s.WriteString(selectedStyle.Render(line))
+			} else {
+				s.WriteString(normalStyle.Render(line))
+			}
+			s.WriteString("\n")
+		}
+		s.WriteString("\n")
+	}
+
+	if m.timesheetError != "" {
+		s.WriteString(errorStyle.Render(m.timesheetError))
+		s.WriteString("\n\n")
+	}
+
+	s.WriteString(helpStyle.Render("enter: open • r: refresh • esc: back"))
+	return s.String()
+}
+
+func (m model) renderSearchPrompt() string {
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render("Search Tasks"))
+	s.WriteString("\n\n")
+	s.WriteString(fmt.Sprintf("Search: %s█\n\n", m.searchQuery))
+	s.WriteString(helpStyle.Render("Finds words in the title, description, project and tags of tasks in both sections"))
+	s.WriteString("\n\n")
+	s.WriteString(helpStyle.Render("enter: search • esc: cancel"))
+	return s.String()
+}
+
+func (m model) renderFilterPrompt() string {
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render("Filter Team Tasks"))
+	s.WriteString("\n\n")
+	s.WriteString(fmt.Sprintf("Filter: %s█\n\n", m.filterInput))
+	if m.filterError != "" {
+		s.WriteString(errorStyle.Render(m.filterError))
+		s.WriteString("\n\n")
+	}
+	s.WriteString(helpStyle.Render(`project:Web status:todo,review tag:ui assignee:alice due_from:2026-01-01 due_to:... created_from:... created_to:...`))
+	s.WriteString("\n")
+	s.WriteString(helpStyle.Render(`Other words are searched for; quote values with spaces, as in project:"Web site"`))
+	s.WriteString("\n\n")
+	s.WriteString(helpStyle.Render("enter: apply (empty shows all) • esc: cancel"))
+	return s.String()
+}
+
+func (m model) renderSearch() string {
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render(fmt.Sprintf("Search: %s", m.searchQuery)))
+	s.WriteString("\n\n")
+
+	if len(m.searchHits) == 0 {
+		s.WriteString("Nothing matches.\n\n")
+	} else {
+		for i, hit := range m.searchHits {
+			style := normalStyle
+			cursor := "  "
+			if m.searchCursor == i {
+				style = selectedStyle
+				cursor = "▶ "
+			}
+
+			section := "[personal] "
+			if hit.section == "team" {
+				section = "[team]     "
+			}
+			line := style.Render(cursor+section) + renderHighlights(hit.Title, style)
+			if hit.Task.Project != "" {
+				line += style.Render(" [" + hit.Task.Project + "]")
+			}
+			s.WriteString(line)
+			s.WriteString("\n")
+			if hit.Snippet != "" {
+				s.WriteString("             ")
+				s.WriteString(renderHighlights(hit.Snippet, helpStyle))
+				s.WriteString("\n")
+			}
+		}
+		s.WriteString("\n")
+	}
+
+	for _, note := range m.searchNotes {
+		s.WriteString(errorStyle.Render(note))
+		s.WriteString("\n")
+	}
+	if len(m.searchNotes) > 0 {
+		s.WriteString("\n")
+	}
+
+	s.WriteString(helpStyle.Render("enter: go to task • /: new search • esc: back"))
+	return s.String()
+}
+
+// renderHighlights renders search text in style, making the parts between
+// highlight markers stand out.
+func renderHighlights(text string, style lipgloss.Style) string {
+	var s strings.Builder
+	for {
+		start := strings.Index(text, models.HighlightStart)
+		if start < 0 {
+			break
+		}
+		stop := strings.Index(text[start:], models.HighlightStop)
+		if stop < 0 {
+			break
+		}
+		stop += start
+
+		s.WriteString(style.Render(text[:start]))
+		s.WriteString(highlightStyle.Inherit(style).Render(text[start+len(models.HighlightStart) : stop]))
+		text = text[stop+len(models.HighlightStop):]
+	}
+	s.WriteString(style.Render(text))
+	return s.String()
+}
+
+func (m model) renderConflict() string {
+	var s strings.Builder
+
+	theirs := m.conflict.theirs
+	s.WriteString(titleStyle.Render("Conflict - " + theirs.Title))
+	s.WriteString("\n\n")
+	s.WriteString("Someone else changed this task after you loaded it.\n\n")
+
+	if m.conflict.mine == nil {
+		s.WriteString("Mine: delete the task\n\n")
+		s.WriteString("Theirs:\n")
+		for _, row := range conflictRows(theirs) {
+			s.WriteString(fmt.Sprintf("  %-10s %s\n", row[0], row[1]))
+		}
+	} else {
+		mineRows := conflictRows(*m.conflict.mine)
+		s.WriteString(fmt.Sprintf("  %-10s %-28s %s\n", "", "Theirs", "Mine"))
+		for i, row := range conflictRows(theirs) {
+			line := fmt.Sprintf("  %-10s %-28s %s", row[0], row[1], mineRows[i][1])
+			if row[1] != mineRows[i][1] {
+				line = errorStyle.Render(line)
+			}
+			s.WriteString(line + "\n")
+		}
+	}
+	s.WriteString("\n")
+
+	s.WriteString(helpStyle.Render("o: overwrite with mine • t/esc: keep theirs"))
+	return s.String()
+}
+
+func (m model) renderBlocked() string {
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render("Blocked - " + m.blocked.task.Title))
+	s.WriteString("\n\n")
+	s.WriteString("This task is waiting for:\n\n")
+	for _, blocker := range m.blocked.blockers {
+		s.WriteString(fmt.Sprintf("  🔒 %s (%s)\n", blocker.Title, blocker.Status))
+	}
+	s.WriteString("\n")
+
+	s.WriteString(helpStyle.Render("f: do it anyway • esc: cancel"))
+	return s.String()
+}
+
+// conflictRows lists the fields shown side by side in the conflict prompt.
+func conflictRows(task models.Task) [][2]string {
+	timer := "stopped"
+	if task.IsActive {
+		timer = "running"
+	}
+
+	return [][2]string{
+		{"Title", truncate(task.Title, 26)},
+		{"Project", truncate(task.Project, 26)},
+		{"Status", task.Status},
+		{"Priority", task.Priority},
+		{"Due", task.DueDate},
+		{"Estimate", formatEstimate(task.EstimateSeconds)},
+		{"Tags", truncate(strings.Join(task.Tags, ", "), 26)},
+		{"Notes", truncate(task.Description, 26)},
+		{"Timer", timer},
+		{"Time", formatDuration(task.TotalTimeSeconds)},
+	}
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+// formatDuration renders seconds as MM:SS, or HH:MM:SS from an hour up.
+func formatDuration(totalSeconds int) string {
+	hours := totalSeconds / 3600
+	minutes := (totalSeconds % 3600) / 60
+	seconds := totalSeconds % 60
+
+	if hours > 0 {
+		return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%02d:%02d", minutes, seconds)
+}
+
+// formatEstimate renders an estimate compactly, such as 45m, 2h or 1h30m.
+func formatEstimate(totalSeconds int) string {
+	hours := totalSeconds / 3600
+	minutes := (totalSeconds % 3600) / 60
+
+	switch {
+	case hours > 0 && minutes > 0:
+		return fmt.Sprintf("%dh%02dm", hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%dh", hours)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
 }
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// calendarTimeLayout is an iCalendar date-time in UTC.
+const calendarTimeLayout = "20060102T150405Z"
+
+// WriteCalendar renders tasks as an iCalendar (RFC 5545) feed named name.
+// Every finished time entry becomes a VEVENT spanning the time worked and
+// every task with a due date a VTODO due that day. UIDs come from the entry
+// and task IDs, so calendars update events in place when the feed changes.
+func WriteCalendar(w io.Writer, name string, tasks []ExportedTask) error {
+	out := &calendarWriter{w: bufio.NewWriter(w)}
+	stamp := time.Now().UTC().Format(calendarTimeLayout)
+
+	out.line("BEGIN", "VCALENDAR")
+	out.line("VERSION", "2.0")
+	out.line("PRODID", "-//TaskTime//TaskTime//EN")
+	out.line("CALSCALE", "GREGORIAN")
+	out.line("X-WR-CALNAME", escapeCalendarText(name))
+
+	for _, task := range tasks {
+		summary := task.Title
+		if task.Project != "" {
+			summary += " [" + task.Project + "]"
+		}
+
+		for _, entry := range task.TimeEntries {
+			if entry.EndTime == nil {
+				continue
+			}
+			description := fmt.Sprintf("%d:%02d tracked", entry.DurationSeconds/3600, entry.DurationSeconds%3600/60)
+			if entry.User != "" {
+				description += " by " + entry.User
+			}
+
+			out.line("BEGIN", "VEVENT")
+			out.line("UID", entry.ID+"@tasktime")
+			out.line("DTSTAMP", stamp)
+			out.line("DTSTART", entry.StartTime.UTC().Format(calendarTimeLayout))
+			out.line("DTEND", entry.EndTime.UTC().Format(calendarTimeLayout))
+			out.line("SUMMARY", escapeCalendarText(summary))
+			out.line("DESCRIPTION", escapeCalendarText(description))
+			out.line("TRANSP", "OPAQUE")
+			out.line("END", "VEVENT")
+		}
+
+		due, err := time.Parse(DueDateLayout, task.DueDate)
+		if err != nil {
+			continue
+		}
+		out.line("BEGIN", "VTODO")
+		out.line("UID", "task-"+task.ID+"@tasktime")
+		out.line("DTSTAMP", stamp)
+		out.line("CREATED", task.CreatedAt.UTC().Format(calendarTimeLayout))
+		out.line("DUE;VALUE=DATE", due.Format("20060102"))
+		out.line("SUMMARY", escapeCalendarText(summary))
+		if task.Description != "" {
+			out.line("DESCRIPTION", escapeCalendarText(task.Description))
+		}
+		out.line("STATUS", todoStatus(task.Status))
+		if priority := todoPriority(task.Priority); priority != "" {
+			out.line("PRIORITY", priority)
+		}
+		if len(task.Tags) > 0 {
+			categories := make([]string, len(task.Tags))
+			for i, tag := range task.Tags {
+				categories[i] = escapeCalendarText(tag)
+			}
+			out.line("CATEGORIES", strings.Join(categories, ","))
+		}
+		out.line("END", "VTODO")
+	}
+
+	out.line("END", "VCALENDAR")
+	if out.err != nil {
+		return out.err
+	}
+	return out.w.Flush()
+}
+
+// todoStatus maps a workflow status to a VTODO one. Statuses other than
+// todo and done count as being worked on.
+func todoStatus(status string) string {
+	switch status {
+	case "", "todo":
+		return "NEEDS-ACTION"
+	case "done":
+		return "COMPLETED"
+	default:
+		return "IN-PROCESS"
+	}
+}
+
+// todoPriority maps a priority to iCalendar's 1 (highest) to 9 (lowest).
+func todoPriority(priority string) string {
+	switch priority {
+	case "urgent":
+		return "1"
+	case "high":
+		return "3"
+	case "medium":
+		return "5"
+	case "low":
+		return "9"
+	default:
+		return ""
+	}
+}
+
+// escapeCalendarText escapes a TEXT value.
+func escapeCalendarText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+// calendarWriter writes content lines, folded at 75 octets as RFC 5545
+// asks, and remembers the first error.
+type calendarWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (c *calendarWriter) line(name, value string) {
+	if c.err != nil {
+		return
+	}
+
+	// Continuation lines start with a space, which counts too
+	line, limit := name+":"+value, 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		if _, c.err = c.w.WriteString(line[:cut] + "\r\n "); c.err != nil {
+			return
+		}
+		line, limit = line[cut:], 74
+	}
+	_, c.err = c.w.WriteString(line + "\r\n")
+}
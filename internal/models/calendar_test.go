@@ -0,0 +1,106 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscapeCalendarText(t *testing.T) {
+	for text, want := range map[string]string{
+		"plain":               "plain",
+		`back\slash`:          `back\\slash`,
+		"a;b,c":               `a\;b\,c`,
+		"two\nlines":          `two\nlines`,
+		"windows\r\nnewlines": `windows\nnewlines`,
+	} {
+		if got := escapeCalendarText(text); got != want {
+			t.Errorf("escapeCalendarText(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestCalendarLinesFold(t *testing.T) {
+	var out bytes.Buffer
+	c := &calendarWriter{w: bufio.NewWriter(&out)}
+	value := strings.Repeat("é", 100) + strings.Repeat("x", 100)
+	c.line("SUMMARY", value)
+	if c.err != nil || c.w.Flush() != nil {
+		t.Fatal(c.err)
+	}
+
+	text := out.String()
+	if !strings.HasSuffix(text, "\r\n") {
+		t.Fatalf("%q doesn't end its line", text)
+	}
+	lines := strings.Split(strings.TrimSuffix(text, "\r\n"), "\r\n")
+	if len(lines) < 2 {
+		t.Fatalf("a %d byte line wasn't folded", len(value))
+	}
+
+	var unfolded strings.Builder
+	for i, line := range lines {
+		if len(line) > 75 {
+			t.Errorf("line %d is %d octets", i, len(line))
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line %d splits a character: %q", i, line)
+		}
+		if i > 0 {
+			if !strings.HasPrefix(line, " ") {
+				t.Fatalf("continuation line %d doesn't start with a space: %q", i, line)
+			}
+			line = line[1:]
+		}
+		unfolded.WriteString(line)
+	}
+	if unfolded.String() != "SUMMARY:"+value {
+		t.Errorf("unfolded to %q", unfolded.String())
+	}
+}
+
+func TestWriteCalendar(t *testing.T) {
+	start := time.Date(2026, 9, 9, 9, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	end := start.Add(90 * time.Minute)
+	running := start.Add(time.Hour)
+	tasks := []ExportedTask{{
+		Task: Task{ID: "t1", Title: "Plan, then build", Project: "Web", DueDate: "2026-09-30", Status: "in-progress", Priority: "high", Tags: []string{"a,b"}},
+		TimeEntries: []TimeEntry{
+			{ID: "e1", User: "alice", StartTime: start, EndTime: &end, DurationSeconds: 5400},
+			{ID: "e2", StartTime: running},
+		},
+	}}
+
+	var out bytes.Buffer
+	if err := WriteCalendar(&out, "Team; tasks", tasks); err != nil {
+		t.Fatal(err)
+	}
+	text := out.String()
+	for _, want := range []string{
+		"X-WR-CALNAME:Team\\; tasks\r\n",
+		"UID:e1@tasktime\r\n",
+		"DTSTART:20260909T070000Z\r\n",
+		"DTEND:20260909T083000Z\r\n",
+		"SUMMARY:Plan\\, then build [Web]\r\n",
+		"DESCRIPTION:1:30 tracked by alice\r\n",
+		"UID:task-t1@tasktime\r\n",
+		"DUE;VALUE=DATE:20260930\r\n",
+		"STATUS:IN-PROCESS\r\n",
+		"PRIORITY:3\r\n",
+		"CATEGORIES:a\\,b\r\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("calendar is missing %q", want)
+		}
+	}
+	if strings.Contains(text, "e2@tasktime") {
+		t.Error("calendar has an event for a running timer")
+	}
+	// BEGIN:VCALENDAR opens the feed, so it has no newline before it
+	if strings.Count(text, "\nBEGIN:")+1 != strings.Count(text, "\nEND:") {
+		t.Error("calendar has unbalanced components")
+	}
+}
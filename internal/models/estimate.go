@@ -0,0 +1,148 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EstimateGroups lists what estimate reports can group tasks by.
+var EstimateGroups = []string{"project", "user"}
+
+// LiveSeconds is a task's tracked time plus everyone's running timers at
+// now.
+func (t Task) LiveSeconds(now time.Time) int {
+	total := t.TotalTimeSeconds
+	if len(t.Timers) > 0 {
+		for _, timer := range t.Timers {
+			total += int(now.Sub(timer.StartTime).Seconds())
+		}
+	} else if t.IsActive && t.StartTime != nil && !t.StartTime.IsZero() {
+		total += int(now.Sub(*t.StartTime).Seconds())
+	}
+	return total
+}
+
+// OverEstimate is sent as task.over_estimate when a running timer takes a
+// task past its estimate.
+type OverEstimate struct {
+	Task           Task `json:"task"`
+	ElapsedSeconds int  `json:"elapsed_seconds"` // The task's and its subtasks' time, running timers included
+}
+
+// ParseEstimateGroup reads the group_by URL parameter of an estimate
+// report, "project" by default.
+func ParseEstimateGroup(values url.Values) (string, error) {
+	group := values.Get("group_by")
+	if group == "" {
+		return "project", nil
+	}
+	return group, CheckEstimateGroup(group)
+}
+
+// CheckEstimateGroup makes sure group is one of EstimateGroups.
+func CheckEstimateGroup(group string) error {
+	for _, known := range EstimateGroups {
+		if group == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("group_by must be one of %s", strings.Join(EstimateGroups, ", "))
+}
+
+// EstimateReport compares the estimates of done tasks with the time they
+// took, per project or per user.
+type EstimateReport struct {
+	GroupBy string        `json:"group_by"`
+	Rows    []EstimateRow `json:"rows"`
+	Total   EstimateRow   `json:"total"`
+}
+
+// EstimateRow is one group of an EstimateReport. Per user, a task's
+// estimate is shared among the people who worked on it in proportion to
+// their time.
+type EstimateRow struct {
+	Key             string `json:"key"` // Empty for tasks without a project, or without any time per user
+	Tasks           int    `json:"tasks"`
+	Over            int    `json:"over"` // Tasks that took longer than estimated
+	EstimateSeconds int    `json:"estimate_seconds"`
+	ActualSeconds   int    `json:"actual_seconds"`
+}
+
+// Ratio is the time taken per estimated hour: 1 is spot on, above 1 took
+// longer than planned. Zero without an estimate.
+func (r EstimateRow) Ratio() float64 {
+	if r.EstimateSeconds == 0 {
+		return 0
+	}
+	return float64(r.ActualSeconds) / float64(r.EstimateSeconds)
+}
+
+// NewEstimateReport starts an empty report grouped by group.
+func NewEstimateReport(group string) *EstimateReport {
+	return &EstimateReport{GroupBy: group, Rows: []EstimateRow{}}
+}
+
+// Add counts a task towards the group key with its share of the estimate
+// and of the time taken. Total is counted separately, once per task.
+func (r *EstimateReport) Add(key string, over bool, estimate, actual int) {
+	for i := range r.Rows {
+		if r.Rows[i].Key == key {
+			r.Rows[i].add(over, estimate, actual)
+			return
+		}
+	}
+	row := EstimateRow{Key: key}
+	row.add(over, estimate, actual)
+	r.Rows = append(r.Rows, row)
+}
+
+// AddTotal counts a task towards the report's total.
+func (r *EstimateReport) AddTotal(over bool, estimate, actual int) {
+	r.Total.add(over, estimate, actual)
+}
+
+func (r *EstimateRow) add(over bool, estimate, actual int) {
+	r.Tasks++
+	if over {
+		r.Over++
+	}
+	r.EstimateSeconds += estimate
+	r.ActualSeconds += actual
+}
+
+// Merge adds another report's rows and total to this one.
+func (r *EstimateReport) Merge(other EstimateReport) {
+	for _, row := range other.Rows {
+		i := 0
+		for i < len(r.Rows) && r.Rows[i].Key != row.Key {
+			i++
+		}
+		if i == len(r.Rows) {
+			r.Rows = append(r.Rows, EstimateRow{Key: row.Key})
+		}
+		r.Rows[i].merge(row)
+	}
+	r.Total.merge(other.Total)
+	r.Sort()
+}
+
+func (r *EstimateRow) merge(other EstimateRow) {
+	r.Tasks += other.Tasks
+	r.Over += other.Over
+	r.EstimateSeconds += other.EstimateSeconds
+	r.ActualSeconds += other.ActualSeconds
+}
+
+// Sort puts the groups with the most estimated time first.
+func (r *EstimateReport) Sort() {
+	sort.SliceStable(r.Rows, func(i, j int) bool {
+		a, b := r.Rows[i], r.Rows[j]
+		if a.EstimateSeconds != b.EstimateSeconds {
+			return a.EstimateSeconds > b.EstimateSeconds
+		}
+		return a.Key < b.Key
+	})
+}
@@ -0,0 +1,330 @@
+package models
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportFormats lists the formats tasks can be exported to and imported
+// from.
+var ExportFormats = []string{"json", "csv"}
+
+// Export is a copy of a store's tasks and their time entries, for moving
+// them to another store. Task IDs are the ones of the store they came from;
+// importing gives them new ones.
+type Export struct {
+	ExportedAt time.Time      `json:"exported_at"`
+	Tasks      []ExportedTask `json:"tasks"`
+}
+
+// ExportedTask is a task with its finished time entries.
+type ExportedTask struct {
+	Task
+	TimeEntries []TimeEntry `json:"time_entries"`
+}
+
+// ImportResult says what an import did. Tasks and time entries that the
+// store already had are skipped rather than added twice.
+type ImportResult struct {
+	TasksCreated      int               `json:"tasks_created"`
+	TasksSkipped      int               `json:"tasks_skipped"`
+	EntriesCreated    int               `json:"entries_created"`
+	EntriesSkipped    int               `json:"entries_skipped"`
+	DependenciesAdded int               `json:"dependencies_added"`
+	IDs               map[string]string `json:"ids"`     // Exported task ID to the ID of the task in this store
+	Created           []string          `json:"created"` // IDs of the tasks created
+	Updated           []string          `json:"updated"` // IDs of existing tasks that got time entries
+}
+
+// CheckExportFormat makes sure format is one of ExportFormats.
+func CheckExportFormat(format string) error {
+	for _, known := range ExportFormats {
+		if format == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("format must be one of %s", strings.Join(ExportFormats, ", "))
+}
+
+// WriteExport encodes an export as JSON or CSV.
+func WriteExport(w io.Writer, format string, export *Export) error {
+	if format == "csv" {
+		return writeExportCSV(w, export)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(export)
+}
+
+// ReadExport decodes an export written by WriteExport.
+func ReadExport(r io.Reader, format string) (*Export, error) {
+	if format == "csv" {
+		return readExportCSV(r)
+	}
+	var export Export
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// Validate checks the tasks and time entries of an export before any of
+// them is imported: titles must be set, statuses must be in the workflow
+// (empty ones become "todo"), priorities, due dates and estimates must be
+// valid, and entries must end after they start.
+func (e *Export) Validate(workflow Workflow) error {
+	for _, task := range e.Tasks {
+		if strings.TrimSpace(task.Title) == "" {
+			return fmt.Errorf("task %s: title cannot be empty", task.ID)
+		}
+		if task.Status != "" && !workflow.Knows(task.Status) {
+			return fmt.Errorf("task %s: status must be one of %s", task.ID, strings.Join(workflow.Statuses, ", "))
+		}
+		if err := validateDetails(task.Priority, task.DueDate, task.EstimateSeconds); err != nil {
+			return fmt.Errorf("task %s: %w", task.ID, err)
+		}
+		for _, entry := range task.TimeEntries {
+			if entry.EndTime == nil {
+				continue
+			}
+			if err := CheckEntryTimes(entry.StartTime, *entry.EndTime); err != nil {
+				return fmt.Errorf("time entry %s of task %s: %w", entry.ID, task.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckEntryTimes makes sure a time entry ends after it starts.
+func CheckEntryTimes(start, end time.Time) error {
+	if !end.After(start) {
+		return errors.New("end_time must be after start_time")
+	}
+	return nil
+}
+
+// exportColumns are the columns of a CSV export. Each row is either a task
+// or one of its time entries, as the type column says; entry rows repeat the
+// title and project of their task, say whether they are billable and give
+// the hours, so the file works as a timesheet in a spreadsheet. Lists are
+// separated by semicolons.
+var exportColumns = []string{
+	"type", "task_id", "title", "project", "status", "priority", "due_date",
+	"estimate_seconds", "tags", "parent_id", "blocked_by", "description",
+	"created_at", "entry_id", "user", "start_time", "end_time",
+	"duration_seconds", "billable", "hours",
+}
+
+func writeExportCSV(w io.Writer, export *Export) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(exportColumns); err != nil {
+		return err
+	}
+
+	for _, task := range export.Tasks {
+		row := map[string]string{
+			"type":        "task",
+			"task_id":     task.ID,
+			"title":       task.Title,
+			"project":     task.Project,
+			"status":      task.Status,
+			"priority":    task.Priority,
+			"due_date":    task.DueDate,
+			"tags":        strings.Join(task.Tags, ";"),
+			"parent_id":   task.ParentID,
+			"blocked_by":  strings.Join(task.BlockedBy, ";"),
+			"description": task.Description,
+			"created_at":  task.CreatedAt.Format(time.RFC3339),
+		}
+		if task.EstimateSeconds > 0 {
+			row["estimate_seconds"] = strconv.Itoa(task.EstimateSeconds)
+		}
+		if err := out.Write(csvRow(row)); err != nil {
+			return err
+		}
+
+		for _, entry := range task.TimeEntries {
+			if entry.EndTime == nil {
+				continue
+			}
+			row := map[string]string{
+				"type":             "entry",
+				"task_id":          task.ID,
+				"title":            task.Title,
+				"project":          task.Project,
+				"created_at":       entry.CreatedAt.Format(time.RFC3339),
+				"entry_id":         entry.ID,
+				"user":             entry.User,
+				"start_time":       entry.StartTime.Format(time.RFC3339),
+				"end_time":         entry.EndTime.Format(time.RFC3339),
+				"duration_seconds": strconv.Itoa(entry.DurationSeconds),
+				"billable":         "yes",
+				"hours":            strconv.FormatFloat(float64(entry.DurationSeconds)/3600, 'f', 2, 64),
+			}
+			if entry.NonBillable {
+				row["billable"] = "no"
+			}
+			if err := out.Write(csvRow(row)); err != nil {
+				return err
+			}
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+func csvRow(values map[string]string) []string {
+	row := make([]string, len(exportColumns))
+	for i, column := range exportColumns {
+		row[i] = values[column]
+	}
+	return row
+}
+
+// readExportCSV reads a CSV export. Columns are found by their header, so
+// they may come in any order and unknown ones are ignored; a spreadsheet
+// can be saved back as CSV and imported.
+func readExportCSV(r io.Reader) (*Export, error) {
+	in := csv.NewReader(r)
+	in.FieldsPerRecord = -1
+
+	header, err := in.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"type", "task_id"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV has no %s column", required)
+		}
+	}
+
+	export := &Export{Tasks: []ExportedTask{}}
+	tasks := make(map[string]int) // Task ID to index in export.Tasks
+	var entries []TimeEntry
+	for line := 2; ; line++ {
+		record, err := in.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		get := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		switch get("type") {
+		case "task":
+			task, err := csvTask(get)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if _, ok := tasks[task.ID]; ok {
+				return nil, fmt.Errorf("line %d: task %s is listed twice", line, task.ID)
+			}
+			tasks[task.ID] = len(export.Tasks)
+			export.Tasks = append(export.Tasks, ExportedTask{Task: *task, TimeEntries: []TimeEntry{}})
+		case "entry":
+			entry, err := csvEntry(get)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			entries = append(entries, *entry)
+		default:
+			return nil, fmt.Errorf("line %d: type must be task or entry", line)
+		}
+	}
+
+	for _, entry := range entries {
+		i, ok := tasks[entry.TaskID]
+		if !ok {
+			return nil, fmt.Errorf("time entry %s belongs to task %s, which isn't in the file", entry.ID, entry.TaskID)
+		}
+		export.Tasks[i].TimeEntries = append(export.Tasks[i].TimeEntries, entry)
+	}
+	return export, nil
+}
+
+func csvTask(get func(string) string) (*Task, error) {
+	task := &Task{
+		ID:          get("task_id"),
+		Title:       get("title"),
+		Project:     get("project"),
+		Status:      get("status"),
+		Priority:    get("priority"),
+		DueDate:     get("due_date"),
+		Tags:        splitList(get("tags")),
+		ParentID:    get("parent_id"),
+		BlockedBy:   splitList(get("blocked_by")),
+		Description: get("description"),
+	}
+	if task.ID == "" {
+		return nil, fmt.Errorf("task_id is empty")
+	}
+	if estimate := get("estimate_seconds"); estimate != "" {
+		seconds, err := strconv.Atoi(estimate)
+		if err != nil {
+			return nil, fmt.Errorf("estimate_seconds must be a number")
+		}
+		task.EstimateSeconds = seconds
+	}
+	if createdAt := get("created_at"); createdAt != "" {
+		t, err := time.Parse(time.RFC3339, createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("created_at must be an RFC 3339 time")
+		}
+		task.CreatedAt = t
+	}
+	return task, nil
+}
+
+func csvEntry(get func(string) string) (*TimeEntry, error) {
+	entry := &TimeEntry{ID: get("entry_id"), TaskID: get("task_id"), User: get("user")}
+	start, err := time.Parse(time.RFC3339, get("start_time"))
+	if err != nil {
+		return nil, fmt.Errorf("start_time must be an RFC 3339 time")
+	}
+	end, err := time.Parse(time.RFC3339, get("end_time"))
+	if err != nil {
+		return nil, fmt.Errorf("end_time must be an RFC 3339 time")
+	}
+	if err := CheckEntryTimes(start, end); err != nil {
+		return nil, err
+	}
+	entry.StartTime, entry.EndTime = start, &end
+	entry.DurationSeconds = int(end.Sub(start).Seconds())
+	switch strings.ToLower(get("billable")) {
+	case "no", "false", "0":
+		entry.NonBillable = true
+	}
+	if createdAt := get("created_at"); createdAt != "" {
+		if t, err := time.Parse(time.RFC3339, createdAt); err == nil {
+			entry.CreatedAt = t
+		}
+	}
+	return entry, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Priorities lists the task priorities from lowest to highest.
+var Priorities = []string{"low", "medium", "high", "urgent"}
+
+// DueDateLayout is the format of Task.DueDate.
+const DueDateLayout = "2006-01-02"
+
+// PriorityRank orders priorities for sorting: 0 for none, then 1 for "low"
+// up to len(Priorities) for the highest. Unknown priorities rank as none.
+func PriorityRank(priority string) int {
+	for i, p := range Priorities {
+		if p == priority {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// NormalizeTags trims and lowercases tags and drops empty and repeated ones.
+// The result is sorted and never nil.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// Validate checks the fields of a new task.
+func (r CreateTaskRequest) Validate() error {
+	if strings.TrimSpace(r.Title) == "" {
+		return errors.New("title cannot be empty")
+	}
+	return validateDetails(r.Priority, r.DueDate, r.EstimateSeconds)
+}
+
+// Validate checks the fields a partial update sets.
+func (r UpdateTaskRequest) Validate() error {
+	if r.Title != nil && strings.TrimSpace(*r.Title) == "" {
+		return errors.New("title cannot be empty")
+	}
+
+	var priority, dueDate string
+	var estimate int
+	if r.Priority != nil {
+		priority = *r.Priority
+	}
+	if r.DueDate != nil {
+		dueDate = *r.DueDate
+	}
+	if r.EstimateSeconds != nil {
+		estimate = *r.EstimateSeconds
+	}
+	return validateDetails(priority, dueDate, estimate)
+}
+
+func validateDetails(priority, dueDate string, estimateSeconds int) error {
+	if priority != "" && PriorityRank(priority) == 0 {
+		return fmt.Errorf("priority must be one of %s", strings.Join(Priorities, ", "))
+	}
+	if dueDate != "" {
+		if _, err := time.Parse(DueDateLayout, dueDate); err != nil {
+			return errors.New("due_date must look like YYYY-MM-DD")
+		}
+	}
+	if estimateSeconds < 0 {
+		return errors.New("estimate_seconds cannot be negative")
+	}
+	return nil
+}
+
+// Apply copies the fields an update sets onto a task.
+func (r UpdateTaskRequest) Apply(task *Task) {
+	if r.Title != nil {
+		task.Title = *r.Title
+	}
+	if r.Project != nil {
+		task.Project = *r.Project
+	}
+	if r.ProjectID != nil {
+		task.ProjectID = *r.ProjectID
+	}
+	if r.Status != nil {
+		task.Status = *r.Status
+	}
+	if r.Description != nil {
+		task.Description = *r.Description
+	}
+	if r.Priority != nil {
+		task.Priority = *r.Priority
+	}
+	if r.DueDate != nil {
+		task.DueDate = *r.DueDate
+	}
+	if r.EstimateSeconds != nil {
+		task.EstimateSeconds = *r.EstimateSeconds
+	}
+	if r.Tags != nil {
+		task.Tags = NormalizeTags(*r.Tags)
+	}
+	if r.ParentID != nil {
+		task.ParentID = *r.ParentID
+	}
+}
+
+// SetTimers replaces the running timers of a task and keeps IsActive and
+// StartTime in step with them.
+func (t *Task) SetTimers(timers []RunningTimer) {
+	t.Timers = timers
+	t.IsActive = len(timers) > 0
+	t.StartTime = nil
+	for i := range timers {
+		if t.StartTime == nil || timers[i].StartTime.Before(*t.StartTime) {
+			start := timers[i].StartTime
+			t.StartTime = &start
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package models
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InvoiceFormats lists the formats an invoice can be rendered in.
+var InvoiceFormats = []string{"json", "markdown", "html", "csv"}
+
+// Rate is an hourly rate billable time is charged at. A rate for a project
+// and a user beats one for just the project, which beats one for just the
+// user; one for neither is the default for everything else. Money is kept
+// in whole cents so amounts add up exactly.
+type Rate struct {
+	ProjectID       string `json:"project_id,omitempty"`
+	Project         string `json:"project,omitempty"` // The project's name; setting a rate takes either
+	User            string `json:"user,omitempty"`
+	HourlyRateCents int64  `json:"hourly_rate_cents"`
+}
+
+// Validate checks the rate itself; the project is up to the store.
+func (r Rate) Validate() error {
+	if r.HourlyRateCents < 0 {
+		return errors.New("hourly_rate_cents cannot be negative")
+	}
+	return nil
+}
+
+// ParseCents reads an amount like "90", "90.5" or "90.50" as cents.
+func ParseCents(text string) (int64, error) {
+	units, fraction, _ := strings.Cut(strings.TrimSpace(text), ".")
+	if units == "" || len(fraction) > 2 || strings.Trim(units+fraction, "0123456789") != "" {
+		return 0, fmt.Errorf("%q is not an amount like 90 or 90.50", text)
+	}
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+	cents, err := strconv.ParseInt(units+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not an amount like 90 or 90.50", text)
+	}
+	return cents, nil
+}
+
+// FormatCents writes an amount of cents with two decimals.
+func FormatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// RateFor picks the rate that applies to a user's time on a project, most
+// specific first. ok is false when no rate applies.
+func RateFor(rates []Rate, projectID, user string) (rate Rate, ok bool) {
+	best := -1
+	for _, candidate := range rates {
+		if candidate.ProjectID != "" && candidate.ProjectID != projectID {
+			continue
+		}
+		if candidate.User != "" && !strings.EqualFold(candidate.User, user) {
+			continue
+		}
+
+		score := 0
+		if candidate.ProjectID != "" {
+			score += 2
+		}
+		if candidate.User != "" {
+			score++
+		}
+		if score > best {
+			rate, best = candidate, score
+		}
+	}
+	return rate, best >= 0
+}
+
+// BillableRequest represents a request to mark a time entry billable or not
+type BillableRequest struct {
+	Billable bool `json:"billable"`
+}
+
+// InvoiceQuery picks the time an invoice charges for: the approved,
+// billable time entries of a client's projects that started in a period.
+type InvoiceQuery struct {
+	Client   string // Project.Client, ignoring case
+	From     string // DueDateLayout, inclusive
+	To       string // DueDateLayout, inclusive
+	Currency string // Only shown, rates are plain numbers
+}
+
+// ParseInvoiceQuery reads a query from the client, from, to and currency
+// URL parameters.
+func ParseInvoiceQuery(values url.Values) (InvoiceQuery, error) {
+	query := InvoiceQuery{
+		Client:   strings.TrimSpace(values.Get("client")),
+		From:     values.Get("from"),
+		To:       values.Get("to"),
+		Currency: strings.TrimSpace(values.Get("currency")),
+	}
+	return query, query.Validate()
+}
+
+// Validate checks that the query names a client and a period.
+func (q InvoiceQuery) Validate() error {
+	if q.Client == "" {
+		return errors.New("client is required")
+	}
+	if q.From == "" || q.To == "" {
+		return errors.New("from and to are required")
+	}
+	return ReportQuery{GroupBy: "day", From: q.From, To: q.To}.Validate()
+}
+
+// Values encodes the query as URL parameters for ParseInvoiceQuery.
+func (q InvoiceQuery) Values() url.Values {
+	values := url.Values{"client": {q.Client}, "from": {q.From}, "to": {q.To}}
+	if q.Currency != "" {
+		values.Set("currency", q.Currency)
+	}
+	return values
+}
+
+// Matches reports whether a time entry falls in the query's period.
+func (q InvoiceQuery) Matches(entry TimeEntry) bool {
+	return ReportQuery{From: q.From, To: q.To}.Matches(entry)
+}
+
+// Invoice totals a client's billable time per project and user.
+type Invoice struct {
+	Client       string        `json:"client"`
+	From         string        `json:"from"`
+	To           string        `json:"to"`
+	Currency     string        `json:"currency,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+	Lines        []InvoiceLine `json:"lines"`
+	TotalSeconds int           `json:"total_seconds"`
+	TotalCents   int64         `json:"total_cents"` // The sum of the lines' amounts
+}
+
+// InvoiceLine is the time one user spent on one project.
+type InvoiceLine struct {
+	Project         string `json:"project"`
+	User            string `json:"user"`
+	Entries         int    `json:"entries"`
+	Seconds         int    `json:"seconds"`
+	HourlyRateCents int64  `json:"hourly_rate_cents"`
+	Unrated         bool   `json:"unrated,omitempty"` // No rate applies, so it adds nothing to the total
+	AmountCents     int64  `json:"amount_cents"`
+}
+
+// Price sets the line's amount from its time and rate, rounded to the
+// nearest cent.
+func (l *InvoiceLine) Price() {
+	l.AmountCents = (int64(l.Seconds)*l.HourlyRateCents + 1800) / 3600
+}
+
+// Hours is the line's time in hours.
+func (l InvoiceLine) Hours() float64 {
+	return float64(l.Seconds) / 3600
+}
+
+// CheckInvoiceFormat makes sure format is one of InvoiceFormats.
+func CheckInvoiceFormat(format string) error {
+	for _, known := range InvoiceFormats {
+		if format == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("format must be one of %s", strings.Join(InvoiceFormats, ", "))
+}
+
+// InvoiceContentType is the media type of an invoice in a format.
+func InvoiceContentType(format string) string {
+	switch format {
+	case "markdown":
+		return "text/markdown; charset=utf-8"
+	case "html":
+		return "text/html; charset=utf-8"
+	case "csv":
+		return "text/csv; charset=utf-8"
+	default:
+		return "application/json"
+	}
+}
+
+// WriteInvoice renders an invoice as JSON, Markdown, HTML or CSV.
+func WriteInvoice(w io.Writer, format string, invoice *Invoice) error {
+	switch format {
+	case "markdown":
+		return writeInvoiceMarkdown(w, invoice)
+	case "html":
+		return invoiceTemplate.Execute(w, invoice)
+	case "csv":
+		return writeInvoiceCSV(w, invoice)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(invoice)
+}
+
+// Money formats an amount of cents with the invoice's currency, if it has
+// one.
+func (i *Invoice) Money(cents int64) string {
+	text := FormatCents(cents)
+	if i.Currency != "" {
+		text += " " + i.Currency
+	}
+	return text
+}
+
+func writeInvoiceMarkdown(w io.Writer, invoice *Invoice) error {
+	cell := strings.NewReplacer("|", `\|`, "\n", " ").Replace
+
+	var s strings.Builder
+	fmt.Fprintf(&s, "# Invoice for %s\n\n", cell(invoice.Client))
+	fmt.Fprintf(&s, "Period: %s to %s  \nIssued: %s\n\n", invoice.From, invoice.To, invoice.CreatedAt.Format(DueDateLayout))
+	s.WriteString("| Project | User | Hours | Rate | Amount |\n")
+	s.WriteString("|---|---|---:|---:|---:|\n")
+	for _, line := range invoice.Lines {
+		rate := invoice.Money(line.HourlyRateCents)
+		if line.Unrated {
+			rate = "no rate"
+		}
+		fmt.Fprintf(&s, "| %s | %s | %.2f | %s | %s |\n",
+			cell(line.Project), cell(line.User), line.Hours(), rate, invoice.Money(line.AmountCents))
+	}
+	fmt.Fprintf(&s, "| **Total** | | **%.2f** | | **%s** |\n", float64(invoice.TotalSeconds)/3600, invoice.Money(invoice.TotalCents))
+
+	_, err := io.WriteString(w, s.String())
+	return err
+}
+
+var invoiceTemplate = template.Must(template.New("invoice").Funcs(template.FuncMap{
+	"hours": func(seconds int) string { return fmt.Sprintf("%.2f", float64(seconds)/3600) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Invoice for {{.Client}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { padding: 0.3em 0.8em; border-bottom: 1px solid #ccc; text-align: left; }
+td.number, th.number { text-align: right; }
+tfoot td { font-weight: bold; border-bottom: none; }
+</style>
+</head>
+<body>
+<h1>Invoice for {{.Client}}</h1>
+<p>Period: {{.From}} to {{.To}}<br>Issued: {{.CreatedAt.Format "2006-01-02"}}</p>
+<table>
+<thead><tr><th>Project</th><th>User</th><th class="number">Hours</th><th class="number">Rate</th><th class="number">Amount</th></tr></thead>
+<tbody>
+{{- range .Lines}}
+<tr><td>{{.Project}}</td><td>{{.User}}</td><td class="number">{{hours .Seconds}}</td><td class="number">{{if .Unrated}}no rate{{else}}{{$.Money .HourlyRateCents}}{{end}}</td><td class="number">{{$.Money .AmountCents}}</td></tr>
+{{- end}}
+</tbody>
+<tfoot><tr><td>Total</td><td></td><td class="number">{{hours .TotalSeconds}}</td><td></td><td class="number">{{.Money .TotalCents}}</td></tr></tfoot>
+</table>
+</body>
+</html>
+`))
+
+// invoiceColumns are the columns of a CSV invoice. The last row is the
+// total, with "total" as its project.
+var invoiceColumns = []string{"project", "user", "entries", "hours", "hourly_rate", "amount", "currency"}
+
+func writeInvoiceCSV(w io.Writer, invoice *Invoice) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(invoiceColumns); err != nil {
+		return err
+	}
+
+	hours := func(seconds int) string { return strconv.FormatFloat(float64(seconds)/3600, 'f', 2, 64) }
+	entries := 0
+	for _, line := range invoice.Lines {
+		rate := FormatCents(line.HourlyRateCents)
+		if line.Unrated {
+			rate = ""
+		}
+		row := []string{line.Project, line.User, strconv.Itoa(line.Entries), hours(line.Seconds), rate, FormatCents(line.AmountCents), invoice.Currency}
+		if err := out.Write(row); err != nil {
+			return err
+		}
+		entries += line.Entries
+	}
+	if err := out.Write([]string{"total", "", strconv.Itoa(entries), hours(invoice.TotalSeconds), "", FormatCents(invoice.TotalCents), invoice.Currency}); err != nil {
+		return err
+	}
+
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+)
+
+func TestParseCents(t *testing.T) {
+	for text, want := range map[string]int64{
+		"90": 9000, "90.5": 9050, "90.50": 9050, "0.07": 7, " 12.3 ": 1230, "007": 700,
+	} {
+		got, err := ParseCents(text)
+		if err != nil || got != want {
+			t.Errorf("ParseCents(%q) = %d, %v; want %d", text, got, err, want)
+		}
+	}
+
+	for _, text := range []string{"", ".5", "90.505", "-5", "1e3", "9,50", "abc", "99999999999999999999"} {
+		if got, err := ParseCents(text); err == nil {
+			t.Errorf("ParseCents(%q) = %d, want an error", text, got)
+		}
+	}
+}
+
+func TestFormatCents(t *testing.T) {
+	for cents, want := range map[int64]string{0: "0.00", 7: "0.07", 9050: "90.50", -1230: "-12.30"} {
+		if got := FormatCents(cents); got != want {
+			t.Errorf("FormatCents(%d) = %q, want %q", cents, got, want)
+		}
+		if cents >= 0 {
+			if back, err := ParseCents(want); err != nil || back != cents {
+				t.Errorf("ParseCents(FormatCents(%d)) = %d, %v", cents, back, err)
+			}
+		}
+	}
+}
+
+func TestPriceRoundsToTheNearestCent(t *testing.T) {
+	for _, test := range []struct {
+		seconds int
+		rate    int64
+		want    int64
+	}{
+		{3600, 9000, 9000},
+		{5400, 9000, 13500},
+		{1, 9000, 3}, // 2.5 cents rounds up
+		{1, 5000, 1}, // 1.39 cents rounds down
+		{20, 9000, 50},
+		{7, 100, 0},  // 0.19 cents
+		{18, 100, 1}, // Exactly half a cent
+		{0, 9000, 0},
+		{3600, 0, 0},
+	} {
+		line := InvoiceLine{Seconds: test.seconds, HourlyRateCents: test.rate}
+		line.Price()
+		if line.AmountCents != test.want {
+			t.Errorf("%ds at %d cents an hour = %d cents, want %d", test.seconds, test.rate, line.AmountCents, test.want)
+		}
+	}
+}
+
+func TestRateFor(t *testing.T) {
+	rates := []Rate{
+		{HourlyRateCents: 100},
+		{User: "alice", HourlyRateCents: 200},
+		{ProjectID: "web", HourlyRateCents: 300},
+		{ProjectID: "web", User: "alice", HourlyRateCents: 400},
+	}
+	for _, test := range []struct {
+		project, user string
+		want          int64
+	}{
+		{"web", "Alice", 400},
+		{"web", "bob", 300},
+		{"app", "alice", 200},
+		{"app", "bob", 100},
+	} {
+		rate, ok := RateFor(rates, test.project, test.user)
+		if !ok || rate.HourlyRateCents != test.want {
+			t.Errorf("rate for %s on %s = %d, want %d", test.user, test.project, rate.HourlyRateCents, test.want)
+		}
+	}
+
+	if _, ok := RateFor(rates[1:], "app", "bob"); ok {
+		t.Error("found a rate without a default")
+	}
+}
+
+func TestInvoiceCSVTotals(t *testing.T) {
+	invoice := &Invoice{
+		Client: "Acme", Currency: "EUR", TotalSeconds: 5400, TotalCents: 13500,
+		Lines: []InvoiceLine{
+			{Project: "Web", User: "alice", Entries: 2, Seconds: 3600, HourlyRateCents: 9000, AmountCents: 9000},
+			{Project: "Web", User: "bob", Entries: 1, Seconds: 1800, HourlyRateCents: 9000, AmountCents: 4500},
+			{Project: "Web", User: "carol", Entries: 1, Unrated: true},
+		},
+	}
+
+	var out bytes.Buffer
+	if err := WriteInvoice(&out, "csv", invoice); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("%d rows, want a header, 3 lines and a total", len(rows))
+	}
+	if rows[3][4] != "" {
+		t.Errorf("unrated line has rate %q", rows[3][4])
+	}
+	total := rows[4]
+	if total[0] != "total" || total[2] != "4" || total[3] != "1.50" || total[5] != "135.00" || total[6] != "EUR" {
+		t.Errorf("total row = %q", total)
+	}
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Project groups tasks. Tasks link to one through ProjectID and carry its
+// name in Project.
+type Project struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Color       string    `json:"color,omitempty"`        // "#RRGGBB" or an ANSI color number, empty for the default
+	Description string    `json:"description,omitempty"`  // Markdown
+	Archived    bool      `json:"archived"`               // Hidden from autocomplete; its tasks are kept
+	BudgetHours float64   `json:"budget_hours,omitempty"` // Zero means no budget
+	Client      string    `json:"client,omitempty"`       // Who its billable time is invoiced to
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CreateProjectRequest represents a request to create a project
+type CreateProjectRequest struct {
+	Name        string  `json:"name"`
+	Color       string  `json:"color,omitempty"`
+	Description string  `json:"description,omitempty"`
+	BudgetHours float64 `json:"budget_hours,omitempty"`
+	Client      string  `json:"client,omitempty"`
+}
+
+// UpdateProjectRequest represents a partial update of a project. Fields left
+// nil are not changed; an empty color or client or a zero budget clears the
+// field.
+type UpdateProjectRequest struct {
+	Name        *string  `json:"name,omitempty"`
+	Color       *string  `json:"color,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Archived    *bool    `json:"archived,omitempty"`
+	BudgetHours *float64 `json:"budget_hours,omitempty"`
+	Client      *string  `json:"client,omitempty"`
+}
+
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Validate checks the fields of a new project.
+func (r CreateProjectRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("name cannot be empty")
+	}
+	return validateProject(r.Color, r.BudgetHours)
+}
+
+// Validate checks the fields a partial update sets.
+func (r UpdateProjectRequest) Validate() error {
+	if r.Name != nil && strings.TrimSpace(*r.Name) == "" {
+		return errors.New("name cannot be empty")
+	}
+
+	var color string
+	var budget float64
+	if r.Color != nil {
+		color = *r.Color
+	}
+	if r.BudgetHours != nil {
+		budget = *r.BudgetHours
+	}
+	return validateProject(color, budget)
+}
+
+func validateProject(color string, budgetHours float64) error {
+	if err := validateColor(color); err != nil {
+		return err
+	}
+	if budgetHours < 0 {
+		return errors.New("budget_hours cannot be negative")
+	}
+	return nil
+}
+
+// validateColor accepts "#RRGGBB", an ANSI color number or nothing.
+func validateColor(color string) error {
+	if color != "" && !hexColor.MatchString(color) {
+		if n, err := strconv.Atoi(color); err != nil || n < 0 || n > 255 {
+			return errors.New("color must look like #RRGGBB or be an ANSI color from 0 to 255")
+		}
+	}
+	return nil
+}
+
+// Apply copies the fields an update sets onto a project.
+func (r UpdateProjectRequest) Apply(project *Project) {
+	if r.Name != nil {
+		project.Name = strings.TrimSpace(*r.Name)
+	}
+	if r.Color != nil {
+		project.Color = *r.Color
+	}
+	if r.Description != nil {
+		project.Description = *r.Description
+	}
+	if r.Archived != nil {
+		project.Archived = *r.Archived
+	}
+	if r.BudgetHours != nil {
+		project.BudgetHours = *r.BudgetHours
+	}
+	if r.Client != nil {
+		project.Client = strings.TrimSpace(*r.Client)
+	}
+}
@@ -0,0 +1,207 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TaskSortFields lists what GET /api/v1/tasks can sort by. Ties are broken
+// by task ID, so pages never overlap.
+var TaskSortFields = []string{"created_at", "title", "due_date", "priority"}
+
+// DefaultTaskSort is the order tasks come in without a sort parameter:
+// newest first.
+const DefaultTaskSort = "-created_at"
+
+// TaskQuery selects, orders and pages the tasks of GET /api/v1/tasks. Zero
+// fields don't filter; the zero query returns every task newest first.
+type TaskQuery struct {
+	ProjectID   string   // Tasks linked to this project
+	Project     string   // Tasks with this project name, ignoring case
+	Statuses    []string // Tasks with any of these statuses
+	Assignee    string   // Tasks this user runs a timer on or has time entries for
+	Tags        []string // Tasks with all of these tags
+	DueFrom     string   // DueDateLayout, inclusive; tasks without a due date never match a due range
+	DueTo       string   // DueDateLayout, inclusive
+	CreatedFrom string   // DueDateLayout, inclusive
+	CreatedTo   string   // DueDateLayout, inclusive
+	Search      string   // Words that must all appear in the title, description or project
+	Sort        string   // One of TaskSortFields, prefixed with "-" for descending
+	Limit       int      // Page size, zero for no limit
+	Cursor      string   // Where the previous page ended
+}
+
+// ParseTaskQuery reads a query from URL parameters: project_id, project,
+// status (comma separated, any of), assignee, tag (repeatable, all of),
+// due_from, due_to, created_from, created_to, q, sort, limit and cursor.
+func ParseTaskQuery(values url.Values) (TaskQuery, error) {
+	query := TaskQuery{
+		ProjectID:   values.Get("project_id"),
+		Project:     strings.TrimSpace(values.Get("project")),
+		Assignee:    strings.TrimSpace(values.Get("assignee")),
+		DueFrom:     values.Get("due_from"),
+		DueTo:       values.Get("due_to"),
+		CreatedFrom: values.Get("created_from"),
+		CreatedTo:   values.Get("created_to"),
+		Search:      strings.TrimSpace(values.Get("q")),
+		Sort:        values.Get("sort"),
+		Cursor:      values.Get("cursor"),
+	}
+	for _, status := range strings.Split(values.Get("status"), ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			query.Statuses = append(query.Statuses, status)
+		}
+	}
+	if tags := NormalizeTags(values["tag"]); len(tags) > 0 {
+		query.Tags = tags
+	}
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return TaskQuery{}, errors.New("limit must be a positive number")
+		}
+		query.Limit = limit
+	}
+
+	return query, query.Validate()
+}
+
+// Validate checks the query's dates and sort order.
+func (q TaskQuery) Validate() error {
+	dates := []struct{ name, value string }{
+		{"due_from", q.DueFrom}, {"due_to", q.DueTo},
+		{"created_from", q.CreatedFrom}, {"created_to", q.CreatedTo},
+	}
+	for _, date := range dates {
+		if date.value == "" {
+			continue
+		}
+		if _, err := time.Parse(DueDateLayout, date.value); err != nil {
+			return fmt.Errorf("%s must be a date like %s", date.name, DueDateLayout)
+		}
+	}
+
+	field, _ := q.SortField()
+	for _, known := range TaskSortFields {
+		if field == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("sort must be one of %s, optionally prefixed with -", strings.Join(TaskSortFields, ", "))
+}
+
+// SortField returns the field the query sorts by and whether the order is
+// descending.
+func (q TaskQuery) SortField() (field string, descending bool) {
+	sort := q.Sort
+	if sort == "" {
+		sort = DefaultTaskSort
+	}
+	return strings.TrimPrefix(sort, "-"), strings.HasPrefix(sort, "-")
+}
+
+// SearchWords splits Search into lowercased words.
+func (q TaskQuery) SearchWords() []string {
+	return strings.Fields(strings.ToLower(q.Search))
+}
+
+// Values encodes the query as URL parameters for ParseTaskQuery.
+func (q TaskQuery) Values() url.Values {
+	values := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	set("project_id", q.ProjectID)
+	set("project", q.Project)
+	set("status", strings.Join(q.Statuses, ","))
+	set("assignee", q.Assignee)
+	for _, tag := range q.Tags {
+		values.Add("tag", tag)
+	}
+	set("due_from", q.DueFrom)
+	set("due_to", q.DueTo)
+	set("created_from", q.CreatedFrom)
+	set("created_to", q.CreatedTo)
+	set("q", q.Search)
+	set("sort", q.Sort)
+	if q.Limit > 0 {
+		values.Set("limit", strconv.Itoa(q.Limit))
+	}
+	set("cursor", q.Cursor)
+	return values
+}
+
+// Matches reports whether a task passes the query's filters. entries are
+// the task's time entries, for the assignee filter.
+func (q TaskQuery) Matches(task Task, entries []TimeEntry) bool {
+	if q.ProjectID != "" && task.ProjectID != q.ProjectID {
+		return false
+	}
+	if q.Project != "" && !strings.EqualFold(task.Project, q.Project) {
+		return false
+	}
+	if len(q.Statuses) > 0 && !containsString(q.Statuses, task.Status) {
+		return false
+	}
+	if q.Assignee != "" && !workedOn(q.Assignee, task, entries) {
+		return false
+	}
+	for _, tag := range q.Tags {
+		if !containsString(task.Tags, tag) {
+			return false
+		}
+	}
+
+	if q.DueFrom != "" && (task.DueDate == "" || task.DueDate < q.DueFrom) {
+		return false
+	}
+	if q.DueTo != "" && (task.DueDate == "" || task.DueDate > q.DueTo) {
+		return false
+	}
+	created := task.CreatedAt.UTC().Format(DueDateLayout)
+	if q.CreatedFrom != "" && created < q.CreatedFrom {
+		return false
+	}
+	if q.CreatedTo != "" && created > q.CreatedTo {
+		return false
+	}
+
+	text := strings.ToLower(task.Title + " " + task.Description + " " + task.Project)
+	for _, word := range q.SearchWords() {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// workedOn reports whether user runs a timer on the task or has time
+// entries for it, ignoring case.
+func workedOn(user string, task Task, entries []TimeEntry) bool {
+	for _, timer := range task.Timers {
+		if strings.EqualFold(timer.User, user) {
+			return true
+		}
+	}
+	for _, entry := range entries {
+		if strings.EqualFold(entry.User, user) {
+			return true
+		}
+	}
+	return false
+}
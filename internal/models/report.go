@@ -0,0 +1,190 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReportGroups lists what time reports can group entries by.
+var ReportGroups = []string{"project", "user", "day", "week", "month"}
+
+// ReportQuery picks the time entries a report adds up and how it groups
+// them. Entries count towards the day they started on.
+type ReportQuery struct {
+	GroupBy string // One of ReportGroups
+	From    string // DueDateLayout, inclusive; empty for no lower bound
+	To      string // DueDateLayout, inclusive; empty for no upper bound
+	User    string // Only this user's entries, ignoring case; empty for everyone's
+}
+
+// ParseReportQuery reads a query from the group_by, from, to and user URL
+// parameters.
+func ParseReportQuery(values url.Values) (ReportQuery, error) {
+	query := ReportQuery{
+		GroupBy: values.Get("group_by"),
+		From:    values.Get("from"),
+		To:      values.Get("to"),
+		User:    strings.TrimSpace(values.Get("user")),
+	}
+	return query, query.Validate()
+}
+
+// Validate checks the grouping and the dates.
+func (q ReportQuery) Validate() error {
+	known := false
+	for _, group := range ReportGroups {
+		known = known || q.GroupBy == group
+	}
+	if !known {
+		return fmt.Errorf("group_by must be one of %s", strings.Join(ReportGroups, ", "))
+	}
+
+	for _, date := range []struct{ name, value string }{{"from", q.From}, {"to", q.To}} {
+		if date.value == "" {
+			continue
+		}
+		if _, err := time.Parse(DueDateLayout, date.value); err != nil {
+			return fmt.Errorf("%s must be a date like %s", date.name, DueDateLayout)
+		}
+	}
+	if q.From != "" && q.To != "" && q.To < q.From {
+		return fmt.Errorf("to cannot be before from")
+	}
+	return nil
+}
+
+// Period returns the instants the query's dates start and end at in the
+// local time zone, the end being midnight after To. Either is zero where
+// the query has no bound.
+func (q ReportQuery) Period() (start, end time.Time) {
+	if from, err := time.ParseInLocation(DueDateLayout, q.From, time.Local); err == nil {
+		start = from
+	}
+	if to, err := time.ParseInLocation(DueDateLayout, q.To, time.Local); err == nil {
+		end = to.AddDate(0, 0, 1)
+	}
+	return start, end
+}
+
+// Values encodes the query as URL parameters for ParseReportQuery.
+func (q ReportQuery) Values() url.Values {
+	values := url.Values{"group_by": {q.GroupBy}}
+	if q.From != "" {
+		values.Set("from", q.From)
+	}
+	if q.To != "" {
+		values.Set("to", q.To)
+	}
+	if q.User != "" {
+		values.Set("user", q.User)
+	}
+	return values
+}
+
+// Matches reports whether a time entry falls in the query's dates and
+// belongs to its user.
+func (q ReportQuery) Matches(entry TimeEntry) bool {
+	if entry.EndTime == nil {
+		return false
+	}
+	day := entry.StartTime.Local().Format(DueDateLayout)
+	if (q.From != "" && day < q.From) || (q.To != "" && day > q.To) {
+		return false
+	}
+	return q.User == "" || strings.EqualFold(entry.User, q.User)
+}
+
+// Key returns the group a task's time entry falls in: the project name,
+// the user, or the period's first day as YYYY-MM-DD (weeks start on
+// Monday) or YYYY-MM for months.
+func (q ReportQuery) Key(task Task, entry TimeEntry) string {
+	start := entry.StartTime.Local()
+	switch q.GroupBy {
+	case "project":
+		return task.Project
+	case "user":
+		return entry.User
+	case "week":
+		return WeekStart(start).Format(DueDateLayout)
+	case "month":
+		return start.Format("2006-01")
+	default:
+		return start.Format(DueDateLayout)
+	}
+}
+
+// WeekStart returns midnight of the Monday of t's week.
+func WeekStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// TimeReport adds up recorded time per group. Running timers don't count
+// until they are stopped.
+type TimeReport struct {
+	GroupBy      string      `json:"group_by"`
+	From         string      `json:"from,omitempty"`
+	To           string      `json:"to,omitempty"`
+	Rows         []ReportRow `json:"rows"`
+	TotalSeconds int         `json:"total_seconds"`
+}
+
+// ReportRow is one group of a TimeReport.
+type ReportRow struct {
+	Key     string `json:"key"` // See ReportQuery.Key; empty for entries without a project or user
+	Seconds int    `json:"seconds"`
+	Entries int    `json:"entries"`
+}
+
+// NewTimeReport starts an empty report for a query.
+func NewTimeReport(query ReportQuery) *TimeReport {
+	return &TimeReport{GroupBy: query.GroupBy, From: query.From, To: query.To, Rows: []ReportRow{}}
+}
+
+// Add counts entries worth seconds towards the group key.
+func (r *TimeReport) Add(key string, seconds, entries int) {
+	r.TotalSeconds += seconds
+	for i := range r.Rows {
+		if r.Rows[i].Key == key {
+			r.Rows[i].Seconds += seconds
+			r.Rows[i].Entries += entries
+			return
+		}
+	}
+	r.Rows = append(r.Rows, ReportRow{Key: key, Seconds: seconds, Entries: entries})
+}
+
+// Merge adds another report's rows to this one.
+func (r *TimeReport) Merge(other TimeReport) {
+	for _, row := range other.Rows {
+		r.Add(row.Key, row.Seconds, row.Entries)
+	}
+	r.Sort()
+}
+
+// Sort puts periods in date order and projects and users with the most
+// time first.
+func (r *TimeReport) Sort() {
+	sort.SliceStable(r.Rows, func(i, j int) bool {
+		a, b := r.Rows[i], r.Rows[j]
+		if r.GroupBy == "project" || r.GroupBy == "user" {
+			if a.Seconds != b.Seconds {
+				return a.Seconds > b.Seconds
+			}
+		}
+		return a.Key < b.Key
+	})
+}
+
+// Seconds returns the time of a group, zero if the report doesn't have it.
+func (r *TimeReport) Seconds(key string) int {
+	for _, row := range r.Rows {
+		if row.Key == key {
+			return row.Seconds
+		}
+	}
+	return 0
+}
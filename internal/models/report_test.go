@@ -0,0 +1,77 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// inZone runs a test with the local time zone set to zone.
+func inZone(t *testing.T, zone *time.Location) {
+	local := time.Local
+	time.Local = zone
+	t.Cleanup(func() { time.Local = local })
+}
+
+func TestReportQueryGroupsLocalDays(t *testing.T) {
+	inZone(t, time.FixedZone("UTC-5", -5*3600))
+
+	// Sunday evening locally, already Monday in UTC
+	start := time.Date(2026, 9, 7, 2, 30, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	entry := TimeEntry{User: "alice", StartTime: start, EndTime: &end, DurationSeconds: 3600}
+	task := Task{Project: "Web"}
+
+	for group, want := range map[string]string{
+		"day": "2026-09-06", "week": "2026-08-31", "month": "2026-09", "project": "Web", "user": "alice",
+	} {
+		if got := (ReportQuery{GroupBy: group}).Key(task, entry); got != want {
+			t.Errorf("key by %s = %q, want %q", group, got, want)
+		}
+	}
+
+	if !(ReportQuery{GroupBy: "day", From: "2026-09-06", To: "2026-09-06"}).Matches(entry) {
+		t.Error("entry doesn't match its local day")
+	}
+	if (ReportQuery{GroupBy: "day", From: "2026-09-07"}).Matches(entry) {
+		t.Error("entry matches the day it starts on in UTC")
+	}
+	if (ReportQuery{GroupBy: "day", User: "bob"}).Matches(entry) {
+		t.Error("alice's entry matches bob's report")
+	}
+	running := TimeEntry{User: "alice", StartTime: start}
+	if (ReportQuery{GroupBy: "day"}).Matches(running) {
+		t.Error("a running entry matches")
+	}
+}
+
+func TestReportQueryPeriod(t *testing.T) {
+	zone := time.FixedZone("UTC-5", -5*3600)
+	inZone(t, zone)
+
+	from, to := ReportQuery{From: "2026-09-01", To: "2026-09-30"}.Period()
+	if want := time.Date(2026, 9, 1, 0, 0, 0, 0, zone); !from.Equal(want) {
+		t.Errorf("period starts at %s, want %s", from, want)
+	}
+	if want := time.Date(2026, 10, 1, 0, 0, 0, 0, zone); !to.Equal(want) {
+		t.Errorf("period ends at %s, want %s", to, want)
+	}
+
+	if from, to := (ReportQuery{}).Period(); !from.IsZero() || !to.IsZero() {
+		t.Errorf("open period = %s to %s", from, to)
+	}
+}
+
+func TestReportQueryValidate(t *testing.T) {
+	for _, query := range []ReportQuery{
+		{GroupBy: "year"},
+		{GroupBy: "day", From: "09/01/2026"},
+		{GroupBy: "day", From: "2026-09-02", To: "2026-09-01"},
+	} {
+		if query.Validate() == nil {
+			t.Errorf("%+v is valid", query)
+		}
+	}
+	if err := (ReportQuery{GroupBy: "week", From: "2026-09-01", To: "2026-09-01"}).Validate(); err != nil {
+		t.Errorf("one-day week report: %v", err)
+	}
+}
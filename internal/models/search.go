@@ -0,0 +1,16 @@
+package models
+
+// Search highlights wrap the matched words in SearchResult's Title and
+// Snippet.
+const (
+	HighlightStart = "<mark>"
+	HighlightStop  = "</mark>"
+)
+
+// SearchResult is a task matching a full-text search, best matches first.
+type SearchResult struct {
+	Task    Task    `json:"task"`
+	Rank    float64 `json:"rank"`              // Higher is a better match; only comparable within one search
+	Title   string  `json:"title"`             // The task's title with the matches highlighted
+	Snippet string  `json:"snippet,omitempty"` // The parts of the description that match, highlighted
+}
@@ -1,18 +1,65 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Task represents a task in the system
 type Task struct {
-	ID               string     `json:"id"`
-	Title            string     `json:"title"`
-	Project          string     `json:"project"`
-	Status           string     `json:"status"`
-	IsActive         bool       `json:"is_active"`
-	StartTime        *time.Time `json:"start_time,omitempty"`
-	TotalTimeSeconds int        `json:"total_time_seconds"`
-	CreatedAt        time.Time  `json:"created_at"`
-	IsPersonal       bool       `json:"is_personal"` // New field to distinguish personal vs team tasks
+	ID                string         `json:"id"`
+	Title             string         `json:"title"`
+	Project           string         `json:"project"`
+	ProjectID         string         `json:"project_id,omitempty"` // Empty when Project is free text
+	Status            string         `json:"status"`
+	Description       string         `json:"description,omitempty"`      // Markdown
+	Priority          string         `json:"priority,omitempty"`         // One of Priorities, or empty for none
+	DueDate           string         `json:"due_date,omitempty"`         // DueDateLayout, or empty for none
+	EstimateSeconds   int            `json:"estimate_seconds,omitempty"` // Zero means no estimate
+	Tags              []string       `json:"tags,omitempty"`
+	ParentID          string         `json:"parent_id,omitempty"`  // Empty for a top-level task
+	BlockedBy         []string       `json:"blocked_by,omitempty"` // IDs of the tasks this one waits for
+	IsActive          bool           `json:"is_active"`            // Someone has a timer running
+	StartTime         *time.Time     `json:"start_time,omitempty"` // When the earliest running timer started
+	Timers            []RunningTimer `json:"timers,omitempty"`
+	TotalTimeSeconds  int            `json:"total_time_seconds"`
+	RollupTimeSeconds int            `json:"rollup_time_seconds,omitempty"` // Own time plus all subtasks outside the trash, done or not
+	CreatedAt         time.Time      `json:"created_at"`
+	IsPersonal        bool           `json:"is_personal"`          // New field to distinguish personal vs team tasks
+	Version           int            `json:"version"`              // Bumped on every change, used for optimistic locking
+	DeletedAt         *time.Time     `json:"deleted_at,omitempty"` // Set while the task is in the trash
+}
+
+// RunningTimer is a timer someone has running on a task. Several people
+// can time the same team task at once, each with their own timer.
+type RunningTimer struct {
+	User      string    `json:"user,omitempty"` // Empty on personal tasks
+	StartTime time.Time `json:"start_time"`
+}
+
+// TimeEntry is one recorded stretch of work on a task. It mirrors the
+// time_entries table on the server.
+type TimeEntry struct {
+	ID              string     `json:"id"`
+	TaskID          string     `json:"task_id"`
+	User            string     `json:"user,omitempty"` // Who did the work, empty on personal tasks
+	StartTime       time.Time  `json:"start_time"`
+	EndTime         *time.Time `json:"end_time,omitempty"`
+	DurationSeconds int        `json:"duration_seconds"`
+	NonBillable     bool       `json:"non_billable,omitempty"` // Left off invoices; entries are billable unless marked
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// AuditEvent records one change to a team task: who made it, when, and
+// what the changed record looked like before and after.
+type AuditEvent struct {
+	ID        string          `json:"id"`
+	TaskID    string          `json:"task_id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
 }
 
 // WSMessage represents a WebSocket message
@@ -23,11 +70,57 @@ type WSMessage struct {
 
 // CreateTaskRequest represents a request to create a task
 type CreateTaskRequest struct {
-	Title   string `json:"title"`
-	Project string `json:"project"`
+	Title           string   `json:"title"`
+	Project         string   `json:"project"`
+	ProjectID       string   `json:"project_id,omitempty"`
+	Description     string   `json:"description,omitempty"`
+	Priority        string   `json:"priority,omitempty"`
+	DueDate         string   `json:"due_date,omitempty"`
+	EstimateSeconds int      `json:"estimate_seconds,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+	ParentID        string   `json:"parent_id,omitempty"`
+}
+
+// UpdateTaskRequest represents a partial update of a task. Fields left nil
+// are not changed; an empty priority, due date, parent or project ID, a zero
+// estimate or an empty tag list clears the field.
+type UpdateTaskRequest struct {
+	Title           *string   `json:"title,omitempty"`
+	Project         *string   `json:"project,omitempty"`
+	ProjectID       *string   `json:"project_id,omitempty"`
+	Status          *string   `json:"status,omitempty"`
+	Description     *string   `json:"description,omitempty"`
+	Priority        *string   `json:"priority,omitempty"`
+	DueDate         *string   `json:"due_date,omitempty"`
+	EstimateSeconds *int      `json:"estimate_seconds,omitempty"`
+	Tags            *[]string `json:"tags,omitempty"`
+	ParentID        *string   `json:"parent_id,omitempty"`
+}
+
+// DependencyRequest represents a request to make a task wait for another
+type DependencyRequest struct {
+	BlockedBy string `json:"blocked_by"`
+}
+
+// BlockedResponse is the body of a 423 Locked answer: the change needs the
+// task's open blockers to be done first, or has to be forced.
+type BlockedResponse struct {
+	Error     string `json:"error"`
+	BlockedBy []Task `json:"blocked_by"`
 }
 
 // UpdateStatusRequest represents a request to update task status
 type UpdateStatusRequest struct {
 	Status string `json:"status"`
-}
\ No newline at end of file
+}
+
+// TimeEntryRequest represents a request to add or change a time entry
+type TimeEntryRequest struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+// SplitTimeEntryRequest represents a request to cut a time entry in two
+type SplitTimeEntryRequest struct {
+	At time.Time `json:"at"`
+}
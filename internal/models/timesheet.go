@@ -0,0 +1,105 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Timesheet statuses. A week nobody submitted is open; a rejected one can
+// be fixed and submitted again, and an approved one locks the user's time
+// entries of that week until a lead rejects it after all.
+const (
+	TimesheetOpen      = "open"
+	TimesheetSubmitted = "submitted"
+	TimesheetApproved  = "approved"
+	TimesheetRejected  = "rejected"
+)
+
+// timesheetMoves lists the statuses a timesheet may move to from each one.
+var timesheetMoves = map[string][]string{
+	TimesheetOpen:      {TimesheetSubmitted},
+	TimesheetRejected:  {TimesheetSubmitted},
+	TimesheetSubmitted: {TimesheetApproved, TimesheetRejected},
+	TimesheetApproved:  {TimesheetRejected},
+}
+
+// Timesheet is one user's time for one week, which they submit and a lead
+// then approves or rejects.
+type Timesheet struct {
+	User         string           `json:"user"`
+	WeekStart    string           `json:"week_start"` // DueDateLayout, always a Monday
+	Status       string           `json:"status"`
+	Comment      string           `json:"comment,omitempty"` // The reviewer's, cleared on submission
+	SubmittedAt  *time.Time       `json:"submitted_at,omitempty"`
+	ReviewedBy   string           `json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time       `json:"reviewed_at,omitempty"`
+	TotalSeconds int              `json:"total_seconds"`
+	Entries      []TimesheetEntry `json:"entries,omitempty"` // Only when a single timesheet is fetched
+}
+
+// TimesheetEntry is a time entry of a timesheet with the task it is for.
+type TimesheetEntry struct {
+	TimeEntry
+	TaskTitle string `json:"task_title"`
+	Project   string `json:"project,omitempty"`
+}
+
+// TimesheetChange moves a user's timesheet to another status. Reviewer and
+// Comment are only kept for approvals and rejections.
+type TimesheetChange struct {
+	User     string
+	Week     string // WeekStart of the timesheet
+	Status   string
+	Reviewer string
+	Comment  string
+}
+
+// ReviewRequest represents a request to approve or reject a timesheet
+type ReviewRequest struct {
+	Comment string `json:"comment"`
+}
+
+// TimesheetError is returned for a status change timesheets don't allow.
+type TimesheetError struct {
+	From, To string
+}
+
+func (e *TimesheetError) Error() string {
+	return fmt.Sprintf("cannot move a timesheet from %s to %s", e.From, e.To)
+}
+
+// CheckTimesheetMove returns a *TimesheetError unless a timesheet may move
+// from one status to another.
+func CheckTimesheetMove(from, to string) error {
+	for _, allowed := range timesheetMoves[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return &TimesheetError{From: from, To: to}
+}
+
+// Apply moves a timesheet to the change's status at the given time.
+func (c TimesheetChange) Apply(sheet *Timesheet, now time.Time) {
+	sheet.Status = c.Status
+	if c.Status == TimesheetSubmitted {
+		sheet.SubmittedAt = &now
+		sheet.Comment, sheet.ReviewedBy, sheet.ReviewedAt = "", "", nil
+		return
+	}
+	sheet.Comment, sheet.ReviewedBy, sheet.ReviewedAt = c.Comment, c.Reviewer, &now
+}
+
+// WeekOf returns the WeekStart of the week t falls in, in local time.
+func WeekOf(t time.Time) string {
+	return WeekStart(t.Local()).Format(DueDateLayout)
+}
+
+// ParseWeek returns the WeekStart of the week a YYYY-MM-DD date falls in.
+func ParseWeek(date string) (string, error) {
+	day, err := time.ParseInLocation(DueDateLayout, date, time.Local)
+	if err != nil {
+		return "", fmt.Errorf("week must be a date like %s", DueDateLayout)
+	}
+	return WeekOf(day), nil
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// User is someone who signs in to the team server with an API token.
+type User struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Lead      bool      `json:"lead,omitempty"` // May approve and reject other users' timesheets
+	CreatedAt time.Time `json:"created_at"`
+}
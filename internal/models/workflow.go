@@ -0,0 +1,171 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Workflow is the list of statuses tasks move through and which moves
+// between them are allowed. New tasks start at the first status, "todo";
+// "done" has to be one of them, since blockers and due dates key on it.
+type Workflow struct {
+	Statuses []string `json:"statuses"`
+	// Transitions lists the statuses each status may move to. Statuses
+	// left out may move one step forward or back in Statuses.
+	Transitions map[string][]string `json:"transitions,omitempty"`
+	// Colors are "#RRGGBB" or ANSI color numbers for the TUI's badges.
+	Colors map[string]string `json:"colors,omitempty"`
+}
+
+// DefaultWorkflow is used when no workflow is configured.
+func DefaultWorkflow() Workflow {
+	return Workflow{
+		Statuses: []string{"todo", "in-progress", "review", "done"},
+		Colors: map[string]string{
+			"todo":        "8",
+			"in-progress": "33",
+			"review":      "214",
+			"done":        "28",
+		},
+	}
+}
+
+// ParseWorkflow reads a workflow from JSON and checks it.
+func ParseWorkflow(data []byte) (Workflow, error) {
+	var workflow Workflow
+	if err := json.Unmarshal(data, &workflow); err != nil {
+		return Workflow{}, err
+	}
+	return workflow, workflow.Validate()
+}
+
+// Validate checks that a workflow starts with "todo", contains "done" and
+// only names its own statuses.
+func (w Workflow) Validate() error {
+	if len(w.Statuses) == 0 || w.Statuses[0] != "todo" {
+		return errors.New("workflow must start with the todo status")
+	}
+	seen := make(map[string]bool, len(w.Statuses))
+	for _, status := range w.Statuses {
+		if strings.TrimSpace(status) == "" || seen[status] {
+			return fmt.Errorf("workflow status %q is empty or repeated", status)
+		}
+		seen[status] = true
+	}
+	if !seen["done"] {
+		return errors.New("workflow must contain the done status")
+	}
+
+	for from, targets := range w.Transitions {
+		if !seen[from] {
+			return fmt.Errorf("workflow transition from unknown status %q", from)
+		}
+		for _, to := range targets {
+			if !seen[to] {
+				return fmt.Errorf("workflow transition from %q to unknown status %q", from, to)
+			}
+		}
+	}
+	for status, color := range w.Colors {
+		if !seen[status] {
+			return fmt.Errorf("workflow color for unknown status %q", status)
+		}
+		if err := validateColor(color); err != nil {
+			return fmt.Errorf("workflow color for %q: %v", status, err)
+		}
+	}
+	return nil
+}
+
+// TransitionError is returned for a status change the workflow doesn't
+// allow.
+type TransitionError struct {
+	From, To string
+}
+
+func (e *TransitionError) Error() string {
+	if e.From == "" {
+		return fmt.Sprintf("%q is not a status of this workflow", e.To)
+	}
+	return fmt.Sprintf("cannot move a task from %q to %q", e.From, e.To)
+}
+
+// Check returns a *TransitionError unless a task may move from one status
+// to another. Staying put is always allowed, and so is any move from a
+// status the workflow doesn't know, so such tasks can be brought back in.
+func (w Workflow) Check(from, to string) error {
+	if from == to {
+		return nil
+	}
+	if w.index(to) < 0 {
+		return &TransitionError{To: to}
+	}
+	if w.index(from) < 0 {
+		return nil
+	}
+	for _, allowed := range w.allowed(from) {
+		if allowed == to {
+			return nil
+		}
+	}
+	return &TransitionError{From: from, To: to}
+}
+
+// Next returns the first status after from, in workflow order, that from
+// may move to. ok is false at the end of the workflow.
+func (w Workflow) Next(from string) (status string, ok bool) {
+	current := w.index(from)
+	for i := current + 1; i < len(w.Statuses); i++ {
+		if w.Check(from, w.Statuses[i]) == nil {
+			return w.Statuses[i], true
+		}
+	}
+	return "", false
+}
+
+// Previous returns the last status before from, in workflow order, that
+// from may move to. ok is false at the start of the workflow.
+func (w Workflow) Previous(from string) (status string, ok bool) {
+	current := w.index(from)
+	if current < 0 {
+		current = len(w.Statuses)
+	}
+	for i := current - 1; i >= 0; i-- {
+		if w.Check(from, w.Statuses[i]) == nil {
+			return w.Statuses[i], true
+		}
+	}
+	return "", false
+}
+
+// Knows reports whether status is one of the workflow's statuses.
+func (w Workflow) Knows(status string) bool {
+	return w.index(status) >= 0
+}
+
+func (w Workflow) allowed(from string) []string {
+	if targets, ok := w.Transitions[from]; ok {
+		return targets
+	}
+
+	i := w.index(from)
+	var targets []string
+	if i > 0 {
+		targets = append(targets, w.Statuses[i-1])
+	}
+	if i >= 0 && i < len(w.Statuses)-1 {
+		targets = append(targets, w.Statuses[i+1])
+	}
+	return targets
+}
+
+func (w Workflow) index(status string) int {
+	for i, s := range w.Statuses {
+		if s == status {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDefaultWorkflowSteps(t *testing.T) {
+	workflow := DefaultWorkflow()
+	if err := workflow.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		from, to string
+		ok       bool
+	}{
+		{"todo", "in-progress", true},
+		{"in-progress", "todo", true},
+		{"review", "done", true},
+		{"done", "done", true},
+		{"todo", "done", false},
+		{"done", "todo", false},
+		{"todo", "shipped", false},
+		{"archived", "todo", true}, // Bringing back a status the workflow dropped
+	} {
+		err := workflow.Check(test.from, test.to)
+		if test.ok != (err == nil) {
+			t.Errorf("%s to %s: got %v", test.from, test.to, err)
+		}
+		var transition *TransitionError
+		if err != nil && !errors.As(err, &transition) {
+			t.Errorf("%s to %s: got %T, want *TransitionError", test.from, test.to, err)
+		}
+	}
+
+	if next, ok := workflow.Next("todo"); !ok || next != "in-progress" {
+		t.Errorf("next after todo = %q, %v", next, ok)
+	}
+	if _, ok := workflow.Next("done"); ok {
+		t.Error("found a status after done")
+	}
+	if previous, ok := workflow.Previous("review"); !ok || previous != "in-progress" {
+		t.Errorf("previous before review = %q, %v", previous, ok)
+	}
+	if _, ok := workflow.Previous("todo"); ok {
+		t.Error("found a status before todo")
+	}
+}
+
+func TestWorkflowTransitionsOverrideSteps(t *testing.T) {
+	workflow, err := ParseWorkflow([]byte(`{
+		"statuses": ["todo", "doing", "blocked", "done"],
+		"transitions": {"todo": ["doing", "done"], "blocked": ["doing"]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := workflow.Check("todo", "done"); err != nil {
+		t.Errorf("todo to done: %v", err)
+	}
+	if err := workflow.Check("blocked", "done"); err == nil {
+		t.Error("blocked moved to done, which its transitions leave out")
+	}
+	if err := workflow.Check("doing", "blocked"); err != nil {
+		t.Errorf("doing to blocked, one step on: %v", err)
+	}
+	// Next skips statuses the transitions don't allow
+	if next, ok := workflow.Next("todo"); !ok || next != "doing" {
+		t.Errorf("next after todo = %q, %v", next, ok)
+	}
+	if next, ok := workflow.Next("blocked"); ok {
+		t.Errorf("next after blocked = %q, want none", next)
+	}
+}
+
+func TestParseWorkflowRejects(t *testing.T) {
+	for name, data := range map[string]string{
+		"no todo":       `{"statuses": ["doing", "done"]}`,
+		"no done":       `{"statuses": ["todo", "doing"]}`,
+		"repeated":      `{"statuses": ["todo", "todo", "done"]}`,
+		"empty":         `{"statuses": ["todo", " ", "done"]}`,
+		"unknown from":  `{"statuses": ["todo", "done"], "transitions": {"doing": ["done"]}}`,
+		"unknown to":    `{"statuses": ["todo", "done"], "transitions": {"todo": ["doing"]}}`,
+		"unknown color": `{"statuses": ["todo", "done"], "colors": {"doing": "33"}}`,
+		"not a color":   `{"statuses": ["todo", "done"], "colors": {"done": "green"}}`,
+		"not json":      `{"statuses": `,
+	} {
+		if _, err := ParseWorkflow([]byte(data)); err == nil {
+			t.Errorf("%s: parsed %s", name, data)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+// maxAuditEvents caps how many events one /api/v1/audit call returns.
+const maxAuditEvents = 1000
+
+// record adds an event to the audit log. before and after are the task or
+// time entry on either side of the change, nil where there is none. A failed
+// write is logged rather than failing a change that already happened.
+func (s *Server) record(r *http.Request, action, taskID string, before, after interface{}) {
+	if s.audit == nil {
+		return
+	}
+
+	event := models.AuditEvent{
+		TaskID: taskID,
+		Actor:  actor(r),
+		Action: action,
+		Before: auditSnapshot(before),
+		After:  auditSnapshot(after),
+	}
+	if err := s.audit.RecordAudit(event); err != nil {
+		log.Printf("Failed to record %s for task %s: %v", action, taskID, err)
+	}
+}
+
+// snapshot loads a task as it is before a change, for the audit log and to
+// know its old parent. It returns nil if the task can't be read; the change
+// itself reports that.
+func (s *Server) snapshot(taskID string) *models.Task {
+	task, err := s.store.GetTask(taskID)
+	if err != nil {
+		return nil
+	}
+	return task
+}
+
+func auditSnapshot(v interface{}) json.RawMessage {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case *models.Task:
+		if v == nil {
+			return nil
+		}
+	case *models.TimeEntry:
+		if v == nil {
+			return nil
+		}
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// actor names whoever made a request: the user it authenticated as, the
+// user the client says it runs as, or failing that the address it came
+// from.
+func actor(r *http.Request) string {
+	if user := requestUser(r); user != nil {
+		return user.Name
+	}
+	if user := strings.TrimSpace(r.Header.Get("X-Tasktime-User")); user != "" {
+		return user
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func (s *Server) getTaskHistory(w http.ResponseWriter, r *http.Request) {
+	if s.audit == nil {
+		http.Error(w, "audit log not supported by this store", http.StatusNotImplemented)
+		return
+	}
+
+	events, err := s.audit.GetTaskHistory(chi.URLParam(r, "id"))
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+func (s *Server) getAuditEvents(w http.ResponseWriter, r *http.Request) {
+	if s.audit == nil {
+		http.Error(w, "audit log not supported by this store", http.StatusNotImplemented)
+		return
+	}
+
+	var since time.Time
+	if value := r.URL.Query().Get("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			http.Error(w, "since must be an RFC 3339 time", 400)
+			return
+		}
+		since = parsed
+	}
+
+	limit := maxAuditEvents
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "limit must be a positive number", 400)
+			return
+		}
+		if parsed < limit {
+			limit = parsed
+		}
+	}
+
+	events, err := s.audit.GetAuditEvents(since, limit)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
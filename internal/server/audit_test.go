@@ -0,0 +1,47 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+func TestEntryChangesKeepTheOldEntry(t *testing.T) {
+	api := newTestServer(t)
+	task := api.createTask("Audited")
+
+	var added models.TimeEntry
+	api.call("POST", "/api/v1/tasks/"+task.ID+"/time_entries", entryRequest(t, "2026-09-09T09:00:00Z", "2026-09-09T11:00:00Z")).expect(t, 200, &added)
+	api.call("PUT", "/api/v1/time_entries/"+added.ID, entryRequest(t, "2026-09-09T10:00:00Z", "2026-09-09T11:00:00Z")).expect(t, 200, nil)
+	api.call("PUT", "/api/v1/time_entries/"+added.ID+"/billable", models.BillableRequest{Billable: false}).expect(t, 200, nil)
+
+	var history []models.AuditEvent
+	api.call("GET", "/api/v1/tasks/"+task.ID+"/history", nil).expect(t, 200, &history)
+	var updates []models.AuditEvent
+	for _, event := range history {
+		if event.Action == "time_entry.updated" {
+			updates = append(updates, event)
+		}
+	}
+	if len(updates) != 2 {
+		t.Fatalf("history has %d entry updates, want 2: %+v", len(updates), history)
+	}
+
+	entry := func(data json.RawMessage) models.TimeEntry {
+		t.Helper()
+		var entry models.TimeEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			t.Fatalf("decoding %s: %v", data, err)
+		}
+		return entry
+	}
+	moved, moving := entry(updates[0].Before), entry(updates[0].After)
+	if moved.DurationSeconds != 7200 || moving.DurationSeconds != 3600 {
+		t.Fatalf("moving the start recorded %+v to %+v", moved, moving)
+	}
+	billable, nonBillable := entry(updates[1].Before), entry(updates[1].After)
+	if billable.NonBillable || !nonBillable.NonBillable {
+		t.Fatalf("marking non-billable recorded %+v to %+v", billable, nonBillable)
+	}
+}
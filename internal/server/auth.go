@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+	"github.com/ifrunruhin12/tasktime/internal/storage"
+)
+
+type contextKey int
+
+// userKey holds the *models.User a request authenticated as.
+const userKey contextKey = iota
+
+// RequireAuth makes every request, WebSocket included, carry the API token
+// of a user in the store as "Authorization: Bearer <token>". It fails if
+// the store keeps no users.
+func (s *Server) RequireAuth() error {
+	if s.users == nil {
+		return errors.New("this store has no users to authenticate")
+	}
+	s.requireAuth = true
+	return nil
+}
+
+// authenticate rejects requests without a valid API token with 401. The
+// WebSocket endpoint and the calendar feed also take the token as
+// ?access_token=, for clients that can't set headers on the upgrade request
+// and calendar apps that subscribe to a URL.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" && (r.URL.Path == "/api/v1/ws" || r.URL.Path == "/api/v1/calendar.ics") {
+			token = r.URL.Query().Get("access_token")
+		}
+		if token == "" {
+			unauthorized(w, "missing API token")
+			return
+		}
+
+		user, err := s.users.AuthenticateToken(token)
+		if errors.Is(err, storage.ErrInvalidToken) {
+			unauthorized(w, err.Error())
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey, user)))
+	})
+}
+
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="tasktime"`)
+	http.Error(w, message, http.StatusUnauthorized)
+}
+
+// requestUser returns the user a request authenticated as, or nil when the
+// server doesn't require authentication.
+func requestUser(r *http.Request) *models.User {
+	user, _ := r.Context().Value(userKey).(*models.User)
+	return user
+}
+
+// getMe tells a client who it is: its user, or without authentication a
+// user with just the name changes are recorded under.
+func (s *Server) getMe(w http.ResponseWriter, r *http.Request) {
+	user := requestUser(r)
+	if user == nil {
+		user = &models.User{Name: actor(r)}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
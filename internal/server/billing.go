@@ -0,0 +1,152 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ifrunruhin12/tasktime/internal/models"
+	"github.com/ifrunruhin12/tasktime/internal/storage"
+)
+
+// setEntryBillable marks a time entry billable or not. Only leads and the
+// entry's own user may.
+func (s *Server) setEntryBillable(w http.ResponseWriter, r *http.Request) {
+	if !s.checkBilling(w) {
+		return
+	}
+
+	before, ok := s.entryToChange(w, r, chi.URLParam(r, "id"), "change whether this entry is billable")
+	if !ok {
+		return
+	}
+
+	var req models.BillableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	entry, err := s.billing.SetEntryBillable(before.ID, req.Billable)
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+
+	s.record(r, "time_entry.updated", entry.TaskID, before, entry)
+
+	s.broadcastTaskUpdated(entry.TaskID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
+
+func (s *Server) getRates(w http.ResponseWriter, r *http.Request) {
+	if !s.checkBilling(w) {
+		return
+	}
+
+	rates, err := s.billing.GetRates()
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rates)
+}
+
+// setRate adds or changes the rate for the project and user in the body.
+// Only leads may.
+func (s *Server) setRate(w http.ResponseWriter, r *http.Request) {
+	if !s.checkBilling(w) || !s.checkLead(w, r, "set rates") {
+		return
+	}
+
+	var rate models.Rate
+	if err := json.NewDecoder(r.Body).Decode(&rate); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	if err := rate.Validate(); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	saved, err := s.billing.SetRate(rate)
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
+
+// deleteRate removes the rate for ?project= and ?user=, either of which
+// may be left out. Only leads may.
+func (s *Server) deleteRate(w http.ResponseWriter, r *http.Request) {
+	if !s.checkBilling(w) || !s.checkLead(w, r, "delete rates") {
+		return
+	}
+
+	query := r.URL.Query()
+	rate := models.Rate{ProjectID: query.Get("project_id"), Project: query.Get("project"), User: query.Get("user")}
+	if err := s.billing.DeleteRate(rate); err != nil {
+		storeError(w, err)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+// getInvoice totals a client's approved billable time for a period (see
+// storage.BuildInvoice), as JSON or with ?format= as Markdown, HTML or CSV.
+// Only leads may.
+func (s *Server) getInvoice(w http.ResponseWriter, r *http.Request) {
+	if !s.checkBilling(w) || !s.checkLead(w, r, "make invoices") {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if err := models.CheckInvoiceFormat(format); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	query, err := models.ParseInvoiceQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	invoice, err := storage.BuildInvoice(s.store, query)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", models.InvoiceContentType(format))
+	if format != "json" {
+		w.Header().Set("Content-Disposition", `attachment; filename="invoice-`+time.Now().Format("2006-01-02")+`.`+invoiceExtension(format)+`"`)
+	}
+	models.WriteInvoice(w, format, invoice)
+}
+
+func invoiceExtension(format string) string {
+	if format == "markdown" {
+		return "md"
+	}
+	return format
+}
+
+func (s *Server) checkBilling(w http.ResponseWriter) bool {
+	if s.billing == nil || s.timesheets == nil || s.projects == nil {
+		http.Error(w, "billing not supported by this store", http.StatusNotImplemented)
+		return false
+	}
+	return true
+}
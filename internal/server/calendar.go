@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+	"github.com/ifrunruhin12/tasktime/internal/storage"
+)
+
+// getCalendar serves an iCalendar feed of one user's time entries and the
+// due dates of the tasks they worked on or run a timer on. The user is
+// ?user=, or else the one the request authenticated as; without either the
+// feed covers the whole team.
+func (s *Server) getCalendar(w http.ResponseWriter, r *http.Request) {
+	user := strings.TrimSpace(r.URL.Query().Get("user"))
+	if user == "" {
+		if authenticated := requestUser(r); authenticated != nil {
+			user = authenticated.Name
+		}
+	}
+
+	export, err := storage.Export(s.store)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	name := "TaskTime"
+	tasks := export.Tasks
+	if user != "" {
+		name += " - " + user
+		tasks = userCalendar(export.Tasks, user)
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	models.WriteCalendar(w, name, tasks)
+}
+
+// userCalendar keeps the tasks a user has time entries or a timer on, with
+// only that user's entries.
+func userCalendar(tasks []models.ExportedTask, user string) []models.ExportedTask {
+	var mine []models.ExportedTask
+	for _, task := range tasks {
+		entries := []models.TimeEntry{}
+		for _, entry := range task.TimeEntries {
+			if strings.EqualFold(entry.User, user) {
+				entries = append(entries, entry)
+			}
+		}
+
+		timing := false
+		for _, timer := range task.Timers {
+			timing = timing || strings.EqualFold(timer.User, user)
+		}
+
+		if len(entries) > 0 || timing {
+			task.TimeEntries = entries
+			mine = append(mine, task)
+		}
+	}
+	return mine
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+// getDependencies lists the tasks a task is blocked by, done or not.
+func (s *Server) getDependencies(w http.ResponseWriter, r *http.Request) {
+	task, err := s.store.GetTask(chi.URLParam(r, "id"))
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+
+	blockers := []models.Task{}
+	for _, id := range task.BlockedBy {
+		// Blockers in the trash don't count
+		if blocker, err := s.store.GetTask(id); err == nil {
+			blockers = append(blockers, *blocker)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(blockers)
+}
+
+func (s *Server) addDependency(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "id")
+
+	var req models.DependencyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	if req.BlockedBy == "" {
+		http.Error(w, "blocked_by is required", 400)
+		return
+	}
+
+	before := s.snapshot(taskID)
+	task, err := s.store.AddDependency(taskID, req.BlockedBy)
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+
+	// Adding a link that already exists changes nothing to tell anyone
+	if before == nil || task.Version != before.Version {
+		s.record(r, "dependency.added", taskID, before, task)
+
+		s.broadcast(models.WSMessage{
+			Type:    "task.updated",
+			Payload: task,
+		})
+	}
+
+	writeTask(w, task)
+}
+
+func (s *Server) removeDependency(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "id")
+
+	before := s.snapshot(taskID)
+	task, err := s.store.RemoveDependency(taskID, chi.URLParam(r, "blockerID"))
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+
+	s.record(r, "dependency.removed", taskID, before, task)
+
+	s.broadcast(models.WSMessage{
+		Type:    "task.updated",
+		Payload: task,
+	})
+
+	writeTask(w, task)
+}
+
+// checkBlockers answers 423 Locked, listing the open blockers, if the task
+// has any and the request isn't forced with ?force=true. It reports whether
+// the change may go ahead.
+func (s *Server) checkBlockers(w http.ResponseWriter, r *http.Request, taskID string) bool {
+	if force, _ := strconv.ParseBool(r.URL.Query().Get("force")); force {
+		return true
+	}
+
+	blockers, err := s.store.GetOpenBlockers(taskID)
+	if err != nil {
+		storeError(w, err)
+		return false
+	}
+	if len(blockers) == 0 {
+		return true
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusLocked)
+	json.NewEncoder(w).Encode(models.BlockedResponse{
+		Error:     "task is blocked by open tasks; repeat with ?force=true to go ahead anyway",
+		BlockedBy: blockers,
+	})
+	return false
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+func TestDependencyCycle(t *testing.T) {
+	api := newTestServer(t)
+	design, build, ship := api.createTask("Design"), api.createTask("Build"), api.createTask("Ship")
+
+	var blocked models.Task
+	api.call("POST", "/api/v1/tasks/"+build.ID+"/dependencies", models.DependencyRequest{BlockedBy: design.ID}).expect(t, 200, &blocked)
+	if len(blocked.BlockedBy) != 1 || blocked.BlockedBy[0] != design.ID {
+		t.Fatalf("build is blocked by %v", blocked.BlockedBy)
+	}
+	api.call("POST", "/api/v1/tasks/"+ship.ID+"/dependencies", models.DependencyRequest{BlockedBy: build.ID}).expect(t, 200, nil)
+
+	var again models.Task
+	api.call("POST", "/api/v1/tasks/"+build.ID+"/dependencies", models.DependencyRequest{BlockedBy: design.ID}).expect(t, 200, &again)
+	if again.Version != blocked.Version || len(again.BlockedBy) != 1 {
+		t.Fatalf("adding the link again made %+v from %+v", again, blocked)
+	}
+
+	api.call("POST", "/api/v1/tasks/"+design.ID+"/dependencies", models.DependencyRequest{BlockedBy: ship.ID}).expect(t, 400, nil)
+	api.call("POST", "/api/v1/tasks/"+design.ID+"/dependencies", models.DependencyRequest{BlockedBy: design.ID}).expect(t, 400, nil)
+	api.call("POST", "/api/v1/tasks/"+design.ID+"/dependencies", models.DependencyRequest{}).expect(t, 400, nil)
+}
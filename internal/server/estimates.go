@@ -0,0 +1,116 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+	"github.com/ifrunruhin12/tasktime/internal/storage"
+)
+
+// estimateCheckInterval is the longest WatchEstimates waits between checks,
+// which catches time added by other servers sharing the store.
+const estimateCheckInterval = time.Minute
+
+// getEstimateReport compares the estimates of done tasks with the time they
+// took, per ?group_by=project or user.
+func (s *Server) getEstimateReport(w http.ResponseWriter, r *http.Request) {
+	group, err := models.ParseEstimateGroup(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	report, err := storage.EstimateReport(s.store, group)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// WatchEstimates sends a task.over_estimate message the moment running
+// timers take a task's time, its subtasks' included, past its estimate. It
+// wakes up when the next task is due to cross, or when timers, estimates or
+// time entries change. It blocks, so run it in its own goroutine.
+func (s *Server) WatchEstimates() {
+	announced := make(map[string]bool)
+	wait := s.checkEstimates(announced, false)
+	for {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-s.estimatesChanged:
+			timer.Stop()
+		}
+		wait = s.checkEstimates(announced, true)
+	}
+}
+
+// recheckEstimates wakes WatchEstimates after a change that may move a
+// task's crossing.
+func (s *Server) recheckEstimates() {
+	select {
+	case s.estimatesChanged <- struct{}{}:
+	default:
+	}
+}
+
+// checkEstimates announces the tasks running timers took past their
+// estimate since the last check, unless announce is false, and returns how
+// long until the next one is due to cross. Only tasks with a timer running
+// on them or their subtasks are looked at. Tasks already over without
+// their running timers, and tasks that were announced and are still over,
+// aren't announced again.
+func (s *Server) checkEstimates(announced map[string]bool, announce bool) time.Duration {
+	tasks, err := storage.RunningEstimates(s.store)
+	if err != nil {
+		log.Printf("Failed to check estimates: %v", err)
+		return estimateCheckInterval
+	}
+
+	now := time.Now()
+	wait := estimateCheckInterval
+	running := make(map[string]bool, len(tasks))
+	for _, estimated := range tasks {
+		task := estimated.Task
+		running[task.ID] = true
+
+		// tracked is the time recorded so far, live adds the running timers
+		tracked, live := task.RollupTimeSeconds, estimated.LiveSeconds(now)
+		if live <= task.EstimateSeconds {
+			delete(announced, task.ID)
+			due := time.Duration(task.EstimateSeconds-live)*time.Second/time.Duration(len(estimated.Timers)) + time.Second
+			if due < wait {
+				wait = due
+			}
+			continue
+		}
+		if announced[task.ID] {
+			continue
+		}
+
+		announced[task.ID] = true
+		if !announce || tracked > task.EstimateSeconds {
+			continue
+		}
+		log.Printf("Task %s went over its estimate of %ds", task.ID, task.EstimateSeconds)
+		s.broadcast(models.WSMessage{
+			Type:    "task.over_estimate",
+			Payload: models.OverEstimate{Task: task, ElapsedSeconds: live},
+		})
+	}
+	// Tasks whose timers stopped are forgotten; should one start again
+	// while the task is still over, its tracked time keeps it quiet
+	for id := range announced {
+		if !running[id] {
+			delete(announced, id)
+		}
+	}
+
+	return wait
+}
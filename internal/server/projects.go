@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+// getProjects lists projects by name. Archived ones are left out unless
+// ?archived=true is given.
+func (s *Server) getProjects(w http.ResponseWriter, r *http.Request) {
+	if s.projects == nil {
+		http.Error(w, "projects not supported by this store", http.StatusNotImplemented)
+		return
+	}
+
+	projects, err := s.projects.GetProjects(r.URL.Query().Get("archived") == "true")
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(projects)
+}
+
+func (s *Server) getProject(w http.ResponseWriter, r *http.Request) {
+	if s.projects == nil {
+		http.Error(w, "projects not supported by this store", http.StatusNotImplemented)
+		return
+	}
+
+	project, err := s.projects.GetProject(chi.URLParam(r, "id"))
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(project)
+}
+
+func (s *Server) createProject(w http.ResponseWriter, r *http.Request) {
+	if s.projects == nil {
+		http.Error(w, "projects not supported by this store", http.StatusNotImplemented)
+		return
+	}
+
+	var req models.CreateProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	project, err := s.projects.CreateProject(req)
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+
+	s.broadcast(models.WSMessage{
+		Type:    "project.created",
+		Payload: project,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(project)
+}
+
+// updateProject changes a project. A rename also renames it on its tasks,
+// so clients should reload those on project.updated.
+func (s *Server) updateProject(w http.ResponseWriter, r *http.Request) {
+	if s.projects == nil {
+		http.Error(w, "projects not supported by this store", http.StatusNotImplemented)
+		return
+	}
+
+	var req models.UpdateProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	project, err := s.projects.UpdateProject(chi.URLParam(r, "id"), req)
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+
+	s.broadcast(models.WSMessage{
+		Type:    "project.updated",
+		Payload: project,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(project)
+}
+
+// deleteProject removes a project. Its tasks keep the name as free text.
+func (s *Server) deleteProject(w http.ResponseWriter, r *http.Request) {
+	if s.projects == nil {
+		http.Error(w, "projects not supported by this store", http.StatusNotImplemented)
+		return
+	}
+
+	projectID := chi.URLParam(r, "id")
+	if err := s.projects.DeleteProject(projectID); err != nil {
+		storeError(w, err)
+		return
+	}
+
+	s.broadcast(models.WSMessage{
+		Type:    "project.deleted",
+		Payload: map[string]string{"id": projectID},
+	})
+
+	w.WriteHeader(204)
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+// getTimeReport adds up recorded time per project, user, day, week or
+// month (see models.ParseReportQuery).
+func (s *Server) getTimeReport(w http.ResponseWriter, r *http.Request) {
+	if s.reports == nil {
+		http.Error(w, "reports not supported by this store", http.StatusNotImplemented)
+		return
+	}
+
+	query, err := models.ParseReportQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	report, err := s.reports.TimeReport(query)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Search results per /api/v1/search call: how many without a limit, and
+// the most a limit can ask for.
+const (
+	defaultSearchResults = 50
+	maxSearchResults     = 200
+)
+
+// searchTasks answers ?q= with the best matching tasks, highlighted.
+func (s *Server) searchTasks(w http.ResponseWriter, r *http.Request) {
+	if s.search == nil {
+		http.Error(w, "search not supported by this store", http.StatusNotImplemented)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(w, "q cannot be empty", 400)
+		return
+	}
+
+	limit := defaultSearchResults
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "limit must be a positive number", 400)
+			return
+		}
+		limit = parsed
+		if limit > maxSearchResults {
+			limit = maxSearchResults
+		}
+	}
+
+	results, err := s.search.SearchTasks(query, limit)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
@@ -0,0 +1,331 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+	"github.com/ifrunruhin12/tasktime/internal/storage"
+)
+
+// apiClient sends requests to a test server, as a user when token is set.
+type apiClient struct {
+	t     *testing.T
+	url   string
+	token string
+}
+
+// response is a finished response with its body read.
+type response struct {
+	*http.Response
+	body []byte
+}
+
+// newTestServer serves the API from a fresh memory store.
+func newTestServer(t *testing.T) apiClient {
+	ts := httptest.NewServer(New(storage.NewMemoryStore()).Handler())
+	t.Cleanup(ts.Close)
+	return apiClient{t: t, url: ts.URL}
+}
+
+// newAuthServer serves the API from a fresh memory store that requires API
+// tokens. The function it returns adds a user and gives a client acting as
+// them.
+func newAuthServer(t *testing.T) func(name string, lead bool) apiClient {
+	store := storage.NewMemoryStore()
+	srv := New(store)
+	if err := srv.RequireAuth(); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+
+	return func(name string, lead bool) apiClient {
+		if _, err := store.CreateUser(name); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.SetLead(name, lead); err != nil {
+			t.Fatal(err)
+		}
+		token, err := store.CreateToken(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return apiClient{t: t, url: ts.URL, token: token}
+	}
+}
+
+// call sends body as JSON, or as is when it is a string, with header
+// holding pairs of header names and values.
+func (c apiClient) call(method, path string, body interface{}, header ...string) response {
+	c.t.Helper()
+	var reader io.Reader
+	switch body := body.(type) {
+	case nil:
+	case string:
+		reader = strings.NewReader(body)
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.url+path, reader)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return response{resp, data}
+}
+
+// expect fails the test unless the response has the given status, and
+// decodes its body into out if out isn't nil.
+func (r response) expect(t *testing.T, status int, out interface{}) response {
+	t.Helper()
+	if r.StatusCode != status {
+		t.Fatalf("%s %s: got %d, want %d: %s", r.Request.Method, r.Request.URL.Path, r.StatusCode, status, r.body)
+	}
+	if out != nil {
+		if err := json.Unmarshal(r.body, out); err != nil {
+			t.Fatalf("%s %s: decoding %s: %v", r.Request.Method, r.Request.URL.Path, r.body, err)
+		}
+	}
+	return r
+}
+
+func (c apiClient) createTask(title string) models.Task {
+	c.t.Helper()
+	var task models.Task
+	c.call("POST", "/api/v1/tasks", models.CreateTaskRequest{Title: title}).expect(c.t, 200, &task)
+	return task
+}
+
+// parseTime reads an RFC 3339 time.
+func parseTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+// entryRequest is a time entry from start to end, given as RFC 3339.
+func entryRequest(t *testing.T, start, end string) models.TimeEntryRequest {
+	t.Helper()
+	return models.TimeEntryRequest{StartTime: parseTime(t, start), EndTime: parseTime(t, end)}
+}
+
+func TestTaskCRUD(t *testing.T) {
+	api := newTestServer(t)
+
+	api.call("POST", "/api/v1/tasks", models.CreateTaskRequest{Title: "  "}).expect(t, 400, nil)
+
+	created := api.createTask("Write tests")
+	if created.ID == "" || created.Status != "todo" || created.Version != 1 {
+		t.Fatalf("created task = %+v", created)
+	}
+
+	var got models.Task
+	resp := api.call("GET", "/api/v1/tasks/"+created.ID, nil).expect(t, 200, &got)
+	if got.Title != "Write tests" || resp.Header.Get("ETag") != `"1"` {
+		t.Fatalf("got %+v with ETag %s", got, resp.Header.Get("ETag"))
+	}
+
+	title := "Write more tests"
+	var updated models.Task
+	api.call("PATCH", "/api/v1/tasks/"+created.ID, models.UpdateTaskRequest{Title: &title}).expect(t, 200, &updated)
+	if updated.Title != title || updated.Version != 2 {
+		t.Fatalf("updated task = %+v", updated)
+	}
+
+	var tasks []models.Task
+	api.call("GET", "/api/v1/tasks", nil).expect(t, 200, &tasks)
+	if len(tasks) != 1 || tasks[0].Title != title {
+		t.Fatalf("tasks = %+v", tasks)
+	}
+
+	api.call("DELETE", "/api/v1/tasks/"+created.ID, nil).expect(t, 204, nil)
+	api.call("GET", "/api/v1/tasks/"+created.ID, nil).expect(t, 404, nil)
+	api.call("PATCH", "/api/v1/tasks/missing", models.UpdateTaskRequest{Title: &title}).expect(t, 404, nil)
+}
+
+func TestUpdateVersionConflict(t *testing.T) {
+	api := newTestServer(t)
+	task := api.createTask("Shared task")
+
+	mine, theirs := "Mine", "Theirs"
+	api.call("PATCH", "/api/v1/tasks/"+task.ID, models.UpdateTaskRequest{Title: &theirs}, "If-Match", `"1"`).expect(t, 200, nil)
+
+	var current models.Task
+	resp := api.call("PATCH", "/api/v1/tasks/"+task.ID, models.UpdateTaskRequest{Title: &mine}, "If-Match", `"1"`).expect(t, 409, &current)
+	if current.Title != theirs || current.Version != 2 || resp.Header.Get("ETag") != `"2"` {
+		t.Fatalf("conflict sent %+v with ETag %s", current, resp.Header.Get("ETag"))
+	}
+
+	api.call("PATCH", "/api/v1/tasks/"+task.ID, models.UpdateTaskRequest{Title: &mine}, "If-Match", "latest").expect(t, 400, nil)
+	api.call("DELETE", "/api/v1/tasks/"+task.ID, nil, "If-Match", `"1"`).expect(t, 409, nil)
+	api.call("PATCH", "/api/v1/tasks/"+task.ID, models.UpdateTaskRequest{Title: &mine}, "If-Match", `"2"`).expect(t, 200, nil)
+}
+
+func TestTrashAndRestore(t *testing.T) {
+	api := newTestServer(t)
+	task := api.createTask("Old idea")
+
+	api.call("DELETE", "/api/v1/tasks/"+task.ID, nil).expect(t, 204, nil)
+
+	var tasks, trash []models.Task
+	api.call("GET", "/api/v1/tasks", nil).expect(t, 200, &tasks)
+	api.call("GET", "/api/v1/trash", nil).expect(t, 200, &trash)
+	if len(tasks) != 0 || len(trash) != 1 || trash[0].ID != task.ID || trash[0].DeletedAt == nil {
+		t.Fatalf("after deleting: tasks %+v, trash %+v", tasks, trash)
+	}
+
+	var restored models.Task
+	api.call("POST", "/api/v1/tasks/"+task.ID+"/restore", nil).expect(t, 200, &restored)
+	if restored.DeletedAt != nil || restored.Title != "Old idea" {
+		t.Fatalf("restored task = %+v", restored)
+	}
+
+	api.call("GET", "/api/v1/tasks", nil).expect(t, 200, &tasks)
+	api.call("GET", "/api/v1/trash", nil).expect(t, 200, &trash)
+	if len(tasks) != 1 || len(trash) != 0 {
+		t.Fatalf("after restoring: tasks %+v, trash %+v", tasks, trash)
+	}
+	api.call("POST", "/api/v1/tasks/missing/restore", nil).expect(t, 404, nil)
+}
+
+func TestWorkflowTransitions(t *testing.T) {
+	api := newTestServer(t)
+	task := api.createTask("Review me")
+
+	api.call("PUT", "/api/v1/tasks/"+task.ID+"/status", models.UpdateStatusRequest{Status: "done"}).expect(t, 422, nil)
+	api.call("PUT", "/api/v1/tasks/"+task.ID+"/status", models.UpdateStatusRequest{Status: "shipped"}).expect(t, 422, nil)
+	done := "done"
+	api.call("PATCH", "/api/v1/tasks/"+task.ID, models.UpdateTaskRequest{Status: &done}).expect(t, 422, nil)
+
+	var moved models.Task
+	api.call("PUT", "/api/v1/tasks/"+task.ID+"/status", models.UpdateStatusRequest{Status: "in-progress"}).expect(t, 200, &moved)
+	if moved.Status != "in-progress" {
+		t.Fatalf("status = %q", moved.Status)
+	}
+}
+
+func TestCursorPaging(t *testing.T) {
+	api := newTestServer(t)
+	for _, title := range []string{"one", "two", "three", "four", "five"} {
+		api.createTask(title)
+	}
+
+	var all []models.Task
+	api.call("GET", "/api/v1/tasks", nil).expect(t, 200, &all)
+
+	var paged []models.Task
+	cursor, pages := "", 0
+	for {
+		var page []models.Task
+		resp := api.call("GET", "/api/v1/tasks?limit=2&cursor="+cursor, nil).expect(t, 200, &page)
+		paged = append(paged, page...)
+		pages++
+		if cursor = resp.Header.Get("X-Next-Cursor"); cursor == "" {
+			break
+		}
+		if pages > len(all) {
+			t.Fatal("paging doesn't end")
+		}
+	}
+	if pages != 3 || len(paged) != len(all) {
+		t.Fatalf("%d pages with %d tasks, want 3 with %d", pages, len(paged), len(all))
+	}
+	for i := range all {
+		if paged[i].ID != all[i].ID {
+			t.Fatalf("task %d of the pages is %q, want %q", i, paged[i].Title, all[i].Title)
+		}
+	}
+
+	var first []models.Task
+	next := api.call("GET", "/api/v1/tasks?limit=2&sort=title", nil).expect(t, 200, &first).Header.Get("X-Next-Cursor")
+	if len(first) != 2 || first[0].Title != "five" || first[1].Title != "four" {
+		t.Fatalf("first page by title = %+v", first)
+	}
+	api.call("GET", "/api/v1/tasks?limit=2&cursor="+next, nil).expect(t, 400, nil)
+	api.call("GET", "/api/v1/tasks?limit=2&cursor=nonsense", nil).expect(t, 400, nil)
+}
+
+func TestOnlyOwnersAndLeadsChangeEntries(t *testing.T) {
+	user := newAuthServer(t)
+	alice, bob, lee := user("alice", false), user("bob", false), user("lee", true)
+
+	task := alice.createTask("Pairing")
+	var added models.TimeEntry
+	alice.call("POST", "/api/v1/tasks/"+task.ID+"/time_entries", entryRequest(t, "2026-09-09T09:00:00Z", "2026-09-09T11:00:00Z")).expect(t, 200, &added)
+	if added.User != "alice" {
+		t.Fatalf("entry added by alice belongs to %q", added.User)
+	}
+
+	later := entryRequest(t, "2026-09-09T10:00:00Z", "2026-09-09T11:00:00Z")
+	bob.call("PUT", "/api/v1/time_entries/"+added.ID, later).expect(t, 403, nil)
+	bob.call("DELETE", "/api/v1/time_entries/"+added.ID, nil).expect(t, 403, nil)
+	bob.call("PUT", "/api/v1/time_entries/"+added.ID+"/billable", models.BillableRequest{}).expect(t, 403, nil)
+	bob.call("PUT", "/api/v1/time_entries/missing", later).expect(t, 404, nil)
+
+	var changed models.TimeEntry
+	alice.call("PUT", "/api/v1/time_entries/"+added.ID, later).expect(t, 200, &changed)
+	if changed.DurationSeconds != 3600 {
+		t.Fatalf("changed entry = %+v", changed)
+	}
+	lee.call("DELETE", "/api/v1/time_entries/"+added.ID, nil).expect(t, 204, nil)
+}
+
+func TestSplitKeepsTheEntrysUser(t *testing.T) {
+	user := newAuthServer(t)
+	alice, bob, lee := user("alice", false), user("bob", false), user("lee", true)
+
+	task := alice.createTask("Long meeting")
+	var added models.TimeEntry
+	alice.call("POST", "/api/v1/tasks/"+task.ID+"/time_entries", entryRequest(t, "2026-09-09T09:00:00Z", "2026-09-09T12:00:00Z")).expect(t, 200, &added)
+	alice.call("PUT", "/api/v1/time_entries/"+added.ID+"/billable", models.BillableRequest{Billable: false}).expect(t, 200, nil)
+
+	at := models.SplitTimeEntryRequest{At: parseTime(t, "2026-09-09T10:00:00Z")}
+	bob.call("POST", "/api/v1/time_entries/"+added.ID+"/split", at).expect(t, 403, nil)
+	outside := models.SplitTimeEntryRequest{At: added.StartTime}
+	lee.call("POST", "/api/v1/time_entries/"+added.ID+"/split", outside).expect(t, 400, nil)
+
+	var parts []models.TimeEntry
+	lee.call("POST", "/api/v1/time_entries/"+added.ID+"/split", at).expect(t, 200, &parts)
+	if len(parts) != 2 || parts[0].ID != added.ID || parts[0].DurationSeconds != 3600 || parts[1].DurationSeconds != 7200 {
+		t.Fatalf("split into %+v", parts)
+	}
+	if parts[1].User != "alice" || !parts[1].NonBillable {
+		t.Fatalf("second part belongs to %q, non-billable %v", parts[1].User, parts[1].NonBillable)
+	}
+
+	var got models.Task
+	alice.call("GET", "/api/v1/tasks/"+task.ID, nil).expect(t, 200, &got)
+	if got.TotalTimeSeconds != 3*3600 {
+		t.Fatalf("total after splitting = %d", got.TotalTimeSeconds)
+	}
+}
@@ -0,0 +1,236 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ifrunruhin12/tasktime/internal/models"
+	"github.com/ifrunruhin12/tasktime/internal/storage"
+)
+
+// getTimesheets lists submitted timesheets with their totals, filtered by
+// ?user= and ?status=.
+func (s *Server) getTimesheets(w http.ResponseWriter, r *http.Request) {
+	if !s.checkTimesheets(w) {
+		return
+	}
+
+	sheets, err := s.timesheets.GetTimesheets(r.URL.Query().Get("user"), r.URL.Query().Get("status"))
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+	if err := storage.FillTimesheets(s.store, sheets, false); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sheets)
+}
+
+// getTimesheet sends a user's timesheet for the week a date falls in, with
+// its time entries.
+func (s *Server) getTimesheet(w http.ResponseWriter, r *http.Request) {
+	sheet, ok := s.loadTimesheet(w, r)
+	if !ok {
+		return
+	}
+	writeTimesheet(w, sheet)
+}
+
+// submitTimesheet sends a week off for approval. Only its user may.
+func (s *Server) submitTimesheet(w http.ResponseWriter, r *http.Request) {
+	sheet, ok := s.loadTimesheet(w, r)
+	if !ok {
+		return
+	}
+	if !strings.EqualFold(actor(r), sheet.User) {
+		http.Error(w, "only "+sheet.User+" can submit their timesheet", http.StatusForbidden)
+		return
+	}
+
+	s.changeTimesheet(w, r, sheet, models.TimesheetChange{Status: models.TimesheetSubmitted})
+}
+
+// approveTimesheet signs off a submitted week, locking its time entries.
+func (s *Server) approveTimesheet(w http.ResponseWriter, r *http.Request) {
+	s.reviewTimesheet(w, r, models.TimesheetApproved)
+}
+
+// rejectTimesheet sends a week back to its user with a comment saying why.
+func (s *Server) rejectTimesheet(w http.ResponseWriter, r *http.Request) {
+	s.reviewTimesheet(w, r, models.TimesheetRejected)
+}
+
+// reviewTimesheet approves or rejects a timesheet. Only leads may review,
+// and not their own weeks.
+func (s *Server) reviewTimesheet(w http.ResponseWriter, r *http.Request, status string) {
+	var req models.ReviewRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+	}
+	req.Comment = strings.TrimSpace(req.Comment)
+	if status == models.TimesheetRejected && req.Comment == "" {
+		http.Error(w, "a comment saying why is required", 400)
+		return
+	}
+
+	sheet, ok := s.loadTimesheet(w, r)
+	if !ok {
+		return
+	}
+	reviewer := actor(r)
+	if !s.checkLead(w, r, "review timesheets") {
+		return
+	}
+	if strings.EqualFold(reviewer, sheet.User) {
+		http.Error(w, "leads cannot review their own timesheets", http.StatusForbidden)
+		return
+	}
+
+	s.changeTimesheet(w, r, sheet, models.TimesheetChange{Status: status, Reviewer: reviewer, Comment: req.Comment})
+}
+
+// changeTimesheet moves a timesheet loaded by loadTimesheet and tells
+// everyone. A week can't be submitted or approved while its user still has
+// a timer running that started in it, as stopping it adds to the week.
+func (s *Server) changeTimesheet(w http.ResponseWriter, r *http.Request, sheet *models.Timesheet, change models.TimesheetChange) {
+	change.User, change.Week = sheet.User, sheet.WeekStart
+
+	if change.Status != models.TimesheetRejected {
+		running, err := s.timerRunningIn(sheet.User, sheet.WeekStart)
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		if running {
+			http.Error(w, sheet.User+" still has a timer running in this week", http.StatusConflict)
+			return
+		}
+	}
+
+	changed, err := s.timesheets.ChangeTimesheet(change)
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+	sheets := []models.Timesheet{*changed}
+	if err := storage.FillTimesheets(s.store, sheets, true); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	changed = &sheets[0]
+
+	log.Printf("Timesheet of %s for the week of %s %s by %s", changed.User, changed.WeekStart, changed.Status, actor(r))
+
+	s.broadcast(models.WSMessage{
+		Type:    "timesheet." + changed.Status,
+		Payload: changed,
+	})
+
+	writeTimesheet(w, changed)
+}
+
+// loadTimesheet loads the timesheet the {user} and {week} of a request
+// name, with its entries, answering the request itself if it can't.
+func (s *Server) loadTimesheet(w http.ResponseWriter, r *http.Request) (*models.Timesheet, bool) {
+	if !s.checkTimesheets(w) {
+		return nil, false
+	}
+
+	week, err := models.ParseWeek(chi.URLParam(r, "week"))
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return nil, false
+	}
+
+	sheet, err := s.timesheets.GetTimesheet(chi.URLParam(r, "user"), week)
+	if err != nil {
+		storeError(w, err)
+		return nil, false
+	}
+	sheets := []models.Timesheet{*sheet}
+	if err := storage.FillTimesheets(s.store, sheets, true); err != nil {
+		http.Error(w, err.Error(), 500)
+		return nil, false
+	}
+	return &sheets[0], true
+}
+
+func (s *Server) checkTimesheets(w http.ResponseWriter) bool {
+	if s.timesheets == nil {
+		http.Error(w, "timesheets not supported by this store", http.StatusNotImplemented)
+		return false
+	}
+	return true
+}
+
+// isLead reports whether the caller may do what only leads may. That
+// takes an authenticated lead: without -auth anyone could claim a lead's
+// name in X-Tasktime-User, so nobody leads.
+func isLead(r *http.Request) bool {
+	user := requestUser(r)
+	return user != nil && user.Lead
+}
+
+// checkLead refuses with 403 what only leads may do.
+func (s *Server) checkLead(w http.ResponseWriter, r *http.Request, what string) bool {
+	if !isLead(r) {
+		msg := "only leads can " + what
+		if !s.requireAuth {
+			msg += ", which needs a server started with -auth"
+		}
+		http.Error(w, msg, http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// timerRunningIn reports whether a user has a timer running that started
+// in the week starting on week.
+func (s *Server) timerRunningIn(user, week string) (bool, error) {
+	tasks, err := s.store.GetTasks()
+	if err != nil {
+		return false, err
+	}
+	for _, task := range tasks {
+		for _, timer := range task.Timers {
+			if strings.EqualFold(timer.User, user) && models.WeekOf(timer.StartTime) == week {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// checkWeekOpen refuses with 423 to start a timer in a week of the user's
+// that is already approved, since stopping it would add to that week.
+func (s *Server) checkWeekOpen(w http.ResponseWriter, user string) bool {
+	if s.timesheets == nil {
+		return true
+	}
+
+	sheet, err := s.timesheets.GetTimesheet(user, models.WeekOf(time.Now()))
+	if err != nil {
+		storeError(w, err)
+		return false
+	}
+	if sheet.Status == models.TimesheetApproved {
+		storeError(w, storage.ErrWeekLocked)
+		return false
+	}
+	return true
+}
+
+func writeTimesheet(w http.ResponseWriter, sheet *models.Timesheet) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sheet)
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+func TestApprovedWeekIsLocked(t *testing.T) {
+	user := newAuthServer(t)
+	alice, lee := user("alice", false), user("lee", true)
+	(apiClient{t: t, url: alice.url}).call("GET", "/api/v1/tasks", nil).expect(t, 401, nil)
+
+	task := alice.createTask("Timesheet work")
+	var added models.TimeEntry
+	alice.call("POST", "/api/v1/tasks/"+task.ID+"/time_entries", entryRequest(t, "2026-09-09T09:00:00Z", "2026-09-09T11:00:00Z")).expect(t, 200, &added)
+
+	const week = "/api/v1/timesheets/alice/2026-09-07"
+	alice.call("POST", week+"/submit", nil).expect(t, 200, nil)
+	alice.call("POST", week+"/approve", nil).expect(t, 403, nil)
+	var sheet models.Timesheet
+	lee.call("POST", week+"/approve", nil).expect(t, 200, &sheet)
+	if sheet.Status != models.TimesheetApproved || sheet.TotalSeconds != 7200 {
+		t.Fatalf("approved timesheet = %+v", sheet)
+	}
+
+	alice.call("POST", "/api/v1/tasks/"+task.ID+"/time_entries", entryRequest(t, "2026-09-10T09:00:00Z", "2026-09-10T10:00:00Z")).expect(t, 423, nil)
+	alice.call("PUT", "/api/v1/time_entries/"+added.ID, entryRequest(t, "2026-09-09T09:00:00Z", "2026-09-09T12:00:00Z")).expect(t, 423, nil)
+	alice.call("DELETE", "/api/v1/time_entries/"+added.ID, nil).expect(t, 423, nil)
+	alice.call("DELETE", "/api/v1/tasks/"+task.ID, nil).expect(t, 423, nil)
+
+	alice.call("POST", "/api/v1/tasks/"+task.ID+"/time_entries", entryRequest(t, "2026-09-16T09:00:00Z", "2026-09-16T10:00:00Z")).expect(t, 200, nil)
+
+	lee.call("POST", week+"/reject", models.ReviewRequest{Comment: "Missing Friday"}).expect(t, 200, nil)
+	alice.call("DELETE", "/api/v1/time_entries/"+added.ID, nil).expect(t, 204, nil)
+}
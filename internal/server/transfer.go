@@ -0,0 +1,119 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+	"github.com/ifrunruhin12/tasktime/internal/storage"
+)
+
+// exportTasks sends every task outside the trash with its time entries, as
+// JSON or, with ?format=csv, as CSV.
+func (s *Server) exportTasks(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if err := models.CheckExportFormat(format); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	export, err := storage.Export(s.store)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	contentType := "application/json"
+	if format == "csv" {
+		contentType = "text/csv; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="tasktime-`+time.Now().Format("2006-01-02")+`.`+format+`"`)
+	models.WriteExport(w, format, export)
+}
+
+// importTasks adds the tasks of an export in the body, as JSON or CSV
+// going by ?format= or else the Content-Type. Time entries without a user
+// are recorded under whoever imports them; with authentication on, only
+// leads may import entries of other users. The whole export is checked
+// before anything is added, but the import itself isn't atomic: if a store
+// error stops it halfway, the tasks added so far stay and are announced.
+func (s *Server) importTasks(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "text/csv" {
+			format = "csv"
+		}
+	}
+	if err := models.CheckExportFormat(format); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	export, err := models.ReadExport(r.Body, format)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	if err := export.Validate(s.workflow); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	user, caller := actor(r), requestUser(r)
+	for i := range export.Tasks {
+		for j := range export.Tasks[i].TimeEntries {
+			entry := &export.Tasks[i].TimeEntries[j]
+			if entry.User == "" {
+				entry.User = user
+			}
+			if caller != nil && !caller.Lead && !strings.EqualFold(entry.User, caller.Name) {
+				http.Error(w, "only leads can import time entries of other users", 403)
+				return
+			}
+		}
+	}
+
+	result, err := storage.Import(s.store, export)
+	if result != nil {
+		s.announceImport(r, result)
+	}
+	if err != nil {
+		storeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// announceImport records and broadcasts the tasks an import created or
+// added time entries to, including those of an import that failed halfway.
+func (s *Server) announceImport(r *http.Request, result *models.ImportResult) {
+	for _, ids := range []struct {
+		action string
+		ids    []string
+	}{{"task.created", result.Created}, {"task.updated", result.Updated}} {
+		for _, id := range ids.ids {
+			task, err := s.store.GetTask(id)
+			if err != nil {
+				log.Printf("Failed to load imported task %s: %v", id, err)
+				continue
+			}
+
+			s.record(r, "task.imported", id, nil, task)
+
+			s.broadcast(models.WSMessage{
+				Type:    ids.action,
+				Payload: task,
+			})
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+func TestImportSkipsDuplicates(t *testing.T) {
+	api := newTestServer(t)
+	export := `{"tasks": [{
+		"id": "a", "title": "Imported", "status": "review", "created_at": "2026-09-01T08:00:00Z",
+		"time_entries": [{"id": "e", "user": "alice", "start_time": "2026-09-01T09:00:00Z", "end_time": "2026-09-01T10:00:00Z"}]
+	}]}`
+
+	var result models.ImportResult
+	api.call("POST", "/api/v1/import", export).expect(t, 200, &result)
+	if result.TasksCreated != 1 || result.EntriesCreated != 1 || result.TasksSkipped != 0 {
+		t.Fatalf("first import = %+v", result)
+	}
+
+	var task models.Task
+	api.call("GET", "/api/v1/tasks/"+result.IDs["a"], nil).expect(t, 200, &task)
+	if task.Status != "review" || task.TotalTimeSeconds != 3600 {
+		t.Fatalf("imported task = %+v", task)
+	}
+
+	result = models.ImportResult{}
+	api.call("POST", "/api/v1/import", export).expect(t, 200, &result)
+	if result.TasksCreated != 0 || result.TasksSkipped != 1 || result.EntriesCreated != 0 || result.EntriesSkipped != 1 {
+		t.Fatalf("second import = %+v", result)
+	}
+
+	var tasks []models.Task
+	api.call("GET", "/api/v1/tasks", nil).expect(t, 200, &tasks)
+	if len(tasks) != 1 {
+		t.Fatalf("%d tasks after importing twice, want 1", len(tasks))
+	}
+
+	api.call("POST", "/api/v1/import", `{"tasks": [{"id": "b", "title": "Odd", "status": "shipped"}]}`).expect(t, 400, nil)
+	api.call("POST", "/api/v1/import", `{"tasks": [{"id": "c", "title": "Backwards", "time_entries": [
+		{"id": "f", "start_time": "2026-09-01T10:00:00Z", "end_time": "2026-09-01T09:00:00Z"}]}]}`).expect(t, 400, nil)
+}
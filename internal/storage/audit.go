@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+const auditEventColumns = `id, task_id, actor, action, before, after, created_at`
+
+func (s *PostgresStore) RecordAudit(event models.AuditEvent) error {
+	_, err := s.db.Exec(`
+	INSERT INTO audit_events (task_id, actor, action, before, after)
+	VALUES ($1, $2, $3, $4, $5)
+	`, event.TaskID, event.Actor, event.Action, jsonParam(event.Before), jsonParam(event.After))
+	return err
+}
+
+func (s *PostgresStore) GetTaskHistory(taskID string) ([]models.AuditEvent, error) {
+	rows, err := s.db.Query(`
+	SELECT `+auditEventColumns+`
+	FROM audit_events
+	WHERE task_id = $1
+	ORDER BY created_at
+	`, taskID)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	defer rows.Close()
+
+	return scanAuditEvents(rows)
+}
+
+func (s *PostgresStore) GetAuditEvents(since time.Time, limit int) ([]models.AuditEvent, error) {
+	rows, err := s.db.Query(`
+	SELECT `+auditEventColumns+`
+	FROM audit_events
+	WHERE created_at > $1
+	ORDER BY created_at
+	LIMIT $2
+	`, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAuditEvents(rows)
+}
+
+func scanAuditEvents(rows *sql.Rows) ([]models.AuditEvent, error) {
+	events := []models.AuditEvent{}
+	for rows.Next() {
+		var event models.AuditEvent
+		var before, after []byte
+		err := rows.Scan(
+			&event.ID, &event.TaskID, &event.Actor, &event.Action,
+			&before, &after, &event.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		event.Before = before
+		event.After = after
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// jsonParam passes JSON to a JSONB column. lib/pq would send a []byte as
+// bytea, so it has to go as text, and an empty value as NULL.
+func jsonParam(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+// The file-backed store appends audit events to a JSON-lines file next to
+// the task file. It is never rewritten, so purging tasks keeps their history.
+func (s *LocalStore) auditPath() string {
+	return s.filePath + ".audit.jsonl"
+}
+
+func (s *LocalStore) RecordAudit(event models.AuditEvent) error {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if event.ID == "" {
+		event.ID = generateID()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	if s.filePath == "" {
+		s.auditEvents = append(s.auditEvents, event)
+		return nil
+	}
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.auditPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s *LocalStore) GetTaskHistory(taskID string) ([]models.AuditEvent, error) {
+	return s.filterAudit(func(event models.AuditEvent) bool {
+		return event.TaskID == taskID
+	}, 0)
+}
+
+func (s *LocalStore) GetAuditEvents(since time.Time, limit int) ([]models.AuditEvent, error) {
+	return s.filterAudit(func(event models.AuditEvent) bool {
+		return event.CreatedAt.After(since)
+	}, limit)
+}
+
+// filterAudit returns the events that match, in the order they were
+// recorded, stopping after limit events unless limit is zero.
+func (s *LocalStore) filterAudit(match func(models.AuditEvent) bool, limit int) ([]models.AuditEvent, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	events := []models.AuditEvent{}
+	add := func(event models.AuditEvent) bool {
+		if match(event) {
+			events = append(events, event)
+		}
+		return limit == 0 || len(events) < limit
+	}
+
+	if s.filePath == "" {
+		for _, event := range s.auditEvents {
+			if !add(event) {
+				break
+			}
+		}
+		return events, nil
+	}
+
+	f, err := os.Open(s.auditPath())
+	if os.IsNotExist(err) {
+		return events, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var event models.AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			// A torn last line from a crash shouldn't hide the rest
+			continue
+		}
+		if !add(event) {
+			break
+		}
+	}
+
+	return events, scanner.Err()
+}
@@ -0,0 +1,352 @@
+package storage
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+func (s *PostgresStore) SetEntryBillable(id string, billable bool) (*models.TimeEntry, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkEntryOpen(tx, id); err != nil {
+		return nil, err
+	}
+
+	entry, err := scanTimeEntry(tx.QueryRow(`
+	UPDATE time_entries SET non_billable = $1 WHERE id = $2
+	RETURNING `+timeEntryColumns, !billable, id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return entry, tx.Commit()
+}
+
+func (s *PostgresStore) GetRates() ([]models.Rate, error) {
+	rows, err := s.db.Query(`
+	SELECT COALESCE(r.project_id::text, ''), COALESCE(p.name, ''), r.user_name, r.hourly_rate_cents
+	FROM rates r LEFT JOIN projects p ON p.id = r.project_id
+	ORDER BY LOWER(p.name) NULLS FIRST, LOWER(r.user_name)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []models.Rate{}
+	for rows.Next() {
+		var rate models.Rate
+		if err := rows.Scan(&rate.ProjectID, &rate.Project, &rate.User, &rate.HourlyRateCents); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
+
+func (s *PostgresStore) SetRate(rate models.Rate) (*models.Rate, error) {
+	if err := s.rateProject(&rate); err != nil {
+		return nil, err
+	}
+
+	_, err := s.db.Exec(`
+	INSERT INTO rates (project_id, user_name, hourly_rate_cents)
+	VALUES ($1, $2, $3)
+	ON CONFLICT ((COALESCE(project_id::text, '')), (LOWER(user_name)))
+	DO UPDATE SET user_name = EXCLUDED.user_name, hourly_rate_cents = EXCLUDED.hourly_rate_cents
+	`, nullParam(rate.ProjectID), rate.User, rate.HourlyRateCents)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rate, nil
+}
+
+func (s *PostgresStore) DeleteRate(rate models.Rate) error {
+	if err := s.rateProject(&rate); err != nil {
+		return err
+	}
+
+	result, err := s.db.Exec(`
+	DELETE FROM rates
+	WHERE project_id IS NOT DISTINCT FROM $1::uuid AND LOWER(user_name) = LOWER($2)
+	`, nullParam(rate.ProjectID), rate.User)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// rateProject fills in the ID and name of a rate's project, if it has one.
+func (s *PostgresStore) rateProject(rate *models.Rate) error {
+	rate.User = strings.TrimSpace(rate.User)
+	if rate.ProjectID == "" && strings.TrimSpace(rate.Project) == "" {
+		rate.Project = ""
+		return nil
+	}
+
+	id, name, err := s.resolveProject(rate.ProjectID, rate.Project)
+	if err != nil {
+		return err
+	}
+	if id == "" {
+		return ErrInvalidProject
+	}
+	rate.ProjectID, rate.Project = id, name
+	return nil
+}
+
+func (s *LocalStore) SetEntryBillable(id string, billable bool) (*models.TimeEntry, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, task := range file.Tasks {
+		for j, entry := range task.TimeEntries {
+			if entry.ID != id {
+				continue
+			}
+			if weekApproved(file.Timesheets, entry.User, entry.StartTime) {
+				return nil, ErrWeekLocked
+			}
+
+			entry.NonBillable = !billable
+			file.Tasks[i].TimeEntries[j] = entry
+			if err := s.saveFile(file); err != nil {
+				return nil, err
+			}
+			return &entry, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (s *LocalStore) GetRates() ([]models.Rate, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	rates := []models.Rate{}
+	for _, rate := range file.Rates {
+		if rate.ProjectID != "" {
+			_, rate.Project, _ = findProject(file.Projects, rate.ProjectID, "")
+		}
+		rates = append(rates, rate)
+	}
+	sort.SliceStable(rates, func(i, j int) bool {
+		if a, b := strings.ToLower(rates[i].Project), strings.ToLower(rates[j].Project); a != b {
+			return a < b
+		}
+		return strings.ToLower(rates[i].User) < strings.ToLower(rates[j].User)
+	})
+
+	return rates, nil
+}
+
+func (s *LocalStore) SetRate(rate models.Rate) (*models.Rate, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := localRateProject(file.Projects, &rate); err != nil {
+		return nil, err
+	}
+	stored := rate
+	stored.Project = "" // Looked up on reading, so renames carry over
+	if i := findRate(file.Rates, rate); i >= 0 {
+		file.Rates[i] = stored
+	} else {
+		file.Rates = append(file.Rates, stored)
+	}
+	if err := s.saveFile(file); err != nil {
+		return nil, err
+	}
+
+	return &rate, nil
+}
+
+func (s *LocalStore) DeleteRate(rate models.Rate) error {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return err
+	}
+
+	if err := localRateProject(file.Projects, &rate); err != nil {
+		return err
+	}
+	i := findRate(file.Rates, rate)
+	if i < 0 {
+		return ErrNotFound
+	}
+	file.Rates = append(file.Rates[:i], file.Rates[i+1:]...)
+	return s.saveFile(file)
+}
+
+// localRateProject is rateProject for the projects in a local file.
+func localRateProject(projects []models.Project, rate *models.Rate) error {
+	rate.User = strings.TrimSpace(rate.User)
+	if rate.ProjectID == "" && strings.TrimSpace(rate.Project) == "" {
+		rate.Project = ""
+		return nil
+	}
+
+	id, name, err := findProject(projects, rate.ProjectID, rate.Project)
+	if err != nil {
+		return err
+	}
+	if id == "" {
+		return ErrInvalidProject
+	}
+	rate.ProjectID, rate.Project = id, name
+	return nil
+}
+
+// findRate returns the index of the rate for the same project and user as
+// rate, or -1.
+func findRate(rates []models.Rate, rate models.Rate) int {
+	for i, existing := range rates {
+		if existing.ProjectID == rate.ProjectID && strings.EqualFold(existing.User, rate.User) {
+			return i
+		}
+	}
+	return -1
+}
+
+// BuildInvoice totals the billable time a client is charged for from one
+// date to another: the finished entries not marked non-billable, of tasks
+// outside the trash in the client's projects, that started in the period
+// in a week whose timesheet is approved. Time is grouped per project and
+// user and priced at the rate models.RateFor picks. The store has to keep
+// projects, rates and timesheets.
+func BuildInvoice(store TaskStore, query models.InvoiceQuery) (*models.Invoice, error) {
+	projects, hasProjects := store.(ProjectStore)
+	billing, hasRates := store.(BillingStore)
+	timesheets, hasTimesheets := store.(TimesheetStore)
+	if !hasProjects || !hasRates || !hasTimesheets {
+		return nil, errors.New("invoices need a store with projects, rates and timesheets")
+	}
+
+	all, err := projects.GetProjects(true)
+	if err != nil {
+		return nil, err
+	}
+	clientProjects := make(map[string]models.Project)
+	for _, project := range all {
+		if strings.EqualFold(project.Client, query.Client) {
+			clientProjects[project.ID] = project
+		}
+	}
+
+	rates, err := billing.GetRates()
+	if err != nil {
+		return nil, err
+	}
+	approved, err := timesheets.GetTimesheets("", models.TimesheetApproved)
+	if err != nil {
+		return nil, err
+	}
+	approvedWeeks := make(map[string]bool, len(approved))
+	for _, sheet := range approved {
+		approvedWeeks[strings.ToLower(sheet.User)+" "+sheet.WeekStart] = true
+	}
+
+	export, err := Export(store)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice := &models.Invoice{
+		Client:    query.Client,
+		From:      query.From,
+		To:        query.To,
+		Currency:  query.Currency,
+		CreatedAt: time.Now(),
+		Lines:     []models.InvoiceLine{},
+	}
+	lines := make(map[string]int) // Project ID and user to index in invoice.Lines
+	for _, task := range export.Tasks {
+		project, ok := clientProjects[task.ProjectID]
+		if !ok {
+			continue
+		}
+		for _, entry := range task.TimeEntries {
+			if entry.NonBillable || !query.Matches(entry) ||
+				!approvedWeeks[strings.ToLower(entry.User)+" "+models.WeekOf(entry.StartTime)] {
+				continue
+			}
+
+			key := project.ID + " " + strings.ToLower(entry.User)
+			i, ok := lines[key]
+			if !ok {
+				line := models.InvoiceLine{Project: project.Name, User: entry.User}
+				if rate, ok := models.RateFor(rates, project.ID, entry.User); ok {
+					line.HourlyRateCents = rate.HourlyRateCents
+				} else {
+					line.Unrated = true
+				}
+				i = len(invoice.Lines)
+				lines[key] = i
+				invoice.Lines = append(invoice.Lines, line)
+			}
+			invoice.Lines[i].Entries++
+			invoice.Lines[i].Seconds += entry.DurationSeconds
+		}
+	}
+
+	sort.SliceStable(invoice.Lines, func(i, j int) bool {
+		a, b := invoice.Lines[i], invoice.Lines[j]
+		if !strings.EqualFold(a.Project, b.Project) {
+			return strings.ToLower(a.Project) < strings.ToLower(b.Project)
+		}
+		return strings.ToLower(a.User) < strings.ToLower(b.User)
+	})
+	for i := range invoice.Lines {
+		invoice.Lines[i].Price()
+		invoice.TotalSeconds += invoice.Lines[i].Seconds
+		invoice.TotalCents += invoice.Lines[i].AmountCents
+	}
+
+	return invoice, nil
+}
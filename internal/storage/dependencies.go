@@ -0,0 +1,275 @@
+package storage
+
+import "github.com/ifrunruhin12/tasktime/internal/models"
+
+// dependencyLockID is the pg_advisory_xact_lock key that serialises changes
+// to task_dependencies, so two links added at once can't close a cycle that
+// neither check saw.
+const dependencyLockID = 7358
+
+func (s *PostgresStore) AddDependency(taskID, blockedByID string) (*models.Task, error) {
+	if taskID == blockedByID {
+		return nil, ErrDependencyCycle
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", dependencyLockID); err != nil {
+		return nil, err
+	}
+
+	var live int
+	err = tx.QueryRow(`
+	SELECT COUNT(*) FROM tasks WHERE id IN ($1, $2) AND deleted_at IS NULL
+	`, taskID, blockedByID).Scan(&live)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if live != 2 {
+		return nil, ErrNotFound
+	}
+
+	// Would blockedByID, through its own blockers, end up waiting for taskID?
+	var cycle bool
+	err = tx.QueryRow(`
+	WITH RECURSIVE chain AS (
+		SELECT blocked_by_id FROM task_dependencies WHERE task_id = $1
+		UNION
+		SELECT d.blocked_by_id
+		FROM task_dependencies d JOIN chain ON d.task_id = chain.blocked_by_id
+	)
+	SELECT EXISTS (SELECT 1 FROM chain WHERE blocked_by_id = $2)
+	`, blockedByID, taskID).Scan(&cycle)
+	if err != nil {
+		return nil, err
+	}
+	if cycle {
+		return nil, ErrDependencyCycle
+	}
+
+	result, err := tx.Exec(`
+	INSERT INTO task_dependencies (task_id, blocked_by_id)
+	VALUES ($1, $2)
+	ON CONFLICT DO NOTHING
+	`, taskID, blockedByID)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		// Already linked; the task didn't change
+		tx.Rollback()
+		return s.GetTask(taskID)
+	}
+
+	task, err := scanTask(tx.QueryRow(`
+	UPDATE tasks SET version = version + 1
+	WHERE id = $1
+	RETURNING `+taskColumns, taskID))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.withRollup(task)
+}
+
+func (s *PostgresStore) RemoveDependency(taskID, blockedByID string) (*models.Task, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+	DELETE FROM task_dependencies WHERE task_id = $1 AND blocked_by_id = $2
+	`, taskID, blockedByID)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, ErrNotFound
+	}
+
+	task, err := scanTask(tx.QueryRow(`
+	UPDATE tasks SET version = version + 1
+	WHERE id = $1 AND deleted_at IS NULL
+	RETURNING `+taskColumns, taskID))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.withRollup(task)
+}
+
+func (s *PostgresStore) GetOpenBlockers(taskID string) ([]models.Task, error) {
+	rows, err := s.db.Query(`
+	SELECT `+taskColumns+`
+	FROM tasks
+	WHERE id IN (SELECT blocked_by_id FROM task_dependencies WHERE task_id = $1)
+	  AND deleted_at IS NULL AND status <> 'done'
+	ORDER BY created_at
+	`, taskID)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *task)
+	}
+
+	return tasks, rows.Err()
+}
+
+func (s *LocalStore) AddDependency(taskID, blockedByID string) (*models.Task, error) {
+	if taskID == blockedByID {
+		return nil, ErrDependencyCycle
+	}
+
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	tasks, err := s.loadTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(tasks))
+	for i, task := range tasks {
+		if task.DeletedAt == nil {
+			index[task.ID] = i
+		}
+	}
+	i, ok := index[taskID]
+	if _, blockerOK := index[blockedByID]; !ok || !blockerOK {
+		return nil, ErrNotFound
+	}
+
+	// Would blockedByID, through its own blockers, end up waiting for taskID?
+	seen := make(map[string]bool)
+	pending := []string{blockedByID}
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if id == taskID {
+			return nil, ErrDependencyCycle
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		for _, task := range tasks {
+			if task.ID == id {
+				pending = append(pending, task.BlockedBy...)
+			}
+		}
+	}
+
+	if containsString(tasks[i].BlockedBy, blockedByID) {
+		// Already linked; the task didn't change
+		return rolledUp(tasks, taskID), nil
+	}
+	tasks[i].BlockedBy = append(tasks[i].BlockedBy, blockedByID)
+	tasks[i].Version++
+	if err := s.saveTasks(tasks); err != nil {
+		return nil, err
+	}
+
+	return rolledUp(tasks, taskID), nil
+}
+
+func (s *LocalStore) RemoveDependency(taskID, blockedByID string) (*models.Task, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	tasks, err := s.loadTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, task := range tasks {
+		if task.ID == taskID && task.DeletedAt == nil && containsString(task.BlockedBy, blockedByID) {
+			tasks[i].BlockedBy = removeString(task.BlockedBy, blockedByID)
+			tasks[i].Version++
+			if err := s.saveTasks(tasks); err != nil {
+				return nil, err
+			}
+			return rolledUp(tasks, taskID), nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (s *LocalStore) GetOpenBlockers(taskID string) ([]models.Task, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	tasks, err := s.loadTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	var blockedBy []string
+	for _, task := range tasks {
+		if task.ID == taskID {
+			blockedBy = task.BlockedBy
+		}
+	}
+
+	blockers := []models.Task{}
+	for _, task := range tasks {
+		if task.DeletedAt == nil && task.Status != "done" && containsString(blockedBy, task.ID) {
+			blockers = append(blockers, task.Task)
+		}
+	}
+
+	return blockers, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// removeString returns list without s.
+func removeString(list []string, s string) []string {
+	var kept []string
+	for _, item := range list {
+		if item != s {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
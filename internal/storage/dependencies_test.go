@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+func TestDependencies(t *testing.T) {
+	testStores(t, func(t *testing.T, store TaskStore) {
+		var ids []string
+		for _, title := range []string{"Design", "Build", "Ship"} {
+			task, err := store.CreateTask(models.CreateTaskRequest{Title: title})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, task.ID)
+		}
+		design, build, ship := ids[0], ids[1], ids[2]
+
+		blocked, err := store.AddDependency(build, design)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(blocked.BlockedBy) != 1 || blocked.BlockedBy[0] != design || blocked.Version != 2 {
+			t.Fatalf("build after adding design = %+v", blocked)
+		}
+		again, err := store.AddDependency(build, design)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again.Version != blocked.Version || len(again.BlockedBy) != 1 {
+			t.Fatalf("adding the same link again made %+v", again)
+		}
+
+		if _, err := store.AddDependency(ship, build); err != nil {
+			t.Fatal(err)
+		}
+		for _, link := range [][2]string{{design, ship}, {design, design}, {build, ship}} {
+			if _, err := store.AddDependency(link[0], link[1]); !errors.Is(err, ErrDependencyCycle) {
+				t.Errorf("linking %s to %s: got %v, want ErrDependencyCycle", link[0], link[1], err)
+			}
+		}
+
+		blockers, err := store.GetOpenBlockers(ship)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(blockers) != 1 || blockers[0].ID != build {
+			t.Errorf("ship's open blockers = %+v", blockers)
+		}
+
+		if _, err := store.RemoveDependency(build, design); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.RemoveDependency(build, design); !errors.Is(err, ErrNotFound) {
+			t.Errorf("removing a missing link: got %v, want ErrNotFound", err)
+		}
+	})
+}
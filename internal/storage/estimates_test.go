@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+func TestRunningEstimates(t *testing.T) {
+	testStores(t, func(t *testing.T, store TaskStore) {
+		create := func(req models.CreateTaskRequest) *models.Task {
+			t.Helper()
+			task, err := store.CreateTask(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return task
+		}
+		epic := create(models.CreateTaskRequest{Title: "Epic", EstimateSeconds: 8 * 3600})
+		story := create(models.CreateTaskRequest{Title: "Story", ParentID: epic.ID, EstimateSeconds: 3600})
+		chore := create(models.CreateTaskRequest{Title: "Chore", ParentID: story.ID})
+		idle := create(models.CreateTaskRequest{Title: "Idle", EstimateSeconds: 3600})
+		create(models.CreateTaskRequest{Title: "Unestimated"})
+
+		start := time.Now().Add(-2 * time.Hour)
+		if _, err := store.AddTimeEntry(chore.ID, "alice", start, start.Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.AddTimeEntry(idle.ID, "alice", start, start.Add(2*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.StartTimer(chore.ID, "bob", 0); err != nil {
+			t.Fatal(err)
+		}
+
+		running, err := RunningEstimates(store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Slice(running, func(i, j int) bool { return running[i].Task.Title < running[j].Task.Title })
+		if len(running) != 2 || running[0].Task.ID != epic.ID || running[1].Task.ID != story.ID {
+			t.Fatalf("running estimated tasks = %+v", running)
+		}
+		for _, estimated := range running {
+			if len(estimated.Timers) != 1 || estimated.Timers[0].User != "bob" {
+				t.Errorf("%s has timers %+v", estimated.Task.Title, estimated.Timers)
+			}
+			if estimated.Task.RollupTimeSeconds != 3600 {
+				t.Errorf("%s has %ds recorded, want 3600", estimated.Task.Title, estimated.Task.RollupTimeSeconds)
+			}
+			if live := estimated.LiveSeconds(time.Now().Add(time.Minute)); live < 3660 {
+				t.Errorf("%s has %ds live, want at least 3660", estimated.Task.Title, live)
+			}
+		}
+
+		for _, status := range []string{"in-progress", "review", "done"} {
+			if _, err := store.UpdateTaskStatus(story.ID, status, 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+		running, err = RunningEstimates(store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(running) != 1 || running[0].Task.ID != epic.ID {
+			t.Fatalf("with the story done, running estimated tasks = %+v", running)
+		}
+	})
+}
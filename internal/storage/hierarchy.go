@@ -0,0 +1,24 @@
+package storage
+
+import "github.com/ifrunruhin12/tasktime/internal/models"
+
+// rollupTimes sets RollupTimeSeconds on every task to its own time plus that
+// of its subtasks in the list. Subtasks whose parent isn't in the list count
+// only towards themselves.
+func rollupTimes(tasks []models.Task) {
+	index := make(map[string]int, len(tasks))
+	for i := range tasks {
+		index[tasks[i].ID] = i
+		tasks[i].RollupTimeSeconds = 0
+	}
+
+	for _, task := range tasks {
+		// Walk up from every task, adding its time to itself and each
+		// ancestor. seen stops the walk should the data hold a cycle.
+		seen := make(map[string]bool)
+		for i, ok := index[task.ID]; ok && !seen[tasks[i].ID]; i, ok = index[tasks[i].ParentID] {
+			seen[tasks[i].ID] = true
+			tasks[i].RollupTimeSeconds += task.TotalTimeSeconds
+		}
+	}
+}
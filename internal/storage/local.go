@@ -1,16 +1,29 @@
 package storage
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/ifrunruhin12/tasktime/internal/models"
 )
 
+// LocalStore keeps tasks in a JSON file. With an empty filePath the same
+// JSON is kept in memory instead, which is what NewMemoryStore uses.
 type LocalStore struct {
 	filePath string
+	personal bool
+	mu       sync.Mutex
+	data     []byte
+
+	auditEvents []models.AuditEvent // audit log of the in-memory store
+	workflow    *models.Workflow    // nil for models.DefaultWorkflow
 }
 
 func NewLocalStore() (*LocalStore, error) {
@@ -25,144 +38,858 @@ func NewLocalStore() (*LocalStore, error) {
 		return nil, err
 	}
 
-	filePath := filepath.Join(tasktimeDir, "personal_tasks.json")
-	
-	return &LocalStore{
-		filePath: filePath,
-	}, nil
+	store := &LocalStore{
+		filePath: filepath.Join(tasktimeDir, "personal_tasks.json"),
+		personal: true,
+	}
+	if err := store.recoverFile(); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// NewFileStore opens a JSON task file at an arbitrary path. It is used by the
+// server's json-file backend, so tasks created through it are team tasks.
+func NewFileStore(filePath string) (*LocalStore, error) {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return nil, err
+	}
+
+	store := &LocalStore{filePath: filePath}
+	if err := store.recoverFile(); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// localTask is how a task is kept in the JSON file: the task itself plus
+// the history of its timer runs, which isn't part of the task API.
+type localTask struct {
+	models.Task
+	TimeEntries []models.TimeEntry `json:"time_entries,omitempty"`
+}
+
+// NewMemoryStore returns a store that never touches the disk. Everything is
+// lost when the process exits.
+func NewMemoryStore() *LocalStore {
+	return &LocalStore{}
+}
+
+// SetWorkflow sets the status workflow that status changes are checked
+// against. Without one, models.DefaultWorkflow applies.
+func (s *LocalStore) SetWorkflow(workflow models.Workflow) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.workflow = &workflow
+}
+
+// checkStatus is called with the store locked.
+func (s *LocalStore) checkStatus(from, to string) error {
+	if s.workflow == nil {
+		return models.DefaultWorkflow().Check(from, to)
+	}
+	return s.workflow.Check(from, to)
 }
 
 func (s *LocalStore) GetTasks() ([]models.Task, error) {
-	if _, err := os.Stat(s.filePath); os.IsNotExist(err) {
-		return []models.Task{}, nil
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
 	}
+	defer unlock()
 
-	data, err := os.ReadFile(s.filePath)
+	stored, err := s.loadTasks()
 	if err != nil {
 		return nil, err
 	}
 
-	var tasks []models.Task
-	if err := json.Unmarshal(data, &tasks); err != nil {
+	return liveTasks(stored), nil
+}
+
+// liveTasks returns the tasks outside the trash with their rollups.
+func liveTasks(stored []localTask) []models.Task {
+	tasks := []models.Task{}
+	for _, task := range stored {
+		if task.DeletedAt == nil {
+			tasks = append(tasks, task.Task)
+		}
+	}
+	rollupTimes(tasks)
+
+	return tasks
+}
+
+// rolledUp returns a copy of the live task with the given ID with its
+// rollup filled in.
+func rolledUp(stored []localTask, id string) *models.Task {
+	for _, task := range liveTasks(stored) {
+		if task.ID == id {
+			return &task
+		}
+	}
+	return nil
+}
+
+// checkParent makes sure parentID names a live task that isn't id itself
+// or one of its subtasks. An empty parentID is always fine.
+func checkParent(tasks []localTask, id, parentID string) error {
+	if parentID == "" {
+		return nil
+	}
+
+	parents := make(map[string]string)
+	for _, task := range tasks {
+		if task.DeletedAt == nil {
+			parents[task.ID] = task.ParentID
+		}
+	}
+	if _, ok := parents[parentID]; !ok {
+		return ErrInvalidParent
+	}
+
+	seen := make(map[string]bool)
+	for current := parentID; current != "" && !seen[current]; current = parents[current] {
+		if current == id {
+			return ErrInvalidParent
+		}
+		seen[current] = true
+	}
+	return nil
+}
+
+// GetTimeEntries returns the recorded timer runs of a task, oldest first.
+func (s *LocalStore) GetTimeEntries(taskID string) ([]models.TimeEntry, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
 		return nil, err
 	}
+	defer unlock()
 
-	return tasks, nil
+	tasks, err := s.loadTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, task := range tasks {
+		if task.ID == taskID && task.DeletedAt == nil {
+			if task.TimeEntries == nil {
+				return []models.TimeEntry{}, nil
+			}
+			return task.TimeEntries, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (s *LocalStore) GetTimeEntry(id string) (*models.TimeEntry, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, task := range file.Tasks {
+		for _, entry := range task.TimeEntries {
+			if entry.ID == id {
+				return &entry, nil
+			}
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (s *LocalStore) GetTask(id string) (*models.Task, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	tasks, err := s.loadTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	if task := rolledUp(tasks, id); task != nil {
+		return task, nil
+	}
+
+	return nil, ErrNotFound
+}
+
+// localFile is the layout of the JSON file. Files without projects, users
+// or timesheets, which includes every file written before those existed,
+// hold just the array of tasks.
+type localFile struct {
+	Tasks      []localTask        `json:"tasks"`
+	Projects   []models.Project   `json:"projects,omitempty"`
+	Users      []localUser        `json:"users,omitempty"`
+	Timesheets []models.Timesheet `json:"timesheets,omitempty"`
+	Rates      []models.Rate      `json:"rates,omitempty"`
+}
+
+func (s *LocalStore) loadFile() (*localFile, error) {
+	data := s.data
+	if s.filePath != "" {
+		if _, err := os.Stat(s.filePath); os.IsNotExist(err) {
+			return &localFile{Tasks: []localTask{}}, nil
+		}
+
+		var err error
+		data, err = os.ReadFile(s.filePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(data) == 0 {
+		return &localFile{Tasks: []localTask{}}, nil
+	}
+
+	return decodeFile(data)
+}
+
+func decodeFile(data []byte) (*localFile, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var tasks []localTask
+		if err := json.Unmarshal(data, &tasks); err != nil {
+			return nil, err
+		}
+		return &localFile{Tasks: tasks}, nil
+	}
+
+	var file localFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Tasks == nil {
+		file.Tasks = []localTask{}
+	}
+
+	return &file, nil
 }
 
-func (s *LocalStore) saveTasks(tasks []models.Task) error {
-	data, err := json.MarshalIndent(tasks, "", "  ")
+func (s *LocalStore) saveFile(file *localFile) error {
+	// Stay readable by older versions until projects, users, timesheets or
+	// rates are used
+	var content interface{} = file.Tasks
+	if len(file.Projects) > 0 || len(file.Users) > 0 || len(file.Timesheets) > 0 || len(file.Rates) > 0 {
+		content = file
+	}
+
+	data, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if s.filePath == "" {
+		s.data = data
+		return nil
+	}
+
+	return s.writeFile(data)
+}
+
+func (s *LocalStore) loadTasks() ([]localTask, error) {
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	return file.Tasks, nil
+}
+
+// saveTasks replaces the tasks in the file and keeps its projects.
+func (s *LocalStore) saveTasks(tasks []localTask) error {
+	file, err := s.loadFile()
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(s.filePath, data, 0644)
+	file.Tasks = tasks
+	return s.saveFile(file)
 }
 
-func (s *LocalStore) CreateTask(title, project string) (*models.Task, error) {
-	tasks, err := s.GetTasks()
+func (s *LocalStore) CreateTask(req models.CreateTaskRequest) (*models.Task, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkParent(file.Tasks, "", req.ParentID); err != nil {
+		return nil, err
+	}
+	projectID, project, err := findProject(file.Projects, req.ProjectID, req.Project)
 	if err != nil {
 		return nil, err
 	}
 
 	task := &models.Task{
 		ID:               generateID(),
-		Title:            title,
+		Title:            req.Title,
 		Project:          project,
+		ProjectID:        projectID,
 		Status:           "todo",
+		Description:      req.Description,
+		Priority:         req.Priority,
+		DueDate:          req.DueDate,
+		EstimateSeconds:  req.EstimateSeconds,
+		Tags:             models.NormalizeTags(req.Tags),
+		ParentID:         req.ParentID,
 		IsActive:         false,
 		TotalTimeSeconds: 0,
 		CreatedAt:        time.Now(),
-		IsPersonal:       true,
+		IsPersonal:       s.personal,
+		Version:          1,
 	}
 
-	tasks = append([]models.Task{*task}, tasks...)
-	if err := s.saveTasks(tasks); err != nil {
+	file.Tasks = append([]localTask{{Task: *task}}, file.Tasks...)
+	if err := s.saveFile(file); err != nil {
 		return nil, err
 	}
 
 	return task, nil
 }
 
-func (s *LocalStore) UpdateTaskStatus(id, status string) (*models.Task, error) {
-	tasks, err := s.GetTasks()
+func (s *LocalStore) UpdateTask(id string, changes models.UpdateTaskRequest, expectedVersion int) (*models.Task, error) {
+	unlock, err := s.lock(true)
 	if err != nil {
 		return nil, err
 	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+	tasks := file.Tasks
 
 	for i, task := range tasks {
-		if task.ID == id {
+		if task.ID == id && task.DeletedAt == nil {
+			if err := checkVersion(task, expectedVersion); err != nil {
+				return nil, err
+			}
+			if changes.ParentID != nil {
+				if err := checkParent(tasks, id, *changes.ParentID); err != nil {
+					return nil, err
+				}
+			}
+			if changes.Status != nil {
+				if err := s.checkStatus(task.Status, *changes.Status); err != nil {
+					return nil, err
+				}
+			}
+			if projectID, name, resolve := projectChange(changes); resolve {
+				projectID, project, err := findProject(file.Projects, projectID, name)
+				if err != nil {
+					return nil, err
+				}
+				changes.ProjectID, changes.Project = &projectID, &project
+			}
+			changes.Apply(&tasks[i].Task)
+			tasks[i].Version++
+			if err := s.saveFile(file); err != nil {
+				return nil, err
+			}
+			return rolledUp(tasks, id), nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (s *LocalStore) UpdateTaskStatus(id, status string, expectedVersion int) (*models.Task, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	tasks, err := s.loadTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, task := range tasks {
+		if task.ID == id && task.DeletedAt == nil {
+			if err := checkVersion(task, expectedVersion); err != nil {
+				return nil, err
+			}
+			if err := s.checkStatus(task.Status, status); err != nil {
+				return nil, err
+			}
 			tasks[i].Status = status
+			tasks[i].Version++
 			if err := s.saveTasks(tasks); err != nil {
 				return nil, err
 			}
-			return &tasks[i], nil
+			return rolledUp(tasks, id), nil
 		}
 	}
 
-	return nil, os.ErrNotExist
+	return nil, ErrNotFound
 }
 
-func (s *LocalStore) DeleteTask(id string) error {
-	tasks, err := s.GetTasks()
+func (s *LocalStore) DeleteTask(id string, expectedVersion int) error {
+	unlock, err := s.lock(true)
 	if err != nil {
 		return err
 	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return err
+	}
+	tasks := file.Tasks
 
 	for i, task := range tasks {
-		if task.ID == id {
-			tasks = append(tasks[:i], tasks[i+1:]...)
-			return s.saveTasks(tasks)
+		if task.ID == id && task.DeletedAt == nil {
+			if err := checkVersion(task, expectedVersion); err != nil {
+				return err
+			}
+			if entriesLocked(file.Timesheets, task.TimeEntries) {
+				return ErrWeekLocked
+			}
+			now := time.Now()
+			tasks[i].DeletedAt = &now
+			tasks[i].Version++
+			return s.saveFile(file)
 		}
 	}
 
-	return os.ErrNotExist
+	return ErrNotFound
 }
 
-func (s *LocalStore) StartTimer(id string) (*models.Task, error) {
-	tasks, err := s.GetTasks()
+// GetDeletedTasks lists the tasks in the trash, most recently deleted first.
+func (s *LocalStore) GetDeletedTasks() ([]models.Task, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	stored, err := s.loadTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []models.Task{}
+	for _, task := range stored {
+		if task.DeletedAt != nil {
+			tasks = append(tasks, task.Task)
+		}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].DeletedAt.After(*tasks[j].DeletedAt)
+	})
+
+	return tasks, nil
+}
+
+func (s *LocalStore) RestoreTask(id string) (*models.Task, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
 	if err != nil {
 		return nil, err
 	}
+	tasks := file.Tasks
 
 	for i, task := range tasks {
-		if task.ID == id {
-			now := time.Now()
-			tasks[i].IsActive = true
-			tasks[i].StartTime = &now
+		if task.ID == id && task.DeletedAt != nil {
+			if entriesLocked(file.Timesheets, task.TimeEntries) {
+				return nil, ErrWeekLocked
+			}
+			tasks[i].DeletedAt = nil
+			tasks[i].Version++
+			if err := s.saveFile(file); err != nil {
+				return nil, err
+			}
+			return rolledUp(tasks, id), nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// PurgeDeletedTasks leaves tasks with time entries in approved weeks in the
+// trash, since purging them would delete the entries too.
+func (s *LocalStore) PurgeDeletedTasks(deletedBefore time.Time) (int, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return 0, err
+	}
+	tasks := file.Tasks
+
+	kept := tasks[:0]
+	var purgedIDs []string
+	for _, task := range tasks {
+		if task.DeletedAt == nil || !task.DeletedAt.Before(deletedBefore) || entriesLocked(file.Timesheets, task.TimeEntries) {
+			kept = append(kept, task)
+		} else {
+			purgedIDs = append(purgedIDs, task.ID)
+		}
+	}
+
+	purged := len(purgedIDs)
+	if purged == 0 {
+		return 0, nil
+	}
+
+	// Drop links to the purged tasks, as the foreign keys do in Postgres:
+	// their subtasks become top-level tasks and they stop blocking anything.
+	for i := range kept {
+		if containsString(purgedIDs, kept[i].ParentID) {
+			kept[i].ParentID = ""
+		}
+		for _, id := range purgedIDs {
+			if containsString(kept[i].BlockedBy, id) {
+				kept[i].BlockedBy = removeString(kept[i].BlockedBy, id)
+			}
+		}
+	}
+
+	file.Tasks = kept
+	return purged, s.saveFile(file)
+}
+
+func (s *LocalStore) StartTimer(id, user string, expectedVersion int) (*models.Task, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	tasks, err := s.loadTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, task := range tasks {
+		if task.ID == id && task.DeletedAt == nil {
+			if err := checkVersion(task, expectedVersion); err != nil {
+				return nil, err
+			}
+			timers := runningTimers(task.Task)
+			if findTimer(timers, user) >= 0 {
+				return rolledUp(tasks, id), nil
+			}
+			tasks[i].SetTimers(append(timers, models.RunningTimer{User: user, StartTime: time.Now()}))
+			tasks[i].Version++
 			if err := s.saveTasks(tasks); err != nil {
 				return nil, err
 			}
-			return &tasks[i], nil
+			return rolledUp(tasks, id), nil
 		}
 	}
 
-	return nil, os.ErrNotExist
+	return nil, ErrNotFound
 }
 
-func (s *LocalStore) StopTimer(id string) (*models.Task, error) {
-	tasks, err := s.GetTasks()
+func (s *LocalStore) StopTimer(id, user string, expectedVersion int) (*models.Task, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	tasks, err := s.loadTasks()
 	if err != nil {
 		return nil, err
 	}
 
 	for i, task := range tasks {
-		if task.ID == id && task.IsActive && task.StartTime != nil {
-			duration := int(time.Since(*task.StartTime).Seconds())
-			tasks[i].IsActive = false
-			tasks[i].StartTime = nil
+		if task.ID == id && task.DeletedAt == nil {
+			if err := checkVersion(task, expectedVersion); err != nil {
+				return nil, err
+			}
+
+			// The user's own timer, or failing that one nobody owns
+			timers := runningTimers(task.Task)
+			n := findTimer(timers, user)
+			if n < 0 {
+				n = findTimer(timers, "")
+			}
+			if n < 0 {
+				return rolledUp(tasks, id), nil
+			}
+			timer := timers[n]
+
+			now := time.Now()
+			duration := int(now.Sub(timer.StartTime).Seconds())
+			tasks[i].TimeEntries = append(tasks[i].TimeEntries, models.TimeEntry{
+				ID:              generateID(),
+				TaskID:          task.ID,
+				User:            timer.User,
+				StartTime:       timer.StartTime,
+				EndTime:         &now,
+				DurationSeconds: duration,
+				CreatedAt:       now,
+			})
+			tasks[i].SetTimers(append(timers[:n:n], timers[n+1:]...))
 			tasks[i].TotalTimeSeconds += duration
+			tasks[i].Version++
 			if err := s.saveTasks(tasks); err != nil {
 				return nil, err
 			}
-			return &tasks[i], nil
+			return rolledUp(tasks, id), nil
 		}
 	}
 
-	return nil, os.ErrNotExist
+	return nil, ErrNotFound
+}
+
+// runningTimers returns a task's timers. Files written before timers had
+// users only have IsActive and StartTime, which make a timer nobody owns.
+func runningTimers(task models.Task) []models.RunningTimer {
+	if len(task.Timers) == 0 && task.IsActive && task.StartTime != nil {
+		return []models.RunningTimer{{StartTime: *task.StartTime}}
+	}
+	return task.Timers
+}
+
+// findTimer returns the index of the user's timer, or -1.
+func findTimer(timers []models.RunningTimer, user string) int {
+	for i, timer := range timers {
+		if timer.User == user {
+			return i
+		}
+	}
+	return -1
 }
 
-// Simple ID generator for local tasks
+func (s *LocalStore) AddTimeEntry(taskID, user string, start, end time.Time) (*models.TimeEntry, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+	tasks := file.Tasks
+
+	for i, task := range tasks {
+		if task.ID == taskID && task.DeletedAt == nil {
+			if weekApproved(file.Timesheets, user, start) {
+				return nil, ErrWeekLocked
+			}
+			entry := models.TimeEntry{
+				ID:              generateID(),
+				TaskID:          taskID,
+				User:            user,
+				StartTime:       start,
+				EndTime:         &end,
+				DurationSeconds: int(end.Sub(start).Seconds()),
+				CreatedAt:       time.Now(),
+			}
+			tasks[i].setEntries(append(task.TimeEntries, entry))
+			if err := s.saveFile(file); err != nil {
+				return nil, err
+			}
+			return &entry, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (s *LocalStore) UpdateTimeEntry(id string, start, end time.Time) (*models.TimeEntry, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+	tasks := file.Tasks
+
+	for i, task := range tasks {
+		for j, entry := range task.TimeEntries {
+			if entry.ID == id {
+				if weekApproved(file.Timesheets, entry.User, entry.StartTime) || weekApproved(file.Timesheets, entry.User, start) {
+					return nil, ErrWeekLocked
+				}
+				entry.StartTime = start
+				entry.EndTime = &end
+				entry.DurationSeconds = int(end.Sub(start).Seconds())
+
+				entries := append([]models.TimeEntry{}, task.TimeEntries...)
+				entries[j] = entry
+				tasks[i].setEntries(entries)
+				if err := s.saveFile(file); err != nil {
+					return nil, err
+				}
+				return &entry, nil
+			}
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (s *LocalStore) SplitTimeEntry(id string, at time.Time) (*models.TimeEntry, *models.TimeEntry, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, nil, err
+	}
+	tasks := file.Tasks
+
+	for i, task := range tasks {
+		for j, entry := range task.TimeEntries {
+			if entry.ID != id {
+				continue
+			}
+			if entry.EndTime == nil || !at.After(entry.StartTime) || !at.Before(*entry.EndTime) {
+				return nil, nil, ErrInvalidSplit
+			}
+			if weekApproved(file.Timesheets, entry.User, entry.StartTime) || weekApproved(file.Timesheets, entry.User, at) {
+				return nil, nil, ErrWeekLocked
+			}
+
+			first, second := splitEntry(entry, at)
+			second.ID = generateID()
+			second.CreatedAt = time.Now()
+
+			entries := append([]models.TimeEntry{}, task.TimeEntries...)
+			entries[j] = first
+			tasks[i].setEntries(append(entries, second))
+			if err := s.saveFile(file); err != nil {
+				return nil, nil, err
+			}
+			return &first, &second, nil
+		}
+	}
+
+	return nil, nil, ErrNotFound
+}
+
+// splitEntry cuts a finished entry at a time inside it. The second part,
+// still without an ID, gets what is left of the duration so the two add
+// up to the whole.
+func splitEntry(entry models.TimeEntry, at time.Time) (models.TimeEntry, models.TimeEntry) {
+	end := *entry.EndTime
+	first := entry
+	first.EndTime = &at
+	first.DurationSeconds = int(at.Sub(entry.StartTime).Seconds())
+
+	second := models.TimeEntry{
+		TaskID:          entry.TaskID,
+		User:            entry.User,
+		StartTime:       at,
+		EndTime:         &end,
+		DurationSeconds: entry.DurationSeconds - first.DurationSeconds,
+		NonBillable:     entry.NonBillable,
+	}
+	return first, second
+}
+
+func (s *LocalStore) DeleteTimeEntry(id string) (*models.TimeEntry, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+	tasks := file.Tasks
+
+	for i, task := range tasks {
+		for j, entry := range task.TimeEntries {
+			if entry.ID == id {
+				if weekApproved(file.Timesheets, entry.User, entry.StartTime) {
+					return nil, ErrWeekLocked
+				}
+				entries := append([]models.TimeEntry{}, task.TimeEntries[:j]...)
+				entries = append(entries, task.TimeEntries[j+1:]...)
+				tasks[i].setEntries(entries)
+				if err := s.saveFile(file); err != nil {
+					return nil, err
+				}
+				return &entry, nil
+			}
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// setEntries replaces a task's time entries, keeps them in start order and
+// recalculates the total and version. Tasks tracked before entries were
+// recorded have time that no entry accounts for; that part is carried over
+// unchanged.
+func (t *localTask) setEntries(entries []models.TimeEntry) {
+	untracked := t.TotalTimeSeconds - sumDurations(t.TimeEntries)
+	if untracked < 0 {
+		untracked = 0
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartTime.Before(entries[j].StartTime)
+	})
+	t.TimeEntries = entries
+	t.TotalTimeSeconds = untracked + sumDurations(entries)
+	t.Version++
+}
+
+func checkVersion(task localTask, expectedVersion int) error {
+	if expectedVersion != 0 && task.Version != expectedVersion {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+func sumDurations(entries []models.TimeEntry) int {
+	total := 0
+	for _, entry := range entries {
+		total += entry.DurationSeconds
+	}
+	return total
+}
+
+// Simple ID generator for local tasks. The random suffix keeps IDs unique
+// when several tasks are created within the same second.
 func generateID() string {
-	return time.Now().Format("20060102150405") + "-" + time.Now().Format("000")
-}
\ No newline at end of file
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return time.Now().Format("20060102150405") + "-" + hex.EncodeToString(suffix)
+}
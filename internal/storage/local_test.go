@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+// date is a time on 2026-09-09, a Wednesday, in UTC.
+func date(hour, minute int) time.Time {
+	return time.Date(2026, 9, 9, hour, minute, 0, 0, time.UTC)
+}
+
+func TestSplitTimeEntry(t *testing.T) {
+	store := NewMemoryStore()
+	task, err := store.CreateTask(models.CreateTaskRequest{Title: "Workshop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := store.AddTimeEntry(task.ID, "alice", date(9, 0), date(12, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.SetEntryBillable(entry.ID, false); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, at := range []time.Time{date(9, 0), date(12, 0), date(13, 0)} {
+		if _, _, err := store.SplitTimeEntry(entry.ID, at); !errors.Is(err, ErrInvalidSplit) {
+			t.Errorf("splitting at %s: got %v, want ErrInvalidSplit", at.Format("15:04"), err)
+		}
+	}
+	if _, _, err := store.SplitTimeEntry("missing", date(10, 0)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("splitting a missing entry: got %v, want ErrNotFound", err)
+	}
+
+	first, second, err := store.SplitTimeEntry(entry.ID, date(10, 30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ID != entry.ID || !first.EndTime.Equal(date(10, 30)) || first.DurationSeconds != 5400 {
+		t.Errorf("first part = %+v", first)
+	}
+	if second.ID == entry.ID || second.User != "alice" || !second.NonBillable ||
+		!second.StartTime.Equal(date(10, 30)) || !second.EndTime.Equal(date(12, 0)) || second.DurationSeconds != 5400 {
+		t.Errorf("second part = %+v", second)
+	}
+
+	entries, err := store.GetTimeEntries(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("%d entries after splitting, want 2", len(entries))
+	}
+	task, err = store.GetTask(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.TotalTimeSeconds != 3*3600 {
+		t.Errorf("total after splitting = %d, want %d", task.TotalTimeSeconds, 3*3600)
+	}
+}
+
+func TestSplitTimeEntryInApprovedWeek(t *testing.T) {
+	store := NewMemoryStore()
+	task, err := store.CreateTask(models.CreateTaskRequest{Title: "Approved"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := store.AddTimeEntry(task.ID, "alice", date(9, 0), date(12, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	week := models.WeekOf(date(9, 0))
+	for _, status := range []string{models.TimesheetSubmitted, models.TimesheetApproved} {
+		if _, err := store.ChangeTimesheet(models.TimesheetChange{User: "alice", Week: week, Status: status, Reviewer: "lee"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, _, err := store.SplitTimeEntry(entry.ID, date(10, 0)); !errors.Is(err, ErrWeekLocked) {
+		t.Fatalf("splitting in an approved week: got %v, want ErrWeekLocked", err)
+	}
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// localBackupCount is how many previous versions of the task file are kept
+// next to it as file.bak.1 (newest) through file.bak.N (oldest).
+const localBackupCount = 5
+
+// lock serialises access to the store, both between goroutines and, through
+// an advisory lock on a sidecar .lock file, between processes. The returned
+// function releases both locks.
+func (s *LocalStore) lock(exclusive bool) (func(), error) {
+	s.mu.Lock()
+	if s.filePath == "" {
+		return s.mu.Unlock, nil
+	}
+
+	lockFile, err := os.OpenFile(s.filePath+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+
+	if err := lockFileHandle(lockFile, exclusive); err != nil {
+		lockFile.Close()
+		s.mu.Unlock()
+		return nil, err
+	}
+
+	return func() {
+		unlockFileHandle(lockFile)
+		lockFile.Close()
+		s.mu.Unlock()
+	}, nil
+}
+
+// writeFile replaces the task file atomically: the new contents are written
+// and synced to a temp file in the same directory, which is then renamed over
+// the old file. A crash leaves either the old or the new file, never half of
+// one. The previous version is rotated into the backups first.
+func (s *LocalStore) writeFile(data []byte) error {
+	dir := filepath.Dir(s.filePath)
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.filePath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	if err := s.rotateBackups(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), s.filePath); err != nil {
+		return err
+	}
+
+	syncDir(dir)
+	return nil
+}
+
+// rotateBackups shifts file.bak.1..N-1 up by one and copies the current file
+// to file.bak.1. The oldest backup falls off the end.
+func (s *LocalStore) rotateBackups() error {
+	current, err := os.ReadFile(s.filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for i := localBackupCount - 1; i >= 1; i-- {
+		err := os.Rename(s.backupPath(i), s.backupPath(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return os.WriteFile(s.backupPath(1), current, 0644)
+}
+
+func (s *LocalStore) backupPath(n int) string {
+	return fmt.Sprintf("%s.bak.%d", s.filePath, n)
+}
+
+// recoverFile checks that the task file parses. If it doesn't, the broken file
+// is set aside and the newest backup that does parse is restored in its place.
+func (s *LocalStore) recoverFile() error {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(s.filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if validTaskFile(data) {
+		return nil
+	}
+
+	corruptPath := fmt.Sprintf("%s.corrupt-%s", s.filePath, time.Now().Format("20060102150405"))
+	if err := os.Rename(s.filePath, corruptPath); err != nil {
+		return err
+	}
+	log.Printf("Task file %s is corrupt, moved it to %s", s.filePath, corruptPath)
+
+	for i := 1; i <= localBackupCount; i++ {
+		backup, err := os.ReadFile(s.backupPath(i))
+		if err != nil || !validTaskFile(backup) {
+			continue
+		}
+
+		if err := s.writeFile(backup); err != nil {
+			return err
+		}
+		log.Printf("Restored %s from %s", s.filePath, s.backupPath(i))
+		return nil
+	}
+
+	return fmt.Errorf("task file %s is corrupt and no usable backup was found", s.filePath)
+}
+
+func validTaskFile(data []byte) bool {
+	if len(data) == 0 {
+		return true
+	}
+
+	_, err := decodeFile(data)
+	return err == nil
+}
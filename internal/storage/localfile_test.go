@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+func TestWritesRotateBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= localBackupCount+2; i++ {
+		if _, err := store.CreateTask(models.CreateTaskRequest{Title: fmt.Sprintf("Task %d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Each backup holds one task fewer than the version after it
+	for i := 1; i <= localBackupCount; i++ {
+		data, err := os.ReadFile(store.backupPath(i))
+		if err != nil {
+			t.Fatalf("backup %d: %v", i, err)
+		}
+		file, err := decodeFile(data)
+		if err != nil {
+			t.Fatalf("backup %d: %v", i, err)
+		}
+		if want := localBackupCount + 2 - i; len(file.Tasks) != want {
+			t.Errorf("backup %d holds %d tasks, want %d", i, len(file.Tasks), want)
+		}
+	}
+	if _, err := os.Stat(store.backupPath(localBackupCount + 1)); !os.IsNotExist(err) {
+		t.Errorf("found a backup past the last one: %v", err)
+	}
+
+	// Nothing is left behind from the temp files the writes went through
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("left %s behind", entry.Name())
+		}
+	}
+}
+
+func TestRecoverFromBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, title := range []string{"Kept", "Lost", "Also lost"} {
+		if _, err := store.CreateTask(models.CreateTaskRequest{Title: title}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A write cut off halfway
+	if err := os.WriteFile(path, []byte(`[{"id": "`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The newest backup is broken too, so the one before it is used
+	if err := os.WriteFile(store.backupPath(1), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks, err := store.GetTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].Title != "Kept" {
+		t.Errorf("recovered %+v, want just the task in the second backup", tasks)
+	}
+
+	corrupt, err := filepath.Glob(path + ".corrupt-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corrupt) != 1 || string(mustRead(t, corrupt[0])) != `[{"id": "` {
+		t.Errorf("broken file wasn't set aside: %v", corrupt)
+	}
+}
+
+func TestRecoverWithoutBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	if err := os.WriteFile(path, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewFileStore(path); err == nil {
+		t.Fatal("opened a corrupt file with no backups")
+	}
+}
+
+func mustRead(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package storage
+
+import "os"
+
+// Advisory file locks are only implemented on unix. Elsewhere the store still
+// serialises access within one process, but not between processes.
+func lockFileHandle(f *os.File, exclusive bool) error {
+	return nil
+}
+
+func unlockFileHandle(f *os.File) error {
+	return nil
+}
+
+func syncDir(dir string) {}
@@ -0,0 +1,294 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the pg_advisory_lock key that keeps two processes from
+// migrating the same database at once.
+const migrationLockID = 7357
+
+// Migration is one numbered schema change with its up and down SQL.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// loadMigrations reads migrations/NNNN_name.up.sql and the matching
+// .down.sql files, sorted by version.
+func loadMigrations() ([]Migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		fileName := entry.Name()
+
+		var direction string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(fileName, "."+direction+".sql")
+		prefix, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected NNNN_name", fileName)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: bad version: %w", fileName, err)
+		}
+
+		body, err := migrationFiles.ReadFile("migrations/" + fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// MigrationStatus lists every known migration and when it was applied.
+func (s *PostgresStore) MigrationStatus() ([]MigrationStatus, error) {
+	ctx := context.Background()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return nil, err
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		status := MigrationStatus{Version: m.Version, Name: m.Name}
+		if at, ok := applied[m.Version]; ok {
+			appliedAt := at
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// MigrateUp applies every pending migration.
+func (s *PostgresStore) MigrateUp() error {
+	return s.MigrateTo(-1)
+}
+
+// MigrateDown rolls back the most recently applied migration.
+func (s *PostgresStore) MigrateDown() error {
+	return s.withMigrationLock(func(ctx context.Context, conn *sql.Conn, migrations []Migration, current int) error {
+		for i := len(migrations) - 1; i >= 0; i-- {
+			if migrations[i].Version == current {
+				return runMigration(ctx, conn, migrations[i], false)
+			}
+		}
+		if current != 0 {
+			return fmt.Errorf("applied migration %d is unknown to this binary", current)
+		}
+		return nil
+	})
+}
+
+// MigrateTo moves the schema up or down until version is the latest applied
+// migration. A negative version means the newest one available.
+func (s *PostgresStore) MigrateTo(version int) error {
+	return s.withMigrationLock(func(ctx context.Context, conn *sql.Conn, migrations []Migration, current int) error {
+		if version < 0 {
+			// Never roll back here, even if the database is ahead of us.
+			version = current
+			if len(migrations) > 0 && migrations[len(migrations)-1].Version > current {
+				version = migrations[len(migrations)-1].Version
+			}
+		} else if version > 0 && !hasMigration(migrations, version) {
+			return fmt.Errorf("unknown migration version %d", version)
+		}
+
+		if version >= current {
+			for _, m := range migrations {
+				if m.Version > current && m.Version <= version {
+					if err := runMigration(ctx, conn, m, true); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}
+
+		for i := len(migrations) - 1; i >= 0; i-- {
+			m := migrations[i]
+			if m.Version <= current && m.Version > version {
+				if err := runMigration(ctx, conn, m, false); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (s *PostgresStore) withMigrationLock(fn func(context.Context, *sql.Conn, []Migration, int) error) error {
+	ctx := context.Background()
+
+	// Advisory locks belong to a session, so everything runs on one connection.
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	var current int
+	err = conn.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current)
+	if err != nil {
+		return err
+	}
+
+	return fn(ctx, conn, migrations, current)
+}
+
+func ensureMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+	)
+	`)
+	return err
+}
+
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// runMigration applies or reverts a single migration in its own transaction.
+func runMigration(ctx context.Context, conn *sql.Conn, m Migration, up bool) error {
+	body := m.Up
+	if !up {
+		if m.Down == "" {
+			return fmt.Errorf("migration %04d_%s cannot be rolled back", m.Version, m.Name)
+		}
+		body = m.Down
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, body); err != nil {
+		return fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", m.Version, m.Name)
+	} else {
+		_, err = tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", m.Version)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func hasMigration(migrations []Migration, version int) bool {
+	for _, m := range migrations {
+		if m.Version == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS time_entries;
+DROP TABLE IF EXISTS tasks;
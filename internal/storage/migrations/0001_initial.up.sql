@@ -0,0 +1,23 @@
+-- Written with IF NOT EXISTS so databases created before migrations existed
+-- can adopt this as their baseline.
+CREATE TABLE IF NOT EXISTS tasks (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	title TEXT NOT NULL,
+	project TEXT DEFAULT '',
+	status TEXT DEFAULT 'todo',
+	is_active BOOLEAN DEFAULT false,
+	start_time TIMESTAMP,
+	total_time_seconds INTEGER DEFAULT 0,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS time_entries (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	task_id UUID REFERENCES tasks(id) ON DELETE CASCADE,
+	start_time TIMESTAMP NOT NULL,
+	end_time TIMESTAMP,
+	duration_seconds INTEGER,
+	created_at TIMESTAMP DEFAULT NOW()
+);
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS total_time_seconds INTEGER DEFAULT 0;
@@ -0,0 +1,4 @@
+-- Trashed tasks would reappear as live ones, so drop them first.
+DELETE FROM tasks WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE tasks DROP COLUMN deleted_at;
@@ -0,0 +1,13 @@
+-- No foreign key on task_id: the history of a task has to outlive it.
+CREATE TABLE audit_events (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	task_id UUID NOT NULL,
+	actor TEXT NOT NULL,
+	action TEXT NOT NULL,
+	before JSONB,
+	after JSONB,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX audit_events_task_id_idx ON audit_events (task_id, created_at);
+CREATE INDEX audit_events_created_at_idx ON audit_events (created_at);
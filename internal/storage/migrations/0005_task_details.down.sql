@@ -0,0 +1,5 @@
+ALTER TABLE tasks DROP COLUMN tags;
+ALTER TABLE tasks DROP COLUMN estimate_seconds;
+ALTER TABLE tasks DROP COLUMN due_date;
+ALTER TABLE tasks DROP COLUMN priority;
+ALTER TABLE tasks DROP COLUMN description;
@@ -0,0 +1,8 @@
+ALTER TABLE tasks ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE tasks ADD COLUMN priority TEXT NOT NULL DEFAULT '';
+ALTER TABLE tasks ADD COLUMN due_date DATE;
+ALTER TABLE tasks ADD COLUMN estimate_seconds INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE tasks ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX tasks_due_date_idx ON tasks (due_date) WHERE due_date IS NOT NULL;
+CREATE INDEX tasks_tags_idx ON tasks USING GIN (tags);
@@ -0,0 +1,4 @@
+-- Purging a parent from the trash turns its subtasks into top-level tasks.
+ALTER TABLE tasks ADD COLUMN parent_id UUID REFERENCES tasks(id) ON DELETE SET NULL;
+
+CREATE INDEX tasks_parent_id_idx ON tasks (parent_id) WHERE parent_id IS NOT NULL;
@@ -0,0 +1,9 @@
+CREATE TABLE task_dependencies (
+	task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+	blocked_by_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (task_id, blocked_by_id),
+	CHECK (task_id <> blocked_by_id)
+);
+
+CREATE INDEX task_dependencies_blocked_by_idx ON task_dependencies (blocked_by_id);
@@ -0,0 +1,2 @@
+ALTER TABLE tasks DROP COLUMN project_id;
+DROP TABLE IF EXISTS projects;
@@ -0,0 +1,29 @@
+CREATE TABLE projects (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	name TEXT NOT NULL,
+	color TEXT NOT NULL DEFAULT '',
+	description TEXT NOT NULL DEFAULT '',
+	archived BOOLEAN NOT NULL DEFAULT false,
+	budget_hours DOUBLE PRECISION,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX projects_name_idx ON projects (LOWER(name));
+
+-- Deleting a project leaves its name on the tasks as free text.
+ALTER TABLE tasks ADD COLUMN project_id UUID REFERENCES projects(id) ON DELETE SET NULL;
+
+CREATE INDEX tasks_project_id_idx ON tasks (project_id) WHERE project_id IS NOT NULL;
+
+-- Turn the free-text projects tasks already have into real ones. Names that
+-- differ only in case or surrounding spaces become one project, named after
+-- the spelling seen first.
+INSERT INTO projects (name, created_at)
+SELECT DISTINCT ON (LOWER(TRIM(project))) TRIM(project), MIN(created_at) OVER (PARTITION BY LOWER(TRIM(project)))
+FROM tasks
+WHERE TRIM(COALESCE(project, '')) <> ''
+ORDER BY LOWER(TRIM(project)), created_at;
+
+UPDATE tasks SET project_id = projects.id, project = projects.name
+FROM projects
+WHERE LOWER(TRIM(tasks.project)) = LOWER(projects.name);
@@ -0,0 +1,11 @@
+ALTER TABLE time_entries DROP COLUMN user_name;
+
+ALTER TABLE tasks ADD COLUMN is_active BOOLEAN DEFAULT false;
+ALTER TABLE tasks ADD COLUMN start_time TIMESTAMP;
+
+-- A task only has room for one timer again; keep the earliest
+UPDATE tasks SET is_active = true, start_time = timers.start_time
+FROM (SELECT task_id, MIN(start_time) AS start_time FROM active_timers GROUP BY task_id) timers
+WHERE tasks.id = timers.task_id;
+
+DROP TABLE IF EXISTS active_timers;
@@ -0,0 +1,18 @@
+-- One running timer per user and task. user_name is the name changes are
+-- recorded under, empty for timers started before timers had users.
+CREATE TABLE active_timers (
+	task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+	user_name TEXT NOT NULL DEFAULT '',
+	start_time TIMESTAMP NOT NULL,
+	PRIMARY KEY (task_id, user_name)
+);
+
+INSERT INTO active_timers (task_id, start_time)
+SELECT id, start_time FROM tasks WHERE is_active AND start_time IS NOT NULL;
+
+ALTER TABLE tasks DROP COLUMN is_active;
+ALTER TABLE tasks DROP COLUMN start_time;
+
+ALTER TABLE time_entries ADD COLUMN user_name TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX time_entries_user_name_idx ON time_entries (user_name);
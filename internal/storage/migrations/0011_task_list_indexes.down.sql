@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS time_entries_task_id_idx;
+DROP INDEX IF EXISTS tasks_project_idx;
+DROP INDEX IF EXISTS tasks_status_idx;
+DROP INDEX IF EXISTS tasks_created_at_id_idx;
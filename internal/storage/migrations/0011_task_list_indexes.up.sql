@@ -0,0 +1,5 @@
+-- Indexes for the filters and default order of GET /api/v1/tasks.
+CREATE INDEX tasks_created_at_id_idx ON tasks (created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX tasks_status_idx ON tasks (status);
+CREATE INDEX tasks_project_idx ON tasks (LOWER(project));
+CREATE INDEX time_entries_task_id_idx ON time_entries (task_id);
@@ -0,0 +1,9 @@
+-- Full-text search over titles, descriptions and project names, weighted
+-- in that order.
+ALTER TABLE tasks ADD COLUMN search tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('english', title), 'A') ||
+	setweight(to_tsvector('english', description), 'B') ||
+	setweight(to_tsvector('english', COALESCE(project, '')), 'C')
+) STORED;
+
+CREATE INDEX tasks_search_idx ON tasks USING GIN (search);
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN is_lead;
+
+DROP TABLE IF EXISTS timesheets;
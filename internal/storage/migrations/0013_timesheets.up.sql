@@ -0,0 +1,16 @@
+-- One row per user and week that was ever submitted; weeks without one are
+-- open. week_start is the Monday of the week.
+CREATE TABLE timesheets (
+	user_name TEXT NOT NULL,
+	week_start DATE NOT NULL,
+	status TEXT NOT NULL,
+	comment TEXT NOT NULL DEFAULT '',
+	submitted_at TIMESTAMP,
+	reviewed_by TEXT NOT NULL DEFAULT '',
+	reviewed_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX timesheets_user_week_idx ON timesheets (LOWER(user_name), week_start);
+CREATE INDEX timesheets_status_idx ON timesheets (status);
+
+ALTER TABLE users ADD COLUMN is_lead BOOLEAN NOT NULL DEFAULT false;
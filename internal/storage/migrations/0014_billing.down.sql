@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS rates;
+ALTER TABLE projects DROP COLUMN client;
+ALTER TABLE time_entries DROP COLUMN non_billable;
@@ -0,0 +1,14 @@
+-- Time entries are billable unless marked otherwise, so existing ones are
+-- too.
+ALTER TABLE time_entries ADD COLUMN non_billable BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE projects ADD COLUMN client TEXT NOT NULL DEFAULT '';
+
+-- Hourly rates for a project, a user, both or neither (the default). An
+-- empty user_name means any user and a NULL project_id any project.
+CREATE TABLE rates (
+	project_id UUID REFERENCES projects(id) ON DELETE CASCADE,
+	user_name TEXT NOT NULL DEFAULT '',
+	hourly_rate NUMERIC(12, 2) NOT NULL
+);
+
+CREATE UNIQUE INDEX rates_project_user_idx ON rates (COALESCE(project_id::text, ''), LOWER(user_name));
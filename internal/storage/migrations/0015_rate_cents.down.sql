@@ -0,0 +1,4 @@
+ALTER TABLE rates ADD COLUMN hourly_rate NUMERIC(12, 2);
+UPDATE rates SET hourly_rate = hourly_rate_cents / 100.0;
+ALTER TABLE rates ALTER COLUMN hourly_rate SET NOT NULL;
+ALTER TABLE rates DROP COLUMN hourly_rate_cents;
@@ -0,0 +1,6 @@
+-- Rates are kept in whole cents so invoice lines add up to their total
+-- exactly.
+ALTER TABLE rates ADD COLUMN hourly_rate_cents BIGINT;
+UPDATE rates SET hourly_rate_cents = ROUND(hourly_rate * 100);
+ALTER TABLE rates ALTER COLUMN hourly_rate_cents SET NOT NULL;
+ALTER TABLE rates DROP COLUMN hourly_rate;
@@ -0,0 +1,9 @@
+ALTER TABLE tasks DROP COLUMN search;
+ALTER TABLE tasks ADD COLUMN search tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('english', title), 'A') ||
+	setweight(to_tsvector('english', description), 'B') ||
+	setweight(to_tsvector('english', COALESCE(project, '')), 'C')
+) STORED;
+
+CREATE INDEX tasks_search_idx ON tasks USING GIN (search);
+DROP FUNCTION task_tags_text(TEXT[]);
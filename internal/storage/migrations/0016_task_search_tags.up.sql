@@ -0,0 +1,15 @@
+-- Tags join project names in the full-text search, as they do in the
+-- local stores' search. array_to_string isn't immutable, which generated
+-- columns need, so it is wrapped in a function that says it is; tags are
+-- plain text, so the result never changes.
+CREATE FUNCTION task_tags_text(tags TEXT[]) RETURNS TEXT
+LANGUAGE sql IMMUTABLE AS $$ SELECT array_to_string(tags, ' ') $$;
+
+ALTER TABLE tasks DROP COLUMN search;
+ALTER TABLE tasks ADD COLUMN search tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('english', title), 'A') ||
+	setweight(to_tsvector('english', description), 'B') ||
+	setweight(to_tsvector('english', COALESCE(project, '') || ' ' || task_tags_text(tags)), 'C')
+) STORED;
+
+CREATE INDEX tasks_search_idx ON tasks USING GIN (search);
@@ -0,0 +1,30 @@
+ALTER TABLE tasks
+	ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC',
+	ALTER COLUMN deleted_at TYPE TIMESTAMP USING deleted_at AT TIME ZONE 'UTC';
+
+ALTER TABLE time_entries
+	ALTER COLUMN start_time TYPE TIMESTAMP USING start_time AT TIME ZONE 'UTC',
+	ALTER COLUMN end_time TYPE TIMESTAMP USING end_time AT TIME ZONE 'UTC',
+	ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+
+ALTER TABLE active_timers
+	ALTER COLUMN start_time TYPE TIMESTAMP USING start_time AT TIME ZONE 'UTC';
+
+ALTER TABLE audit_events
+	ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+
+ALTER TABLE task_dependencies
+	ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+
+ALTER TABLE projects
+	ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+
+ALTER TABLE users
+	ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+
+ALTER TABLE api_tokens
+	ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+
+ALTER TABLE timesheets
+	ALTER COLUMN submitted_at TYPE TIMESTAMP USING submitted_at AT TIME ZONE 'UTC',
+	ALTER COLUMN reviewed_at TYPE TIMESTAMP USING reviewed_at AT TIME ZONE 'UTC';
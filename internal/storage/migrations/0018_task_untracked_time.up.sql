@@ -0,0 +1,8 @@
+-- Time on a task that no entry accounts for, such as that of personal tasks
+-- tracked before entries were recorded and then imported. It stays part of
+-- the total when entries change, as it does in the local stores.
+ALTER TABLE tasks ADD COLUMN untracked_seconds INTEGER NOT NULL DEFAULT 0;
+
+UPDATE tasks SET untracked_seconds = GREATEST(total_time_seconds - (
+	SELECT COALESCE(SUM(duration_seconds), 0) FROM time_entries WHERE task_id = tasks.id
+), 0);
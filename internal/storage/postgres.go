@@ -2,69 +2,66 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
 
 	"github.com/ifrunruhin12/tasktime/internal/models"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type PostgresStore struct {
 	db *sql.DB
 }
 
+// NewPostgresStore connects to Postgres and applies any pending migrations.
 func NewPostgresStore(databaseURL string) (*PostgresStore, error) {
-	db, err := sql.Open("postgres", databaseURL)
+	store, err := OpenPostgresStore(databaseURL)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := db.Ping(); err != nil {
+	if err := store.MigrateUp(); err != nil {
+		store.Close()
 		return nil, err
 	}
 
-	store := &PostgresStore{db: db}
-	if err := store.createTables(); err != nil {
+	return store, nil
+}
+
+// OpenPostgresStore connects to Postgres without touching the schema. The
+// migrate command uses it so it can move the schema in either direction.
+func OpenPostgresStore(databaseURL string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
 		return nil, err
 	}
 
-	return store, nil
-}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
 
-func (s *PostgresStore) createTables() error {
-	query := `
-	CREATE TABLE IF NOT EXISTS tasks (
-		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-		title TEXT NOT NULL,
-		project TEXT DEFAULT '',
-		status TEXT DEFAULT 'todo',
-		is_active BOOLEAN DEFAULT false,
-		start_time TIMESTAMP,
-		total_time_seconds INTEGER DEFAULT 0,
-		created_at TIMESTAMP DEFAULT NOW()
-	);
-
-	CREATE TABLE IF NOT EXISTS time_entries (
-		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-		task_id UUID REFERENCES tasks(id) ON DELETE CASCADE,
-		start_time TIMESTAMP NOT NULL,
-		end_time TIMESTAMP,
-		duration_seconds INTEGER,
-		created_at TIMESTAMP DEFAULT NOW()
-	);
-	
-	-- Add the new column if it doesn't exist (for existing databases)
-	ALTER TABLE tasks ADD COLUMN IF NOT EXISTS total_time_seconds INTEGER DEFAULT 0;
-	`
-	_, err := s.db.Exec(query)
-	return err
+	return &PostgresStore{db: db}, nil
 }
 
+// taskColumns is the column list every task query selects, in the order
+// scanTask expects.
+const taskColumns = `id, title, project, status,
+	COALESCE(total_time_seconds, 0), created_at, version, deleted_at,
+	description, priority, due_date, estimate_seconds, tags,
+	COALESCE(parent_id::text, ''), COALESCE(project_id::text, ''),
+	ARRAY(SELECT blocked_by_id::text FROM task_dependencies WHERE task_id = tasks.id ORDER BY created_at),
+	ARRAY(SELECT user_name FROM active_timers WHERE task_id = tasks.id ORDER BY start_time, user_name),
+	ARRAY(SELECT EXTRACT(EPOCH FROM start_time)::float8 FROM active_timers WHERE task_id = tasks.id ORDER BY start_time, user_name)`
+
 func (s *PostgresStore) GetTasks() ([]models.Task, error) {
 	query := `
-	SELECT id, title, project, status, is_active, 
-	       start_time, 
-	       COALESCE(total_time_seconds, 0) as total_time_seconds,
-	       created_at 
+	SELECT ` + taskColumns + `
 	FROM tasks 
+	WHERE deleted_at IS NULL
 	ORDER BY created_at DESC
 	`
 
@@ -76,106 +73,706 @@ func (s *PostgresStore) GetTasks() ([]models.Task, error) {
 
 	var tasks []models.Task
 	for rows.Next() {
-		var task models.Task
-		err := rows.Scan(
-			&task.ID, &task.Title, &task.Project, &task.Status,
-			&task.IsActive, &task.StartTime, &task.TotalTimeSeconds, &task.CreatedAt,
-		)
+		task, err := scanTask(rows)
 		if err != nil {
-			continue
+			return nil, err
 		}
-		tasks = append(tasks, task)
+		tasks = append(tasks, *task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
+	rollupTimes(tasks)
 
 	return tasks, nil
 }
 
-func (s *PostgresStore) CreateTask(title, project string) (*models.Task, error) {
-	query := `
-	INSERT INTO tasks (title, project) 
-	VALUES ($1, $2) 
-	RETURNING id, title, project, status, is_active, start_time, total_time_seconds, created_at
-	`
+func (s *PostgresStore) GetTask(id string) (*models.Task, error) {
+	task, err := scanTask(s.db.QueryRow(`SELECT `+taskColumns+` FROM tasks WHERE id = $1 AND deleted_at IS NULL`, id))
+	if err != nil {
+		return nil, notFound(err)
+	}
 
-	var task models.Task
-	err := s.db.QueryRow(query, title, project).Scan(
-		&task.ID, &task.Title, &task.Project, &task.Status,
-		&task.IsActive, &task.StartTime, &task.TotalTimeSeconds, &task.CreatedAt,
+	return s.withRollup(task)
+}
+
+// withRollup fills in a task's RollupTimeSeconds from its live subtree.
+func (s *PostgresStore) withRollup(task *models.Task) (*models.Task, error) {
+	err := s.db.QueryRow(`
+	WITH RECURSIVE subtree AS (
+		SELECT id, total_time_seconds FROM tasks WHERE id = $1
+		UNION
+		SELECT t.id, t.total_time_seconds
+		FROM tasks t JOIN subtree ON t.parent_id = subtree.id
+		WHERE t.deleted_at IS NULL
 	)
+	SELECT COALESCE(SUM(total_time_seconds), 0) FROM subtree
+	`, task.ID).Scan(&task.RollupTimeSeconds)
+	if err != nil {
+		return nil, err
+	}
 
-	return &task, err
+	return task, nil
+}
+
+// checkParent makes sure parentID names a live task that isn't id itself or
+// one of its subtasks. An empty parentID is always fine.
+func (s *PostgresStore) checkParent(id, parentID string) error {
+	if parentID == "" {
+		return nil
+	}
+
+	var exists, cycle bool
+	err := s.db.QueryRow(`
+	WITH RECURSIVE ancestors AS (
+		SELECT id, parent_id FROM tasks WHERE id = $1 AND deleted_at IS NULL
+		UNION
+		SELECT t.id, t.parent_id
+		FROM tasks t JOIN ancestors ON t.id = ancestors.parent_id
+	)
+	SELECT COUNT(*) > 0, COALESCE(BOOL_OR(id::text = $2), false) FROM ancestors
+	`, parentID, id).Scan(&exists, &cycle)
+	if err != nil {
+		if errors.Is(notFound(err), ErrNotFound) {
+			return ErrInvalidParent
+		}
+		return err
+	}
+	if !exists || cycle {
+		return ErrInvalidParent
+	}
+	return nil
 }
 
-func (s *PostgresStore) UpdateTaskStatus(id, status string) (*models.Task, error) {
+func (s *PostgresStore) CreateTask(req models.CreateTaskRequest) (*models.Task, error) {
+	if err := s.checkParent("", req.ParentID); err != nil {
+		return nil, err
+	}
+	projectID, project, err := s.resolveProject(req.ProjectID, req.Project)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
-	UPDATE tasks 
-	SET status = $1 
-	WHERE id = $2 
-	RETURNING id, title, project, status, is_active, start_time, total_time_seconds, created_at
-	`
+	INSERT INTO tasks (title, project, description, priority, due_date, estimate_seconds, tags, parent_id, project_id) 
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) 
+	RETURNING ` + taskColumns
 
-	var task models.Task
-	err := s.db.QueryRow(query, status, id).Scan(
-		&task.ID, &task.Title, &task.Project, &task.Status,
-		&task.IsActive, &task.StartTime, &task.TotalTimeSeconds, &task.CreatedAt,
+	return scanTask(s.db.QueryRow(query,
+		req.Title, project, req.Description, req.Priority, nullParam(req.DueDate),
+		req.EstimateSeconds, pq.Array(models.NormalizeTags(req.Tags)), nullParam(req.ParentID),
+		nullParam(projectID),
+	))
+}
+
+func (s *PostgresStore) UpdateTask(id string, changes models.UpdateTaskRequest, expectedVersion int) (*models.Task, error) {
+	var sets []string
+	var args []interface{}
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if changes.Title != nil {
+		set("title", *changes.Title)
+	}
+	if projectID, name, resolve := projectChange(changes); resolve {
+		projectID, project, err := s.resolveProject(projectID, name)
+		if err != nil {
+			return nil, err
+		}
+		set("project", project)
+		set("project_id", nullParam(projectID))
+	} else if changes.ProjectID != nil {
+		set("project_id", nil)
+	}
+	if changes.Status != nil {
+		set("status", *changes.Status)
+	}
+	if changes.Description != nil {
+		set("description", *changes.Description)
+	}
+	if changes.Priority != nil {
+		set("priority", *changes.Priority)
+	}
+	if changes.DueDate != nil {
+		set("due_date", nullParam(*changes.DueDate))
+	}
+	if changes.EstimateSeconds != nil {
+		set("estimate_seconds", *changes.EstimateSeconds)
+	}
+	if changes.Tags != nil {
+		set("tags", pq.Array(models.NormalizeTags(*changes.Tags)))
+	}
+	if changes.ParentID != nil {
+		if err := s.checkParent(id, *changes.ParentID); err != nil {
+			return nil, err
+		}
+		set("parent_id", nullParam(*changes.ParentID))
+	}
+
+	if len(sets) == 0 {
+		return s.GetTask(id)
+	}
+
+	return s.updateTask(id, expectedVersion, strings.Join(sets, ", "), args...)
+}
+
+func (s *PostgresStore) UpdateTaskStatus(id, status string, expectedVersion int) (*models.Task, error) {
+	return s.updateTask(id, expectedVersion, "status = $1", status)
+}
+
+func (s *PostgresStore) DeleteTask(id string, expectedVersion int) error {
+	tx, err := s.lockTask(id, expectedVersion)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkTaskEntriesOpen(tx, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE tasks SET deleted_at = NOW(), version = version + 1 WHERE id = $1", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgresStore) GetDeletedTasks() ([]models.Task, error) {
+	rows, err := s.db.Query(`
+	SELECT ` + taskColumns + `
+	FROM tasks
+	WHERE deleted_at IS NOT NULL
+	ORDER BY deleted_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *task)
+	}
+
+	return tasks, rows.Err()
+}
+
+func (s *PostgresStore) RestoreTask(id string) (*models.Task, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT true FROM tasks WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE", id).Scan(&exists); err != nil {
+		return nil, notFound(err)
+	}
+	if err := checkTaskEntriesOpen(tx, id); err != nil {
+		return nil, err
+	}
+
+	task, err := scanTask(tx.QueryRow(`
+	UPDATE tasks
+	SET deleted_at = NULL, version = version + 1
+	WHERE id = $1
+	RETURNING `+taskColumns, id))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.withRollup(task)
+}
+
+// PurgeDeletedTasks leaves tasks with time entries in approved weeks in the
+// trash, since deleting them would delete the entries too.
+func (s *PostgresStore) PurgeDeletedTasks(deletedBefore time.Time) (int, error) {
+	rows, err := s.db.Query("SELECT id FROM tasks WHERE deleted_at < $1", deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, id := range ids {
+		deleted, err := s.purgeTask(id, deletedBefore)
+		if err != nil {
+			return purged, err
+		}
+		if deleted {
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// purgeTask deletes a task that is still in the trash since before
+// deletedBefore, unless it has time entries in an approved week.
+func (s *PostgresStore) purgeTask(id string, deletedBefore time.Time) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRow("SELECT true FROM tasks WHERE id = $1 AND deleted_at < $2 FOR UPDATE", id, deletedBefore).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := checkTaskEntriesOpen(tx, id); errors.Is(err, ErrWeekLocked) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM tasks WHERE id = $1", id); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (s *PostgresStore) StartTimer(id, user string, expectedVersion int) (*models.Task, error) {
+	tx, err := s.lockTask(id, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+	INSERT INTO active_timers (task_id, user_name, start_time)
+	VALUES ($1, $2, NOW())
+	ON CONFLICT DO NOTHING
+	`, id, user)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		// Already running; starting it again must not move its start
+		tx.Rollback()
+		return s.GetTask(id)
+	}
+
+	task, err := scanTask(tx.QueryRow(`
+	UPDATE tasks SET version = version + 1
+	WHERE id = $1
+	RETURNING `+taskColumns, id))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.withRollup(task)
+}
+
+func (s *PostgresStore) StopTimer(id, user string, expectedVersion int) (*models.Task, error) {
+	// One transaction, so NOW() is the same instant for the entry and the
+	// total, and the version check covers both.
+	tx, err := s.lockTask(id, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Stop the user's own timer, or failing that one nobody owns
+	var duration int
+	err = tx.QueryRow(`
+	WITH stopped AS (
+		DELETE FROM active_timers
+		WHERE task_id = $1 AND user_name = (
+			SELECT user_name FROM active_timers
+			WHERE task_id = $1 AND user_name IN ($2, '')
+			ORDER BY user_name = $2 DESC
+			LIMIT 1
+		)
+		RETURNING task_id, user_name, start_time
 	)
+	INSERT INTO time_entries (task_id, user_name, start_time, end_time, duration_seconds)
+	SELECT task_id, user_name, start_time, NOW(), EXTRACT(EPOCH FROM (NOW() - start_time))::INTEGER
+	FROM stopped
+	RETURNING duration_seconds
+	`, id, user).Scan(&duration)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Nothing of theirs was running
+		tx.Rollback()
+		return s.GetTask(id)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	return &task, err
+	task, err := scanTask(tx.QueryRow(`
+	UPDATE tasks
+	SET total_time_seconds = total_time_seconds + $2,
+	    version = version + 1
+	WHERE id = $1
+	RETURNING `+taskColumns, id, duration))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.withRollup(task)
 }
 
-func (s *PostgresStore) DeleteTask(id string) error {
-	_, err := s.db.Exec("DELETE FROM tasks WHERE id = $1", id)
-	return err
+// lockTask starts a transaction holding a row lock on a live task, after
+// checking it is at expectedVersion unless that is zero.
+func (s *PostgresStore) lockTask(id string, expectedVersion int) (*sql.Tx, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	var version int
+	err = tx.QueryRow("SELECT version FROM tasks WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", id).Scan(&version)
+	if err != nil {
+		tx.Rollback()
+		return nil, notFound(err)
+	}
+	if expectedVersion != 0 && version != expectedVersion {
+		tx.Rollback()
+		return nil, ErrVersionConflict
+	}
+
+	return tx, nil
 }
 
-func (s *PostgresStore) StartTimer(id string) (*models.Task, error) {
-	query := `
-	UPDATE tasks 
-	SET is_active = true, start_time = NOW() 
-	WHERE id = $1 
-	RETURNING id, title, project, status, is_active, start_time, total_time_seconds, created_at
-	`
+// updateTask applies set to one task and bumps its version. A non-zero
+// expectedVersion makes the update conditional on the task still being at
+// that version. Placeholders in set start at $1 and match args.
+func (s *PostgresStore) updateTask(id string, expectedVersion int, set string, args ...interface{}) (*models.Task, error) {
+	args = append(args, id, expectedVersion)
+	query := fmt.Sprintf(`
+	UPDATE tasks
+	SET %s, version = version + 1
+	WHERE id = $%d AND deleted_at IS NULL AND ($%d = 0 OR version = $%d)
+	RETURNING `+taskColumns, set, len(args)-1, len(args), len(args))
+
+	task, err := scanTask(s.db.QueryRow(query, args...))
+	if err != nil {
+		return nil, s.conflictOrNotFound(id, err)
+	}
+
+	return s.withRollup(task)
+}
+
+// conflictOrNotFound explains why a conditional write matched no row: the
+// task is either gone or at a different version.
+func (s *PostgresStore) conflictOrNotFound(id string, err error) error {
+	if !errors.Is(err, sql.ErrNoRows) {
+		return notFound(err)
+	}
+
+	var exists bool
+	if err := s.db.QueryRow("SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1 AND deleted_at IS NULL)", id).Scan(&exists); err != nil {
+		return notFound(err)
+	}
+	if exists {
+		return ErrVersionConflict
+	}
+	return ErrNotFound
+}
 
+func scanTask(row rowScanner) (*models.Task, error) {
 	var task models.Task
-	err := s.db.QueryRow(query, id).Scan(
+	var dueDate *time.Time
+	var timerUsers []string
+	var timerStarts []float64
+	err := row.Scan(
 		&task.ID, &task.Title, &task.Project, &task.Status,
-		&task.IsActive, &task.StartTime, &task.TotalTimeSeconds, &task.CreatedAt,
+		&task.TotalTimeSeconds, &task.CreatedAt,
+		&task.Version, &task.DeletedAt,
+		&task.Description, &task.Priority, &dueDate, &task.EstimateSeconds, pq.Array(&task.Tags),
+		&task.ParentID, &task.ProjectID, pq.Array(&task.BlockedBy),
+		pq.Array(&timerUsers), pq.Array(&timerStarts),
 	)
+	if err != nil {
+		return nil, err
+	}
+	if dueDate != nil {
+		task.DueDate = dueDate.Format(models.DueDateLayout)
+	}
 
-	return &task, err
+	var timers []models.RunningTimer
+	for i := range timerUsers {
+		seconds, fraction := math.Modf(timerStarts[i])
+		timers = append(timers, models.RunningTimer{
+			User:      timerUsers[i],
+			StartTime: time.Unix(int64(seconds), int64(fraction*1e9)).UTC(),
+		})
+	}
+	task.SetTimers(timers)
+
+	return &task, nil
+}
+
+// nullParam stores an empty string, such as a missing due date or parent,
+// as NULL.
+func nullParam(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
 }
 
-func (s *PostgresStore) StopTimer(id string) (*models.Task, error) {
-	// First, record the time entry and update total time
-	_, err := s.db.Exec(`
-		INSERT INTO time_entries (task_id, start_time, end_time, duration_seconds)
-		SELECT id, start_time, NOW(), 
-		       EXTRACT(EPOCH FROM (NOW() - start_time))::INTEGER
-		FROM tasks 
-		WHERE id = $1 AND is_active = true
-	`, id)
+const timeEntryColumns = `id, task_id, start_time, end_time, COALESCE(duration_seconds, 0), created_at, user_name, non_billable`
 
+func (s *PostgresStore) GetTimeEntries(taskID string) ([]models.TimeEntry, error) {
+	if _, err := s.GetTask(taskID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+	SELECT `+timeEntryColumns+`
+	FROM time_entries
+	WHERE task_id = $1
+	ORDER BY start_time
+	`, taskID)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	// Then update the task with accumulated time
-	query := `
-	UPDATE tasks 
-	SET is_active = false, 
-	    start_time = NULL,
-	    total_time_seconds = total_time_seconds + COALESCE(EXTRACT(EPOCH FROM (NOW() - start_time))::INTEGER, 0)
-	WHERE id = $1 
-	RETURNING id, title, project, status, is_active, start_time, total_time_seconds, created_at
-	`
+	entries := []models.TimeEntry{}
+	for rows.Next() {
+		entry, err := scanTimeEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
 
-	var task models.Task
-	err = s.db.QueryRow(query, id).Scan(
-		&task.ID, &task.Title, &task.Project, &task.Status,
-		&task.IsActive, &task.StartTime, &task.TotalTimeSeconds, &task.CreatedAt,
+	return entries, rows.Err()
+}
+
+func (s *PostgresStore) GetTimeEntry(id string) (*models.TimeEntry, error) {
+	entry, err := scanTimeEntry(s.db.QueryRow(`SELECT `+timeEntryColumns+` FROM time_entries WHERE id = $1`, id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return entry, nil
+}
+
+func (s *PostgresStore) AddTimeEntry(taskID, user string, start, end time.Time) (*models.TimeEntry, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1 AND deleted_at IS NULL)", taskID).Scan(&exists)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+	if err := checkWeekOpen(tx, user, start); err != nil {
+		return nil, err
+	}
+
+	entry, err := scanTimeEntry(tx.QueryRow(`
+	INSERT INTO time_entries (task_id, user_name, start_time, end_time, duration_seconds)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING `+timeEntryColumns,
+		taskID, user, start, end, int(end.Sub(start).Seconds()),
+	))
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	if err := recalculateTotal(tx, taskID); err != nil {
+		return nil, err
+	}
+
+	return entry, tx.Commit()
+}
+
+func (s *PostgresStore) UpdateTimeEntry(id string, start, end time.Time) (*models.TimeEntry, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkEntryOpen(tx, id); err != nil {
+		return nil, err
+	}
+
+	entry, err := scanTimeEntry(tx.QueryRow(`
+	UPDATE time_entries
+	SET start_time = $1, end_time = $2, duration_seconds = $3
+	WHERE id = $4
+	RETURNING `+timeEntryColumns,
+		start, end, int(end.Sub(start).Seconds()), id,
+	))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if err := checkWeekOpen(tx, entry.User, start); err != nil {
+		return nil, err
+	}
+
+	if err := recalculateTotal(tx, entry.TaskID); err != nil {
+		return nil, err
+	}
+
+	return entry, tx.Commit()
+}
+
+func (s *PostgresStore) SplitTimeEntry(id string, at time.Time) (*models.TimeEntry, *models.TimeEntry, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	entry, err := scanTimeEntry(tx.QueryRow(`SELECT `+timeEntryColumns+` FROM time_entries WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		return nil, nil, notFound(err)
+	}
+	if entry.EndTime == nil || !at.After(entry.StartTime) || !at.Before(*entry.EndTime) {
+		return nil, nil, ErrInvalidSplit
+	}
+	if err := checkWeekOpen(tx, entry.User, entry.StartTime); err != nil {
+		return nil, nil, err
+	}
+	if err := checkWeekOpen(tx, entry.User, at); err != nil {
+		return nil, nil, err
+	}
+
+	first, second := splitEntry(*entry, at)
+	updated, err := scanTimeEntry(tx.QueryRow(`
+	UPDATE time_entries
+	SET end_time = $1, duration_seconds = $2
+	WHERE id = $3
+	RETURNING `+timeEntryColumns,
+		first.EndTime, first.DurationSeconds, id,
+	))
+	if err != nil {
+		return nil, nil, err
+	}
+	created, err := scanTimeEntry(tx.QueryRow(`
+	INSERT INTO time_entries (task_id, user_name, start_time, end_time, duration_seconds, non_billable)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING `+timeEntryColumns,
+		second.TaskID, second.User, second.StartTime, second.EndTime, second.DurationSeconds, second.NonBillable,
+	))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := recalculateTotal(tx, entry.TaskID); err != nil {
+		return nil, nil, err
+	}
+
+	return updated, created, tx.Commit()
+}
+
+func (s *PostgresStore) DeleteTimeEntry(id string) (*models.TimeEntry, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkEntryOpen(tx, id); err != nil {
+		return nil, err
+	}
+
+	entry, err := scanTimeEntry(tx.QueryRow(`
+	DELETE FROM time_entries
+	WHERE id = $1
+	RETURNING `+timeEntryColumns, id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	if err := recalculateTotal(tx, entry.TaskID); err != nil {
+		return nil, err
+	}
+
+	return entry, tx.Commit()
+}
+
+// recalculateTotal sets a task's total time to the sum of its entries plus
+// its untracked time, and bumps its version.
+func recalculateTotal(tx *sql.Tx, taskID string) error {
+	_, err := tx.Exec(`
+	UPDATE tasks
+	SET total_time_seconds = untracked_seconds + (
+		SELECT COALESCE(SUM(duration_seconds), 0) FROM time_entries WHERE task_id = $1
+	),
+	    version = version + 1
+	WHERE id = $1
+	`, taskID)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTimeEntry(row rowScanner) (*models.TimeEntry, error) {
+	var entry models.TimeEntry
+	err := row.Scan(
+		&entry.ID, &entry.TaskID, &entry.StartTime,
+		&entry.EndTime, &entry.DurationSeconds, &entry.CreatedAt, &entry.User,
+		&entry.NonBillable,
 	)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// notFound maps "no such row" errors to ErrNotFound. Malformed IDs count as
+// missing too, since they can never match a UUID column.
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
 
-	return &task, err
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "22P02", "23503": // invalid_text_representation, foreign_key_violation
+			return ErrNotFound
+		}
+	}
+
+	return err
 }
 
 func (s *PostgresStore) Close() error {
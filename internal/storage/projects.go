@@ -0,0 +1,437 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+	"github.com/lib/pq"
+)
+
+const projectColumns = `id, name, color, description, archived, COALESCE(budget_hours, 0), client, created_at`
+
+func (s *PostgresStore) GetProjects(includeArchived bool) ([]models.Project, error) {
+	rows, err := s.db.Query(`
+	SELECT `+projectColumns+`
+	FROM projects
+	WHERE $1 OR NOT archived
+	ORDER BY LOWER(name)
+	`, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []models.Project{}
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, *project)
+	}
+
+	return projects, rows.Err()
+}
+
+func (s *PostgresStore) GetProject(id string) (*models.Project, error) {
+	project, err := scanProject(s.db.QueryRow(`SELECT `+projectColumns+` FROM projects WHERE id = $1`, id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return project, nil
+}
+
+func (s *PostgresStore) CreateProject(req models.CreateProjectRequest) (*models.Project, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	project, err := scanProject(tx.QueryRow(`
+	INSERT INTO projects (name, color, description, budget_hours, client)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING `+projectColumns,
+		strings.TrimSpace(req.Name), req.Color, req.Description, nullBudget(req.BudgetHours), strings.TrimSpace(req.Client),
+	))
+	if err != nil {
+		return nil, projectError(err)
+	}
+
+	_, err = tx.Exec(`
+	UPDATE tasks SET project_id = $1, project = $2, version = version + 1
+	WHERE project_id IS NULL AND LOWER(TRIM(project)) = LOWER($2)
+	`, project.ID, project.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+func (s *PostgresStore) UpdateProject(id string, changes models.UpdateProjectRequest) (*models.Project, error) {
+	var sets []string
+	var args []interface{}
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if changes.Name != nil {
+		set("name", strings.TrimSpace(*changes.Name))
+	}
+	if changes.Color != nil {
+		set("color", *changes.Color)
+	}
+	if changes.Description != nil {
+		set("description", *changes.Description)
+	}
+	if changes.Archived != nil {
+		set("archived", *changes.Archived)
+	}
+	if changes.BudgetHours != nil {
+		set("budget_hours", nullBudget(*changes.BudgetHours))
+	}
+	if changes.Client != nil {
+		set("client", strings.TrimSpace(*changes.Client))
+	}
+
+	if len(sets) == 0 {
+		return s.GetProject(id)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	args = append(args, id)
+	project, err := scanProject(tx.QueryRow(fmt.Sprintf(`
+	UPDATE projects SET %s WHERE id = $%d
+	RETURNING `+projectColumns, strings.Join(sets, ", "), len(args)), args...))
+	if err != nil {
+		return nil, projectError(err)
+	}
+
+	// Tasks carry the project's name, so a rename changes them too
+	_, err = tx.Exec(`
+	UPDATE tasks SET project = $1, version = version + 1
+	WHERE project_id = $2 AND project <> $1
+	`, project.Name, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+func (s *PostgresStore) DeleteProject(id string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The foreign key would unlink the tasks as well, but without bumping
+	// their versions
+	_, err = tx.Exec(`
+	UPDATE tasks SET project_id = NULL, version = version + 1 WHERE project_id = $1
+	`, id)
+	if err != nil {
+		return notFound(err)
+	}
+
+	result, err := tx.Exec("DELETE FROM projects WHERE id = $1", id)
+	if err != nil {
+		return notFound(err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+
+	return tx.Commit()
+}
+
+// resolveProject works out the project a task belongs to. A projectID has to
+// name an existing project, whose name the task then carries. Without one, a
+// name matching a project regardless of case links the task to it, and any
+// other name stays free text.
+func (s *PostgresStore) resolveProject(projectID, name string) (string, string, error) {
+	if projectID != "" {
+		err := s.db.QueryRow("SELECT name FROM projects WHERE id = $1", projectID).Scan(&name)
+		if err != nil {
+			if errors.Is(notFound(err), ErrNotFound) {
+				return "", "", ErrInvalidProject
+			}
+			return "", "", err
+		}
+		return projectID, name, nil
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", "", nil
+	}
+
+	err := s.db.QueryRow("SELECT id, name FROM projects WHERE LOWER(name) = LOWER($1)", name).Scan(&projectID, &name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", name, nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return projectID, name, nil
+}
+
+func scanProject(row rowScanner) (*models.Project, error) {
+	var project models.Project
+	err := row.Scan(
+		&project.ID, &project.Name, &project.Color, &project.Description,
+		&project.Archived, &project.BudgetHours, &project.Client, &project.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// nullBudget stores a zero budget, meaning none, as NULL.
+func nullBudget(hours float64) interface{} {
+	if hours == 0 {
+		return nil
+	}
+	return hours
+}
+
+// projectError maps a clash with the unique index on project names to
+// ErrDuplicateProject.
+func projectError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" { // unique_violation
+		return ErrDuplicateProject
+	}
+	return notFound(err)
+}
+
+// projectChange reports whether an update moves a task to another project
+// and, if so, the ID or name to resolve. Clearing ProjectID on its own only
+// unlinks the task and leaves its project name as free text.
+func projectChange(changes models.UpdateTaskRequest) (projectID, name string, resolve bool) {
+	if changes.ProjectID != nil && *changes.ProjectID != "" {
+		return *changes.ProjectID, "", true
+	}
+	if changes.Project != nil {
+		return "", *changes.Project, true
+	}
+	return "", "", false
+}
+
+func (s *LocalStore) GetProjects(includeArchived bool) ([]models.Project, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	projects := []models.Project{}
+	for _, project := range file.Projects {
+		if includeArchived || !project.Archived {
+			projects = append(projects, project)
+		}
+	}
+	sort.SliceStable(projects, func(i, j int) bool {
+		return strings.ToLower(projects[i].Name) < strings.ToLower(projects[j].Name)
+	})
+
+	return projects, nil
+}
+
+func (s *LocalStore) GetProject(id string) (*models.Project, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, project := range file.Projects {
+		if project.ID == id {
+			return &project, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (s *LocalStore) CreateProject(req models.CreateProjectRequest) (*models.Project, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if projectNamed(file.Projects, name, "") {
+		return nil, ErrDuplicateProject
+	}
+
+	project := models.Project{
+		ID:          generateID(),
+		Name:        name,
+		Color:       req.Color,
+		Description: req.Description,
+		BudgetHours: req.BudgetHours,
+		Client:      strings.TrimSpace(req.Client),
+		CreatedAt:   time.Now(),
+	}
+	file.Projects = append(file.Projects, project)
+	for i, task := range file.Tasks {
+		if task.ProjectID == "" && strings.EqualFold(strings.TrimSpace(task.Project), name) {
+			file.Tasks[i].ProjectID = project.ID
+			file.Tasks[i].Project = name
+			file.Tasks[i].Version++
+		}
+	}
+	if err := s.saveFile(file); err != nil {
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+func (s *LocalStore) UpdateProject(id string, changes models.UpdateProjectRequest) (*models.Project, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range file.Projects {
+		if file.Projects[i].ID != id {
+			continue
+		}
+
+		project := file.Projects[i]
+		changes.Apply(&project)
+		if projectNamed(file.Projects, project.Name, id) {
+			return nil, ErrDuplicateProject
+		}
+		file.Projects[i] = project
+
+		// Tasks carry the project's name, so a rename changes them too
+		for j, task := range file.Tasks {
+			if task.ProjectID == id && task.Project != project.Name {
+				file.Tasks[j].Project = project.Name
+				file.Tasks[j].Version++
+			}
+		}
+
+		if err := s.saveFile(file); err != nil {
+			return nil, err
+		}
+		return &project, nil
+	}
+
+	return nil, ErrNotFound
+}
+
+func (s *LocalStore) DeleteProject(id string) error {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return err
+	}
+
+	for i, project := range file.Projects {
+		if project.ID != id {
+			continue
+		}
+
+		file.Projects = append(file.Projects[:i], file.Projects[i+1:]...)
+		for j, task := range file.Tasks {
+			if task.ProjectID == id {
+				file.Tasks[j].ProjectID = ""
+				file.Tasks[j].Version++
+			}
+		}
+		rates := file.Rates[:0]
+		for _, rate := range file.Rates {
+			if rate.ProjectID != id {
+				rates = append(rates, rate)
+			}
+		}
+		file.Rates = rates
+		return s.saveFile(file)
+	}
+
+	return ErrNotFound
+}
+
+// findProject is resolveProject for the projects in a local file.
+func findProject(projects []models.Project, projectID, name string) (string, string, error) {
+	if projectID != "" {
+		for _, project := range projects {
+			if project.ID == projectID {
+				return project.ID, project.Name, nil
+			}
+		}
+		return "", "", ErrInvalidProject
+	}
+
+	name = strings.TrimSpace(name)
+	for _, project := range projects {
+		if name != "" && strings.EqualFold(project.Name, name) {
+			return project.ID, project.Name, nil
+		}
+	}
+	return "", name, nil
+}
+
+// projectNamed reports whether a project other than exceptID already has
+// name, ignoring case.
+func projectNamed(projects []models.Project, name, exceptID string) bool {
+	for _, project := range projects {
+		if project.ID != exceptID && strings.EqualFold(project.Name, name) {
+			return true
+		}
+	}
+	return false
+}
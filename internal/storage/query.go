@@ -0,0 +1,373 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+	"github.com/lib/pq"
+)
+
+// taskCursor marks where a page of tasks ended: the sort key and ID of its
+// last task. Cursors only make sense for the sort order they were made for.
+type taskCursor struct {
+	Sort string `json:"s"`
+	Key  string `json:"k"`
+	ID   string `json:"id"`
+}
+
+func (c taskCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reads a query's cursor, which is nil for the first page.
+// valid, if not nil, vets the cursor's key and ID for the store.
+func decodeCursor(query models.TaskQuery, valid func(taskCursor) bool) (*taskCursor, error) {
+	if query.Cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(query.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor taskCursor
+	if json.Unmarshal(data, &cursor) != nil || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	if field, descending := query.SortField(); cursor.Sort != sortName(field, descending) {
+		return nil, ErrInvalidCursor
+	}
+	if valid != nil && !valid(cursor) {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+func sortName(field string, descending bool) string {
+	if descending {
+		return "-" + field
+	}
+	return field
+}
+
+// pgSortKeys are the expressions Postgres sorts tasks by for each sort
+// field, and the type a cursor's key is cast back to.
+var pgSortKeys = map[string]struct{ expr, cast string }{
+	"created_at": {"created_at", "timestamptz"},
+	"title":      {"LOWER(title)", "text"},
+	"due_date":   {"COALESCE(due_date, DATE '9999-12-31')", "date"}, // Tasks without a due date go last
+	"priority":   {priorityRankSQL(), "int"},
+}
+
+// pgCursorValid reports whether Postgres can take a cursor's ID as a UUID
+// and its key as the sort field's type, so a doctored cursor is
+// ErrInvalidCursor rather than a failed cast.
+func pgCursorValid(field string) func(taskCursor) bool {
+	return func(cursor taskCursor) bool {
+		return isUUID(cursor.ID) && castable(cursor.Key, pgSortKeys[field].cast)
+	}
+}
+
+// isUUID reports whether id is a UUID as Postgres writes them.
+func isUUID(id string) bool {
+	if len(id) != 36 {
+		return false
+	}
+	for i, r := range id {
+		switch i {
+		case 8, 13, 18, 23:
+			if r != '-' {
+				return false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// castable reports whether a sort key as Postgres wrote it as text can be
+// cast back to its type.
+func castable(key, cast string) bool {
+	switch cast {
+	case "timestamptz":
+		for _, layout := range []string{"2006-01-02 15:04:05.999999999-07", "2006-01-02 15:04:05.999999999-07:00"} {
+			if _, err := time.Parse(layout, key); err == nil {
+				return true
+			}
+		}
+		return false
+	case "date":
+		_, err := time.Parse(models.DueDateLayout, key)
+		return err == nil
+	case "int":
+		_, err := strconv.Atoi(key)
+		return err == nil
+	default:
+		return true
+	}
+}
+
+// priorityRankSQL is models.PriorityRank in SQL.
+func priorityRankSQL() string {
+	expr := "CASE priority"
+	for i, priority := range models.Priorities {
+		expr += fmt.Sprintf(" WHEN '%s' THEN %d", priority, i+1)
+	}
+	return expr + " ELSE 0 END"
+}
+
+func (s *PostgresStore) FindTasks(query models.TaskQuery) ([]models.Task, string, error) {
+	field, descending := query.SortField()
+	cursor, err := decodeCursor(query, pgCursorValid(field))
+	if err != nil {
+		return nil, "", err
+	}
+
+	var where []string
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where = append(where, "deleted_at IS NULL")
+	if query.ProjectID != "" {
+		// Compared through projects so a malformed ID matches nothing
+		where = append(where, "project_id = (SELECT id FROM projects WHERE id::text = "+arg(query.ProjectID)+")")
+	}
+	if query.Project != "" {
+		where = append(where, "LOWER(project) = LOWER("+arg(query.Project)+")")
+	}
+	if len(query.Statuses) > 0 {
+		where = append(where, "status = ANY("+arg(pq.Array(query.Statuses))+")")
+	}
+	if query.Assignee != "" {
+		user := arg(query.Assignee)
+		where = append(where, `(EXISTS (SELECT 1 FROM active_timers WHERE task_id = tasks.id AND LOWER(user_name) = LOWER(`+user+`))
+		OR EXISTS (SELECT 1 FROM time_entries WHERE task_id = tasks.id AND LOWER(user_name) = LOWER(`+user+`)))`)
+	}
+	if len(query.Tags) > 0 {
+		where = append(where, "tags @> "+arg(pq.Array(query.Tags)))
+	}
+	if query.DueFrom != "" {
+		where = append(where, "due_date >= "+arg(query.DueFrom)+"::date")
+	}
+	if query.DueTo != "" {
+		where = append(where, "due_date <= "+arg(query.DueTo)+"::date")
+	}
+	// Creation days are UTC days, as in models.TaskQuery.Matches
+	if query.CreatedFrom != "" {
+		where = append(where, "created_at >= ("+arg(query.CreatedFrom)+"::date)::timestamp AT TIME ZONE 'UTC'")
+	}
+	if query.CreatedTo != "" {
+		where = append(where, "created_at < ("+arg(query.CreatedTo)+"::date + 1)::timestamp AT TIME ZONE 'UTC'")
+	}
+	for _, word := range query.SearchWords() {
+		pattern := "%" + likeEscaper.Replace(word) + "%"
+		where = append(where, "(title || ' ' || description || ' ' || project) ILIKE "+arg(pattern))
+	}
+
+	key := pgSortKeys[field]
+	order, after := "ASC", ">"
+	if descending {
+		order, after = "DESC", "<"
+	}
+	if cursor != nil {
+		where = append(where, fmt.Sprintf("(%s, id) %s (%s::%s, %s::uuid)",
+			key.expr, after, arg(cursor.Key), key.cast, arg(cursor.ID)))
+	}
+
+	sqlQuery := `SELECT ` + taskColumns + `, (` + key.expr + `)::text
+	FROM tasks
+	WHERE ` + strings.Join(where, " AND ") + `
+	ORDER BY ` + key.expr + ` ` + order + `, id ` + order
+	if query.Limit > 0 {
+		// One more than asked for tells whether there is a next page
+		sqlQuery += " LIMIT " + arg(query.Limit+1)
+	}
+
+	rows, err := s.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	var keys []string
+	for rows.Next() {
+		var sortKey string
+		task, err := scanTask(extraColumns{rows, []interface{}{&sortKey}})
+		if err != nil {
+			return nil, "", err
+		}
+		tasks = append(tasks, *task)
+		keys = append(keys, sortKey)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if query.Limit > 0 && len(tasks) > query.Limit {
+		tasks = tasks[:query.Limit]
+		last := tasks[len(tasks)-1]
+		next = taskCursor{Sort: sortName(field, descending), Key: keys[len(tasks)-1], ID: last.ID}.encode()
+	}
+
+	if err := s.rollupPage(tasks); err != nil {
+		return nil, "", err
+	}
+	return tasks, next, nil
+}
+
+// extraColumns scans a task row that has more columns after taskColumns
+// into extra.
+type extraColumns struct {
+	rows  *sql.Rows
+	extra []interface{}
+}
+
+func (r extraColumns) Scan(dest ...interface{}) error {
+	return r.rows.Scan(append(dest, r.extra...)...)
+}
+
+// likeEscaper escapes the wildcards of ILIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// rollupPage fills in RollupTimeSeconds for a page of tasks, whose
+// subtasks may not be on it.
+func (s *PostgresStore) rollupPage(tasks []models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+
+	rows, err := s.db.Query(`
+	WITH RECURSIVE subtree AS (
+		SELECT id AS root, id, total_time_seconds FROM tasks WHERE id = ANY($1::uuid[])
+		UNION
+		SELECT subtree.root, t.id, t.total_time_seconds
+		FROM tasks t JOIN subtree ON t.parent_id = subtree.id
+		WHERE t.deleted_at IS NULL
+	)
+	SELECT root::text, COALESCE(SUM(total_time_seconds), 0) FROM subtree GROUP BY root
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	rollups := make(map[string]int, len(tasks))
+	for rows.Next() {
+		var id string
+		var seconds int
+		if err := rows.Scan(&id, &seconds); err != nil {
+			return err
+		}
+		rollups[id] = seconds
+	}
+	for i := range tasks {
+		tasks[i].RollupTimeSeconds = rollups[tasks[i].ID]
+	}
+	return rows.Err()
+}
+
+func (s *LocalStore) FindTasks(query models.TaskQuery) ([]models.Task, string, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, "", err
+	}
+	defer unlock()
+
+	stored, err := s.loadTasks()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return findTasks(stored, query)
+}
+
+// localSortKey is what the local store sorts tasks by for a sort field.
+// Keys compare as plain strings.
+func localSortKey(field string, task models.Task) string {
+	switch field {
+	case "title":
+		return strings.ToLower(task.Title)
+	case "due_date":
+		if task.DueDate == "" {
+			return "9999-12-31" // Tasks without a due date go last
+		}
+		return task.DueDate
+	case "priority":
+		return strconv.Itoa(models.PriorityRank(task.Priority))
+	default:
+		return task.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000000")
+	}
+}
+
+// findTasks filters, sorts and pages live tasks for LocalStore.FindTasks.
+func findTasks(stored []localTask, query models.TaskQuery) ([]models.Task, string, error) {
+	cursor, err := decodeCursor(query, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Rollups need every live task, not just the ones that match
+	live := liveTasks(stored)
+	entries := make(map[string][]models.TimeEntry, len(stored))
+	for _, task := range stored {
+		entries[task.ID] = task.TimeEntries
+	}
+
+	field, descending := query.SortField()
+	type keyed struct {
+		task models.Task
+		key  string
+	}
+	var matches []keyed
+	for _, task := range live {
+		if query.Matches(task, entries[task.ID]) {
+			matches = append(matches, keyed{task, localSortKey(field, task)})
+		}
+	}
+
+	// before reports whether a sorts ahead of b in the query's order
+	before := func(aKey, aID, bKey, bID string) bool {
+		if aKey != bKey {
+			return (aKey < bKey) != descending
+		}
+		return aID != bID && (aID < bID) != descending
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return before(matches[i].key, matches[i].task.ID, matches[j].key, matches[j].task.ID)
+	})
+
+	tasks := []models.Task{}
+	next := ""
+	for _, match := range matches {
+		if cursor != nil && !before(cursor.Key, cursor.ID, match.key, match.task.ID) {
+			continue
+		}
+		if query.Limit > 0 && len(tasks) == query.Limit {
+			last := tasks[len(tasks)-1]
+			next = taskCursor{Sort: sortName(field, descending), Key: localSortKey(field, last), ID: last.ID}.encode()
+			break
+		}
+		tasks = append(tasks, match.task)
+	}
+
+	return tasks, next, nil
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	const id = "0f8fad5b-d9cb-469f-a165-70867728950e"
+	cursor := func(sort, key, id string) string {
+		return taskCursor{Sort: sort, Key: key, ID: id}.encode()
+	}
+
+	for name, test := range map[string]struct {
+		query models.TaskQuery
+		valid bool
+	}{
+		"created_at": {models.TaskQuery{Cursor: cursor("-created_at", "2026-09-01 08:00:00.123456+00", id)}, true},
+		"offset":     {models.TaskQuery{Cursor: cursor("-created_at", "2026-09-01 08:00:00+05:30", id)}, true},
+		"title":      {models.TaskQuery{Sort: "title", Cursor: cursor("title", "anything at all", id)}, true},
+		"due_date":   {models.TaskQuery{Sort: "due_date", Cursor: cursor("due_date", "9999-12-31", id)}, true},
+		"priority":   {models.TaskQuery{Sort: "-priority", Cursor: cursor("-priority", "3", id)}, true},
+
+		"not base64":  {models.TaskQuery{Cursor: "!!"}, false},
+		"not json":    {models.TaskQuery{Cursor: base64.RawURLEncoding.EncodeToString([]byte("{"))}, false},
+		"no id":       {models.TaskQuery{Cursor: cursor("-created_at", "2026-09-01 08:00:00+00", "")}, false},
+		"other sort":  {models.TaskQuery{Sort: "title", Cursor: cursor("-created_at", "2026-09-01 08:00:00+00", id)}, false},
+		"id":          {models.TaskQuery{Cursor: cursor("-created_at", "2026-09-01 08:00:00+00", "'; DROP TABLE tasks")}, false},
+		"timestamp":   {models.TaskQuery{Cursor: cursor("-created_at", "yesterday", id)}, false},
+		"date":        {models.TaskQuery{Sort: "due_date", Cursor: cursor("due_date", "2026-13-45", id)}, false},
+		"priority no": {models.TaskQuery{Sort: "priority", Cursor: cursor("priority", "high", id)}, false},
+	} {
+		field, _ := test.query.SortField()
+		decoded, err := decodeCursor(test.query, pgCursorValid(field))
+		if test.valid && (err != nil || decoded == nil || decoded.ID != id) {
+			t.Errorf("%s: got %+v, %v", name, decoded, err)
+		}
+		if !test.valid && !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: got %v, want ErrInvalidCursor", name, err)
+		}
+	}
+
+	if decoded, err := decodeCursor(models.TaskQuery{}, nil); decoded != nil || err != nil {
+		t.Errorf("first page: got %+v, %v", decoded, err)
+	}
+	// Local IDs aren't UUIDs, so only Postgres checks them
+	local := models.TaskQuery{Cursor: cursor("-created_at", "2026-09-01T08:00:00.000000000", "20260901080000-0a1b2c3d")}
+	if _, err := decodeCursor(local, nil); err != nil {
+		t.Errorf("local cursor: %v", err)
+	}
+}
+
+func TestFindTasksPages(t *testing.T) {
+	testStores(t, func(t *testing.T, store TaskStore) {
+		for _, req := range []models.CreateTaskRequest{
+			{Title: "Deploy", Priority: "high", DueDate: "2026-09-03"},
+			{Title: "backup", Priority: "low"},
+			{Title: "Write docs", Priority: "medium", DueDate: "2026-09-01"},
+			{Title: "Answer mail", Priority: "high", Tags: []string{"inbox"}},
+			{Title: "Clean desk", DueDate: "2026-09-02"},
+		} {
+			if _, err := store.CreateTask(req); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, sort := range []string{"-created_at", "created_at", "title", "-title", "due_date", "-due_date", "priority", "-priority"} {
+			all, next, err := store.FindTasks(models.TaskQuery{Sort: sort})
+			if err != nil {
+				t.Fatalf("%s: %v", sort, err)
+			}
+			if len(all) != 5 || next != "" {
+				t.Fatalf("%s: %d tasks and cursor %q in one page", sort, len(all), next)
+			}
+
+			var paged []models.Task
+			query := models.TaskQuery{Sort: sort, Limit: 2}
+			for page := 0; page == 0 || query.Cursor != ""; page++ {
+				if page > len(all) {
+					t.Fatalf("%s: paging doesn't end", sort)
+				}
+				tasks, next, err := store.FindTasks(query)
+				if err != nil {
+					t.Fatalf("%s page %d: %v", sort, page, err)
+				}
+				paged = append(paged, tasks...)
+				query.Cursor = next
+			}
+			if len(paged) != len(all) {
+				t.Fatalf("%s: pages hold %d tasks, want %d", sort, len(paged), len(all))
+			}
+			for i := range all {
+				if paged[i].ID != all[i].ID {
+					t.Errorf("%s: task %d of the pages is %q, want %q", sort, i, paged[i].Title, all[i].Title)
+				}
+			}
+		}
+
+		byTitle, _, err := store.FindTasks(models.TaskQuery{Sort: "title"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if byTitle[0].Title != "Answer mail" || byTitle[1].Title != "backup" {
+			t.Errorf("by title starts with %q, %q", byTitle[0].Title, byTitle[1].Title)
+		}
+		byDue, _, err := store.FindTasks(models.TaskQuery{Sort: "due_date"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if byDue[0].Title != "Write docs" || byDue[2].Title != "Deploy" || byDue[4].DueDate != "" {
+			t.Errorf("by due date: %q, %q, %q", byDue[0].Title, byDue[2].Title, byDue[4].Title)
+		}
+
+		matched, _, err := store.FindTasks(models.TaskQuery{Search: "mail", Tags: []string{"inbox"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(matched) != 1 || matched[0].Title != "Answer mail" {
+			t.Errorf("search and tag matched %+v", matched)
+		}
+	})
+}
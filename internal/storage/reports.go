@@ -0,0 +1,296 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+// TimeReport reads the matching entries and groups them in Go with
+// models.ReportQuery, in the local time zone as LocalStore.TimeReport does,
+// so the personal and team rows of a merged report agree on days and weeks.
+func (s *PostgresStore) TimeReport(query models.ReportQuery) (*models.TimeReport, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	where := []string{"t.deleted_at IS NULL", "e.end_time IS NOT NULL"}
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	from, to := query.Period()
+	if !from.IsZero() {
+		where = append(where, "e.start_time >= "+arg(from))
+	}
+	if !to.IsZero() {
+		where = append(where, "e.start_time < "+arg(to))
+	}
+	if query.User != "" {
+		where = append(where, "LOWER(e.user_name) = LOWER("+arg(query.User)+")")
+	}
+
+	rows, err := s.db.Query(`
+	SELECT COALESCE(t.project, ''), e.user_name, e.start_time, e.end_time, COALESCE(e.duration_seconds, 0)
+	FROM time_entries e JOIN tasks t ON t.id = e.task_id
+	WHERE `+strings.Join(where, " AND "), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := models.NewTimeReport(query)
+	for rows.Next() {
+		var task models.Task
+		var entry models.TimeEntry
+		if err := rows.Scan(&task.Project, &entry.User, &entry.StartTime, &entry.EndTime, &entry.DurationSeconds); err != nil {
+			return nil, err
+		}
+		if query.Matches(entry) {
+			report.Add(query.Key(task, entry), entry.DurationSeconds, 1)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	report.Sort()
+
+	return report, nil
+}
+
+// TimeReport groups entries by the local time zone.
+func (s *LocalStore) TimeReport(query models.ReportQuery) (*models.TimeReport, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	stored, err := s.loadTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	report := models.NewTimeReport(query)
+	for _, task := range stored {
+		if task.DeletedAt != nil {
+			continue
+		}
+		for _, entry := range task.TimeEntries {
+			if query.Matches(entry) {
+				report.Add(query.Key(task.Task, entry), entry.DurationSeconds, 1)
+			}
+		}
+	}
+	report.Sort()
+
+	return report, nil
+}
+
+// EstimateReport compares the estimates of done tasks outside the trash
+// with their time and their subtasks' time, grouped by project or user. A
+// subtask of a task with an estimate is left out, since that estimate
+// covers it already.
+func EstimateReport(store TaskStore, group string) (*models.EstimateReport, error) {
+	if err := models.CheckEstimateGroup(group); err != nil {
+		return nil, err
+	}
+	export, err := Export(store)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make(map[string]models.ExportedTask, len(export.Tasks))
+	children := make(map[string][]string)
+	for _, task := range export.Tasks {
+		tasks[task.ID] = task
+		if task.ParentID != "" {
+			children[task.ParentID] = append(children[task.ParentID], task.ID)
+		}
+	}
+	estimatedAbove := func(task models.ExportedTask) bool {
+		seen := map[string]bool{task.ID: true}
+		for parent, ok := tasks[task.ParentID]; ok && !seen[parent.ID]; parent, ok = tasks[parent.ParentID] {
+			if parent.EstimateSeconds > 0 {
+				return true
+			}
+			seen[parent.ID] = true
+		}
+		return false
+	}
+
+	report := models.NewEstimateReport(group)
+	for _, task := range export.Tasks {
+		if task.Status != "done" || task.EstimateSeconds <= 0 || estimatedAbove(task) {
+			continue
+		}
+
+		// Time per user across the task and its subtasks
+		perUser := make(map[string]int)
+		users := []string{}
+		actual := 0
+		seen := make(map[string]bool)
+		var add func(id string)
+		add = func(id string) {
+			if seen[id] {
+				return
+			}
+			seen[id] = true
+			for _, entry := range tasks[id].TimeEntries {
+				key := strings.ToLower(entry.User)
+				if _, ok := perUser[key]; !ok {
+					users = append(users, entry.User)
+				}
+				perUser[key] += entry.DurationSeconds
+				actual += entry.DurationSeconds
+			}
+			for _, child := range children[id] {
+				add(child)
+			}
+		}
+		add(task.ID)
+		over := actual > task.EstimateSeconds
+		report.AddTotal(over, task.EstimateSeconds, actual)
+
+		if group == "project" || actual == 0 {
+			key := task.Project
+			if group == "user" {
+				key = ""
+			}
+			report.Add(key, over, task.EstimateSeconds, actual)
+			continue
+		}
+		for _, user := range users {
+			seconds := perUser[strings.ToLower(user)]
+			report.Add(user, over, task.EstimateSeconds*seconds/actual, seconds)
+		}
+	}
+	report.Sort()
+
+	return report, nil
+}
+
+// EstimatedTask is a task with an estimate as the estimate watcher sees
+// it. Its RollupTimeSeconds is the time recorded on it and its subtasks so
+// far.
+type EstimatedTask struct {
+	Task   models.Task
+	Timers []models.RunningTimer // Running on the task or any of its subtasks
+}
+
+// LiveSeconds is the task's and its subtasks' time, running timers
+// included, at now.
+func (t EstimatedTask) LiveSeconds(now time.Time) int {
+	total := t.Task.RollupTimeSeconds
+	for _, timer := range t.Timers {
+		total += int(now.Sub(timer.StartTime).Seconds())
+	}
+	return total
+}
+
+// RunningEstimates returns the tasks outside the trash that aren't done,
+// have an estimate and have a timer running on them or one of their
+// subtasks. Stores that implement EstimateStore look them up directly;
+// others have all their tasks read.
+func RunningEstimates(store TaskStore) ([]EstimatedTask, error) {
+	if estimates, ok := store.(EstimateStore); ok {
+		return estimates.GetRunningEstimates()
+	}
+	tasks, err := store.GetTasks()
+	if err != nil {
+		return nil, err
+	}
+	return runningEstimates(tasks), nil
+}
+
+// runningEstimates picks the running estimated tasks out of tasks, whose
+// rollups must be filled in.
+func runningEstimates(tasks []models.Task) []EstimatedTask {
+	index := make(map[string]int, len(tasks))
+	for i := range tasks {
+		index[tasks[i].ID] = i
+	}
+
+	var estimated []EstimatedTask
+	found := make(map[string]int) // Task ID to index in estimated
+	for _, task := range tasks {
+		timers := runningTimers(task)
+		if len(timers) == 0 {
+			continue
+		}
+		// Walk up from the timed task, as rollupTimes does
+		seen := make(map[string]bool)
+		for i, ok := index[task.ID]; ok && !seen[tasks[i].ID]; i, ok = index[tasks[i].ParentID] {
+			seen[tasks[i].ID] = true
+			if tasks[i].EstimateSeconds <= 0 || tasks[i].Status == "done" {
+				continue
+			}
+			j, ok := found[tasks[i].ID]
+			if !ok {
+				j = len(estimated)
+				found[tasks[i].ID] = j
+				estimated = append(estimated, EstimatedTask{Task: tasks[i]})
+			}
+			estimated[j].Timers = append(estimated[j].Timers, timers...)
+		}
+	}
+	return estimated
+}
+
+// GetRunningEstimates walks up from the running timers, so only the tasks
+// above them are read.
+func (s *PostgresStore) GetRunningEstimates() ([]EstimatedTask, error) {
+	rows, err := s.db.Query(`
+	WITH RECURSIVE timed AS (
+		SELECT a.task_id AS timer_task, a.user_name, a.start_time, t.id, t.parent_id
+		FROM active_timers a JOIN tasks t ON t.id = a.task_id
+		WHERE t.deleted_at IS NULL
+		UNION
+		SELECT timed.timer_task, timed.user_name, timed.start_time, t.id, t.parent_id
+		FROM tasks t JOIN timed ON t.id = timed.parent_id
+		WHERE t.deleted_at IS NULL
+	)
+	SELECT timed.id::text, timed.user_name, timed.start_time
+	FROM timed JOIN tasks t ON t.id = timed.id
+	WHERE t.estimate_seconds > 0 AND t.status <> 'done'
+	ORDER BY timed.start_time
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	timers := make(map[string][]models.RunningTimer)
+	for rows.Next() {
+		var id string
+		var timer models.RunningTimer
+		if err := rows.Scan(&id, &timer.User, &timer.StartTime); err != nil {
+			return nil, err
+		}
+		if _, ok := timers[id]; !ok {
+			ids = append(ids, id)
+		}
+		timers[id] = append(timers[id], timer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	estimated := make([]EstimatedTask, 0, len(ids))
+	for _, id := range ids {
+		task, err := s.GetTask(id)
+		if errors.Is(err, ErrNotFound) {
+			continue // Trashed since
+		}
+		if err != nil {
+			return nil, err
+		}
+		estimated = append(estimated, EstimatedTask{Task: *task, Timers: timers[id]})
+	}
+	return estimated, nil
+}
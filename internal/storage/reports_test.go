@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+func TestTimeReportGroupsLocalDays(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC-5", -5*3600)
+	t.Cleanup(func() { time.Local = local })
+
+	testStores(t, func(t *testing.T, store TaskStore) {
+		task, err := store.CreateTask(models.CreateTaskRequest{Title: "Late", Project: "Web"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Sunday evening locally, already Monday in UTC
+		late := time.Date(2026, 9, 7, 2, 30, 0, 0, time.UTC)
+		if _, err := store.AddTimeEntry(task.ID, "alice", late, late.Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		monday := time.Date(2026, 9, 7, 14, 0, 0, 0, time.UTC)
+		if _, err := store.AddTimeEntry(task.ID, "bob", monday, monday.Add(30*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+
+		reports := store.(ReportStore)
+		byDay, err := reports.TimeReport(models.ReportQuery{GroupBy: "day"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if byDay.Seconds("2026-09-06") != 3600 || byDay.Seconds("2026-09-07") != 1800 || byDay.TotalSeconds != 5400 {
+			t.Errorf("by day = %+v", byDay.Rows)
+		}
+
+		byWeek, err := reports.TimeReport(models.ReportQuery{GroupBy: "week", From: "2026-09-07"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(byWeek.Rows) != 1 || byWeek.Seconds("2026-09-07") != 1800 {
+			t.Errorf("by week from Monday = %+v", byWeek.Rows)
+		}
+
+		byUser, err := reports.TimeReport(models.ReportQuery{GroupBy: "user", To: "2026-09-06", User: "ALICE"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(byUser.Rows) != 1 || byUser.Seconds("alice") != 3600 {
+			t.Errorf("alice's time up to Sunday = %+v", byUser.Rows)
+		}
+	})
+}
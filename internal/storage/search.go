@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+// headlineOptions make ts_headline mark matches the way SearchResult
+// documents.
+const headlineOptions = `StartSel=` + models.HighlightStart + `, StopSel=` + models.HighlightStop
+
+func (s *PostgresStore) SearchTasks(query string, limit int) ([]models.SearchResult, error) {
+	rows, err := s.db.Query(`
+	SELECT `+taskColumns+`,
+		ts_rank(search, q)::float8 AS rank,
+		ts_headline('english', title, q, '`+headlineOptions+`, HighlightAll=true'),
+		CASE WHEN to_tsvector('english', description) @@ q
+			THEN ts_headline('english', description, q, '`+headlineOptions+`, MaxFragments=2, MaxWords=20, MinWords=8')
+			ELSE '' END
+	FROM tasks, websearch_to_tsquery('english', $1) q
+	WHERE deleted_at IS NULL AND search @@ q
+	ORDER BY rank DESC, created_at DESC
+	LIMIT $2
+	`, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.SearchResult{}
+	for rows.Next() {
+		var result models.SearchResult
+		task, err := scanTask(extraColumns{rows, []interface{}{&result.Rank, &result.Title, &result.Snippet}})
+		if err != nil {
+			return nil, err
+		}
+		result.Task = *task
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tasks := make([]models.Task, len(results))
+	for i := range results {
+		tasks[i] = results[i].Task
+	}
+	if err := s.rollupPage(tasks); err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Task = tasks[i]
+	}
+	return results, nil
+}
+
+// Weights of matches in the title, description, and project and tags for
+// the local search, the same as Postgres' defaults for the A, B and C
+// weights.
+const (
+	titleWeight       = 1.0
+	descriptionWeight = 0.4
+	projectWeight     = 0.2
+)
+
+// SearchTasks matches words anywhere in a task, so parts of words match
+// too. Every word has to match somewhere.
+func (s *LocalStore) SearchTasks(query string, limit int) ([]models.SearchResult, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	stored, err := s.loadTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	words := strings.Fields(strings.ToLower(query))
+	results := []models.SearchResult{}
+	if len(words) == 0 {
+		return results, nil
+	}
+	for _, task := range liveTasks(stored) {
+		title := strings.ToLower(task.Title)
+		description := strings.ToLower(task.Description)
+		project := strings.ToLower(task.Project + " " + strings.Join(task.Tags, " "))
+
+		rank := 0.0
+		for _, word := range words {
+			matched := 0.0
+			if strings.Contains(title, word) {
+				matched += titleWeight
+			}
+			if strings.Contains(description, word) {
+				matched += descriptionWeight
+			}
+			if strings.Contains(project, word) {
+				matched += projectWeight
+			}
+			if matched == 0 {
+				rank = 0
+				break
+			}
+			rank += matched
+		}
+		if rank == 0 {
+			continue
+		}
+
+		results = append(results, models.SearchResult{
+			Task:    task,
+			Rank:    rank,
+			Title:   highlight(task.Title, words),
+			Snippet: snippet(task.Description, words),
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].Task.CreatedAt.After(results[j].Task.CreatedAt)
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// highlight marks every occurrence of the lowercase words in text,
+// ignoring case.
+func highlight(text string, words []string) string {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		return text // Lowercasing moved the bytes around; leave it be
+	}
+
+	marked := make([]bool, len(text))
+	for _, word := range words {
+		for start := 0; ; {
+			i := strings.Index(lower[start:], word)
+			if i < 0 {
+				break
+			}
+			for j := start + i; j < start+i+len(word); j++ {
+				marked[j] = true
+			}
+			start += i + len(word)
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if marked[i] && (i == 0 || !marked[i-1]) {
+			b.WriteString(models.HighlightStart)
+		}
+		b.WriteByte(text[i])
+		if marked[i] && (i == len(text)-1 || !marked[i+1]) {
+			b.WriteString(models.HighlightStop)
+		}
+	}
+	return b.String()
+}
+
+// snippetContext is how many bytes of a description a snippet shows before
+// and after the first match.
+const snippetContext = 60
+
+// snippet returns the highlighted part of a description around its first
+// match, or "" if none of the words are in it.
+func snippet(description string, words []string) string {
+	lower := strings.ToLower(description)
+	first := -1
+	for _, word := range words {
+		if i := strings.Index(lower, word); i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+	if first < 0 || len(lower) != len(description) {
+		return ""
+	}
+
+	start := first - snippetContext
+	if start < 0 {
+		start = 0
+	}
+	end := first + 2*snippetContext
+	if end > len(description) {
+		end = len(description)
+	}
+	for start > 0 && !utf8.RuneStart(description[start]) {
+		start--
+	}
+	for end < len(description) && !utf8.RuneStart(description[end]) {
+		end++
+	}
+
+	text := highlight(strings.Join(strings.Fields(description[start:end]), " "), words)
+	if start > 0 {
+		text = "…" + text
+	}
+	if end < len(description) {
+		text += "…"
+	}
+	return text
+}
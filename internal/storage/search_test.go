@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+func TestSearchTasks(t *testing.T) {
+	testStores(t, func(t *testing.T, store TaskStore) {
+		search := store.(SearchStore)
+
+		var trashed *models.Task
+		for _, req := range []models.CreateTaskRequest{
+			{Title: "Invoice Acme", Description: "Send the September invoice to the client"},
+			{Title: "Client meeting", Description: "Talk about the invoice and next steps"},
+			{Title: "Release notes", Project: "Web", Tags: []string{"invoice"}},
+			{Title: "Old invoice draft"},
+		} {
+			task, err := store.CreateTask(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			trashed = task
+		}
+		if err := store.DeleteTask(trashed.ID, trashed.Version); err != nil {
+			t.Fatal(err)
+		}
+
+		results, err := search.SearchTasks("invoice", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 3 {
+			t.Fatalf("%d results, want the 3 live tasks: %+v", len(results), results)
+		}
+		// Title matches rank above description ones, which rank above tags
+		for i, want := range []string{"Invoice Acme", "Client meeting", "Release notes"} {
+			if results[i].Task.Title != want {
+				t.Errorf("result %d is %q, want %q", i, results[i].Task.Title, want)
+			}
+		}
+		if !strings.Contains(results[0].Title, models.HighlightStart+"Invoice"+models.HighlightStop) {
+			t.Errorf("title not highlighted: %q", results[0].Title)
+		}
+		if !strings.Contains(results[1].Snippet, models.HighlightStart+"invoice"+models.HighlightStop) {
+			t.Errorf("snippet not highlighted: %q", results[1].Snippet)
+		}
+		if results[2].Snippet != "" {
+			t.Errorf("tag match has snippet %q", results[2].Snippet)
+		}
+
+		// Every word has to match
+		results, err = search.SearchTasks("invoice client", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 2 {
+			t.Errorf("%d results for two words, want 2", len(results))
+		}
+
+		results, err = search.SearchTasks("invoice", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 {
+			t.Errorf("%d results past the limit of 1", len(results))
+		}
+	})
+}
@@ -0,0 +1,245 @@
+package storage
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+var (
+	// ErrNotFound is returned when a task or time entry does not exist.
+	ErrNotFound = errors.New("not found")
+
+	// ErrVersionConflict is returned when a write names an expected task
+	// version and the task has moved on since.
+	ErrVersionConflict = errors.New("task was changed by someone else")
+
+	// ErrInvalidParent is returned when a task's parent doesn't exist or is
+	// the task itself or one of its subtasks.
+	ErrInvalidParent = errors.New("parent task does not exist or is a subtask of this task")
+
+	// ErrDependencyCycle is returned when a "blocked by" link would make a
+	// task wait for itself, directly or through other tasks.
+	ErrDependencyCycle = errors.New("dependency would create a cycle")
+
+	// ErrDuplicateProject is returned when a project would get the name of
+	// another one, ignoring case.
+	ErrDuplicateProject = errors.New("a project with that name already exists")
+
+	// ErrInvalidProject is returned when a task names a project ID that
+	// doesn't exist.
+	ErrInvalidProject = errors.New("project does not exist")
+
+	// ErrDuplicateUser is returned when a user would get the name of
+	// another one, ignoring case.
+	ErrDuplicateUser = errors.New("a user with that name already exists")
+
+	// ErrInvalidToken is returned for an API token no user has.
+	ErrInvalidToken = errors.New("invalid API token")
+
+	// ErrInvalidCursor is returned for a page cursor the store didn't
+	// make, or made for another sort order.
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// ErrWeekLocked is returned when a time entry would be added to, moved
+	// into or out of, or removed from a week whose timesheet is approved.
+	ErrWeekLocked = errors.New("time entries of an approved week cannot change")
+
+	// ErrInvalidEntry is returned for a time entry that doesn't end after
+	// it starts.
+	ErrInvalidEntry = errors.New("end_time must be after start_time")
+
+	// ErrInvalidSplit is returned when a time entry would be split at a
+	// time that isn't strictly inside it.
+	ErrInvalidSplit = errors.New("split time must fall inside the entry")
+)
+
+// TaskStore is implemented by every task backend. The server works against
+// this interface so it can run on Postgres, a JSON file or plain memory.
+//
+// Every change to a task bumps its Version. Methods that take an
+// expectedVersion only apply when the task is still at that version and
+// return ErrVersionConflict otherwise; zero skips the check. Tasks outside
+// the trash come back with RollupTimeSeconds filled in.
+type TaskStore interface {
+	GetTasks() ([]models.Task, error)
+	// FindTasks returns a page of the tasks outside the trash that match a
+	// query, and the cursor of the next page, empty on the last one.
+	FindTasks(query models.TaskQuery) (tasks []models.Task, next string, err error)
+	GetTask(id string) (*models.Task, error)
+	CreateTask(req models.CreateTaskRequest) (*models.Task, error)
+	UpdateTask(id string, changes models.UpdateTaskRequest, expectedVersion int) (*models.Task, error)
+	UpdateTaskStatus(id, status string, expectedVersion int) (*models.Task, error)
+	// DeleteTask moves a task to the trash. It keeps its time entries and
+	// can be brought back with RestoreTask until it is purged. Stores that
+	// keep timesheets refuse to trash or restore a task with entries in an
+	// approved week with ErrWeekLocked, since that would change the week.
+	DeleteTask(id string, expectedVersion int) error
+	GetDeletedTasks() ([]models.Task, error)
+	RestoreTask(id string) (*models.Task, error)
+	// PurgeDeletedTasks permanently removes tasks that went to the trash
+	// before the given time, their time entries with them, and reports how
+	// many there were. Tasks with entries in an approved week are kept.
+	PurgeDeletedTasks(deletedBefore time.Time) (int, error)
+
+	// Timers belong to a user, so several users can time one task at once.
+	// Starting a timer the user already runs leaves it alone. Stopping
+	// records a time entry for the user's own timer or, failing that, one
+	// nobody owns; with neither running it changes nothing. Personal tasks
+	// use an empty user.
+	StartTimer(id, user string, expectedVersion int) (*models.Task, error)
+	StopTimer(id, user string, expectedVersion int) (*models.Task, error)
+
+	// Time entries. Every change recalculates the task's total time.
+	GetTimeEntries(taskID string) ([]models.TimeEntry, error)
+	GetTimeEntry(id string) (*models.TimeEntry, error)
+	AddTimeEntry(taskID, user string, start, end time.Time) (*models.TimeEntry, error)
+	UpdateTimeEntry(id string, start, end time.Time) (*models.TimeEntry, error)
+	// SplitTimeEntry cuts an entry in two at a time inside it, in one
+	// write: the entry ends there and a new one for the same user, billable
+	// or not like it, covers the rest. The total doesn't change.
+	SplitTimeEntry(id string, at time.Time) (first, second *models.TimeEntry, err error)
+	// DeleteTimeEntry returns the removed entry so the caller knows which
+	// task changed.
+	DeleteTimeEntry(id string) (*models.TimeEntry, error)
+
+	// Dependencies. A task lists the tasks blocking it in BlockedBy; adding
+	// or removing one is a change to that task and returns it. Adding one it
+	// already has returns it unchanged, at the same version.
+	AddDependency(taskID, blockedByID string) (*models.Task, error)
+	RemoveDependency(taskID, blockedByID string) (*models.Task, error)
+	// GetOpenBlockers returns the blockers of a task that are neither done
+	// nor in the trash.
+	GetOpenBlockers(taskID string) ([]models.Task, error)
+}
+
+// AuditLog stores the history of changes made through the server.
+type AuditLog interface {
+	RecordAudit(event models.AuditEvent) error
+	// GetTaskHistory returns a task's events, oldest first.
+	GetTaskHistory(taskID string) ([]models.AuditEvent, error)
+	// GetAuditEvents returns up to limit events recorded after since,
+	// oldest first, so the last one can be used as the next since.
+	GetAuditEvents(since time.Time, limit int) ([]models.AuditEvent, error)
+}
+
+// ProjectStore keeps the projects tasks belong to. A task that names a
+// project ID gets that project's name; one that only names a project is
+// linked to the project of that name if there is one, ignoring case.
+// Creating a project links the unlinked tasks that already carry its name,
+// renaming it renames it on its tasks, and deleting it leaves the name on
+// them as free text.
+type ProjectStore interface {
+	// GetProjects returns projects sorted by name, leaving out archived
+	// ones unless includeArchived is set.
+	GetProjects(includeArchived bool) ([]models.Project, error)
+	GetProject(id string) (*models.Project, error)
+	CreateProject(req models.CreateProjectRequest) (*models.Project, error)
+	UpdateProject(id string, changes models.UpdateProjectRequest) (*models.Project, error)
+	DeleteProject(id string) error
+}
+
+// UserStore keeps the team server's users and their API tokens. Only a
+// hash of each token is stored, so a token can't be shown again after it
+// is created.
+type UserStore interface {
+	CreateUser(name string) (*models.User, error)
+	// GetUsers returns all users sorted by name.
+	GetUsers() ([]models.User, error)
+	// CreateToken issues another API token for the user with the given
+	// name, ignoring case.
+	CreateToken(userName string) (string, error)
+	// AuthenticateToken returns the user a token belongs to, or
+	// ErrInvalidToken.
+	AuthenticateToken(token string) (*models.User, error)
+	// SetLead lets the user with the given name, ignoring case, review
+	// other users' timesheets, or stops them from doing so.
+	SetLead(userName string, lead bool) (*models.User, error)
+}
+
+// SearchStore finds tasks outside the trash by the words in their title,
+// description, project and tags.
+type SearchStore interface {
+	// SearchTasks returns up to limit matches for a query, best first.
+	SearchTasks(query string, limit int) ([]models.SearchResult, error)
+}
+
+// ReportStore adds up recorded time. Entries of tasks in the trash are left
+// out.
+type ReportStore interface {
+	TimeReport(query models.ReportQuery) (*models.TimeReport, error)
+}
+
+// EstimateStore finds the tasks the server's estimate watcher checks
+// without reading every task; see RunningEstimates.
+type EstimateStore interface {
+	GetRunningEstimates() ([]EstimatedTask, error)
+}
+
+// ImportStore takes in tasks exported from another store; see Import.
+type ImportStore interface {
+	// ImportTask adds a copy of a task under a new ID with its status,
+	// creation time and finished time entries. Its ParentID must be empty
+	// or name a live task of this store; its project is looked up by name
+	// and its BlockedBy is ignored.
+	ImportTask(task models.Task, entries []models.TimeEntry) (*models.Task, error)
+}
+
+// BillingStore keeps what invoices need besides time entries: which entries
+// are billable and the hourly rates; see BuildInvoice.
+type BillingStore interface {
+	// SetEntryBillable marks a time entry billable or not. Entries of an
+	// approved week are locked like any other change to them.
+	SetEntryBillable(id string, billable bool) (*models.TimeEntry, error)
+	// GetRates returns all rates with their project names, the default
+	// first.
+	GetRates() ([]models.Rate, error)
+	// SetRate adds a rate or changes the one for the same project and user.
+	// The project is looked up by ID or else by name; one that doesn't
+	// exist is ErrInvalidProject.
+	SetRate(rate models.Rate) (*models.Rate, error)
+	// DeleteRate removes the rate for a project and user, found as in
+	// SetRate.
+	DeleteRate(rate models.Rate) error
+}
+
+// TimesheetStore keeps the weekly timesheets users submit for approval.
+// While a user's week is approved, the store refuses changes to that user's
+// time entries starting in it with ErrWeekLocked.
+type TimesheetStore interface {
+	// GetTimesheet returns a user's timesheet for the week starting on
+	// week, with status models.TimesheetOpen if it was never submitted.
+	GetTimesheet(user, week string) (*models.Timesheet, error)
+	// GetTimesheets returns the submitted timesheets of a user, or of
+	// everyone for "", that have a status, or any for "", newest week
+	// first.
+	GetTimesheets(user, status string) ([]models.Timesheet, error)
+	// ChangeTimesheet moves a timesheet to another status, or returns a
+	// *models.TimesheetError if it can't move there from where it is.
+	ChangeTimesheet(change models.TimesheetChange) (*models.Timesheet, error)
+}
+
+var (
+	_ TaskStore = (*PostgresStore)(nil)
+	_ TaskStore = (*LocalStore)(nil)
+	_ AuditLog  = (*PostgresStore)(nil)
+	_ AuditLog  = (*LocalStore)(nil)
+
+	_ ProjectStore = (*PostgresStore)(nil)
+	_ ProjectStore = (*LocalStore)(nil)
+	_ UserStore    = (*PostgresStore)(nil)
+	_ UserStore    = (*LocalStore)(nil)
+	_ SearchStore  = (*PostgresStore)(nil)
+	_ SearchStore  = (*LocalStore)(nil)
+	_ ReportStore  = (*PostgresStore)(nil)
+	_ ReportStore  = (*LocalStore)(nil)
+	_ ImportStore  = (*PostgresStore)(nil)
+	_ ImportStore  = (*LocalStore)(nil)
+
+	_ TimesheetStore = (*PostgresStore)(nil)
+	_ TimesheetStore = (*LocalStore)(nil)
+	_ BillingStore   = (*PostgresStore)(nil)
+	_ BillingStore   = (*LocalStore)(nil)
+	_ EstimateStore  = (*PostgresStore)(nil)
+)
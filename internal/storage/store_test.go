@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+// testPostgres connects to the database named by
+// TASKTIME_TEST_DATABASE_URL, migrated and emptied, or skips the test when
+// it isn't set.
+func testPostgres(t *testing.T) *PostgresStore {
+	t.Helper()
+	url := os.Getenv("TASKTIME_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TASKTIME_TEST_DATABASE_URL is not set")
+	}
+
+	store, err := NewPostgresStore(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	if _, err := store.db.Exec(`TRUNCATE tasks, projects, users, audit_events, timesheets, rates CASCADE`); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// testStores runs a test against a fresh memory store and, when
+// TASKTIME_TEST_DATABASE_URL is set, an emptied Postgres store.
+func testStores(t *testing.T, test func(t *testing.T, store TaskStore)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewMemoryStore())
+	})
+	t.Run("postgres", func(t *testing.T) {
+		test(t, testPostgres(t))
+	})
+}
+
+func TestVersionConflicts(t *testing.T) {
+	testStores(t, func(t *testing.T, store TaskStore) {
+		task, err := store.CreateTask(models.CreateTaskRequest{Title: "Shared"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if task.Version != 1 {
+			t.Fatalf("new task is at version %d", task.Version)
+		}
+
+		title := "Renamed"
+		renamed, err := store.UpdateTask(task.ID, models.UpdateTaskRequest{Title: &title}, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if renamed.Version != 2 {
+			t.Fatalf("renamed task is at version %d", renamed.Version)
+		}
+
+		stale := "Stale"
+		if _, err := store.UpdateTask(task.ID, models.UpdateTaskRequest{Title: &stale}, 1); !errors.Is(err, ErrVersionConflict) {
+			t.Errorf("stale update: got %v, want ErrVersionConflict", err)
+		}
+		if _, err := store.UpdateTaskStatus(task.ID, "in-progress", 1); !errors.Is(err, ErrVersionConflict) {
+			t.Errorf("stale status change: got %v, want ErrVersionConflict", err)
+		}
+		if _, err := store.StartTimer(task.ID, "alice", 1); !errors.Is(err, ErrVersionConflict) {
+			t.Errorf("stale timer start: got %v, want ErrVersionConflict", err)
+		}
+		if err := store.DeleteTask(task.ID, 1); !errors.Is(err, ErrVersionConflict) {
+			t.Errorf("stale delete: got %v, want ErrVersionConflict", err)
+		}
+		if _, err := store.UpdateTask("missing", models.UpdateTaskRequest{Title: &stale}, 1); !errors.Is(err, ErrNotFound) {
+			t.Errorf("updating a missing task: got %v, want ErrNotFound", err)
+		}
+
+		tasks, err := store.GetTasks()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tasks) != 1 || tasks[0].Title != title || tasks[0].Version != 2 {
+			t.Fatalf("tasks = %+v", tasks)
+		}
+
+		if err := store.DeleteTask(task.ID, 2); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
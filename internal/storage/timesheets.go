@@ -0,0 +1,323 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+const timesheetColumns = `user_name, to_char(week_start, 'YYYY-MM-DD'), status, comment,
+	submitted_at, reviewed_by, reviewed_at`
+
+func scanTimesheet(row rowScanner) (*models.Timesheet, error) {
+	var sheet models.Timesheet
+	err := row.Scan(
+		&sheet.User, &sheet.WeekStart, &sheet.Status, &sheet.Comment,
+		&sheet.SubmittedAt, &sheet.ReviewedBy, &sheet.ReviewedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &sheet, nil
+}
+
+// openTimesheet is the timesheet of a week nobody submitted.
+func openTimesheet(user, week string) *models.Timesheet {
+	return &models.Timesheet{User: user, WeekStart: week, Status: models.TimesheetOpen}
+}
+
+func (s *PostgresStore) GetTimesheet(user, week string) (*models.Timesheet, error) {
+	sheet, err := scanTimesheet(s.db.QueryRow(`
+	SELECT `+timesheetColumns+` FROM timesheets
+	WHERE LOWER(user_name) = LOWER($1) AND week_start = $2
+	`, user, week))
+	if errors.Is(err, sql.ErrNoRows) {
+		return openTimesheet(user, week), nil
+	}
+	return sheet, err
+}
+
+func (s *PostgresStore) GetTimesheets(user, status string) ([]models.Timesheet, error) {
+	rows, err := s.db.Query(`
+	SELECT `+timesheetColumns+` FROM timesheets
+	WHERE ($1::text = '' OR LOWER(user_name) = LOWER($1)) AND ($2::text = '' OR status = $2)
+	ORDER BY week_start DESC, LOWER(user_name)
+	`, user, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sheets := []models.Timesheet{}
+	for rows.Next() {
+		sheet, err := scanTimesheet(rows)
+		if err != nil {
+			return nil, err
+		}
+		sheets = append(sheets, *sheet)
+	}
+
+	return sheets, rows.Err()
+}
+
+func (s *PostgresStore) ChangeTimesheet(change models.TimesheetChange) (*models.Timesheet, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	sheet, err := scanTimesheet(tx.QueryRow(`
+	SELECT `+timesheetColumns+` FROM timesheets
+	WHERE LOWER(user_name) = LOWER($1) AND week_start = $2
+	FOR UPDATE
+	`, change.User, change.Week))
+	stored := err == nil
+	if errors.Is(err, sql.ErrNoRows) {
+		sheet, err = openTimesheet(change.User, change.Week), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := models.CheckTimesheetMove(sheet.Status, change.Status); err != nil {
+		return nil, err
+	}
+	change.Apply(sheet, time.Now().UTC())
+
+	query := `
+	INSERT INTO timesheets (status, comment, submitted_at, reviewed_by, reviewed_at, user_name, week_start)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING ` + timesheetColumns
+	if stored {
+		query = `
+		UPDATE timesheets
+		SET status = $1, comment = $2, submitted_at = $3, reviewed_by = $4, reviewed_at = $5
+		WHERE LOWER(user_name) = LOWER($6) AND week_start = $7
+		RETURNING ` + timesheetColumns
+	}
+	sheet, err = scanTimesheet(tx.QueryRow(query,
+		sheet.Status, sheet.Comment, sheet.SubmittedAt, sheet.ReviewedBy, sheet.ReviewedAt,
+		sheet.User, sheet.WeekStart,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	return sheet, tx.Commit()
+}
+
+// checkWeekOpen returns ErrWeekLocked if the user's timesheet for the week
+// start falls in is approved. It holds a share lock on the timesheet until
+// tx ends, so the week can't be approved under a change that saw it open.
+func checkWeekOpen(tx *sql.Tx, user string, start time.Time) error {
+	var status string
+	err := tx.QueryRow(`
+	SELECT status FROM timesheets
+	WHERE LOWER(user_name) = LOWER($1) AND week_start = $2
+	FOR SHARE
+	`, user, models.WeekOf(start)).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if status == models.TimesheetApproved {
+		return ErrWeekLocked
+	}
+	return nil
+}
+
+// checkEntryOpen is checkWeekOpen for the week a stored entry starts in.
+func checkEntryOpen(tx *sql.Tx, id string) error {
+	var user string
+	var start time.Time
+	err := tx.QueryRow("SELECT user_name, start_time FROM time_entries WHERE id = $1 FOR UPDATE", id).Scan(&user, &start)
+	if err != nil {
+		return notFound(err)
+	}
+	return checkWeekOpen(tx, user, start)
+}
+
+// checkTaskEntriesOpen is checkWeekOpen for every time entry of a task, so
+// it can't go to or come back from the trash, or be purged, under an
+// approved week.
+func checkTaskEntriesOpen(tx *sql.Tx, taskID string) error {
+	rows, err := tx.Query("SELECT user_name, start_time FROM time_entries WHERE task_id = $1", taskID)
+	if err != nil {
+		return err
+	}
+	type entry struct {
+		user  string
+		start time.Time
+	}
+	var entries []entry
+	for rows.Next() {
+		var e entry
+		if err := rows.Scan(&e.user, &e.start); err != nil {
+			rows.Close()
+			return err
+		}
+		entries = append(entries, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if err := checkWeekOpen(tx, e.user, e.start); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *LocalStore) GetTimesheet(user, week string) (*models.Timesheet, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	if i := findTimesheet(file.Timesheets, user, week); i >= 0 {
+		return &file.Timesheets[i], nil
+	}
+	return openTimesheet(user, week), nil
+}
+
+func (s *LocalStore) GetTimesheets(user, status string) ([]models.Timesheet, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	sheets := []models.Timesheet{}
+	for _, sheet := range file.Timesheets {
+		if (user == "" || strings.EqualFold(sheet.User, user)) && (status == "" || sheet.Status == status) {
+			sheets = append(sheets, sheet)
+		}
+	}
+	sort.SliceStable(sheets, func(i, j int) bool {
+		if sheets[i].WeekStart != sheets[j].WeekStart {
+			return sheets[i].WeekStart > sheets[j].WeekStart
+		}
+		return strings.ToLower(sheets[i].User) < strings.ToLower(sheets[j].User)
+	})
+
+	return sheets, nil
+}
+
+func (s *LocalStore) ChangeTimesheet(change models.TimesheetChange) (*models.Timesheet, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	i := findTimesheet(file.Timesheets, change.User, change.Week)
+	if i < 0 {
+		file.Timesheets = append(file.Timesheets, *openTimesheet(change.User, change.Week))
+		i = len(file.Timesheets) - 1
+	}
+	sheet := &file.Timesheets[i]
+
+	if err := models.CheckTimesheetMove(sheet.Status, change.Status); err != nil {
+		return nil, err
+	}
+	change.Apply(sheet, time.Now())
+	if err := s.saveFile(file); err != nil {
+		return nil, err
+	}
+
+	return sheet, nil
+}
+
+// findTimesheet returns the index of a user's timesheet for a week, or -1.
+func findTimesheet(sheets []models.Timesheet, user, week string) int {
+	for i, sheet := range sheets {
+		if strings.EqualFold(sheet.User, user) && sheet.WeekStart == week {
+			return i
+		}
+	}
+	return -1
+}
+
+// weekApproved reports whether the user's timesheet for the week start
+// falls in is approved.
+func weekApproved(sheets []models.Timesheet, user string, start time.Time) bool {
+	i := findTimesheet(sheets, user, models.WeekOf(start))
+	return i >= 0 && sheets[i].Status == models.TimesheetApproved
+}
+
+// entriesLocked reports whether any of the entries starts in a week its
+// user's timesheet approves.
+func entriesLocked(sheets []models.Timesheet, entries []models.TimeEntry) bool {
+	for _, entry := range entries {
+		if weekApproved(sheets, entry.User, entry.StartTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// timesheetOf lists the entries of a timesheet's user and week and adds
+// them up.
+func timesheetOf(sheet *models.Timesheet, tasks []models.ExportedTask) {
+	sheet.Entries = []models.TimesheetEntry{}
+	sheet.TotalSeconds = 0
+	for _, task := range tasks {
+		for _, entry := range task.TimeEntries {
+			if entry.EndTime == nil || !strings.EqualFold(entry.User, sheet.User) || models.WeekOf(entry.StartTime) != sheet.WeekStart {
+				continue
+			}
+			sheet.Entries = append(sheet.Entries, models.TimesheetEntry{TimeEntry: entry, TaskTitle: task.Title, Project: task.Project})
+			sheet.TotalSeconds += entry.DurationSeconds
+		}
+	}
+	sort.SliceStable(sheet.Entries, func(i, j int) bool {
+		return sheet.Entries[i].StartTime.Before(sheet.Entries[j].StartTime)
+	})
+}
+
+// FillTimesheets adds up the time of each timesheet from the store's tasks
+// outside the trash, and with withEntries lists the entries too.
+func FillTimesheets(store TaskStore, sheets []models.Timesheet, withEntries bool) error {
+	if len(sheets) == 0 {
+		return nil
+	}
+	export, err := Export(store)
+	if err != nil {
+		return fmt.Errorf("adding up timesheets: %w", err)
+	}
+
+	for i := range sheets {
+		timesheetOf(&sheets[i], export.Tasks)
+		if !withEntries {
+			sheets[i].Entries = nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+func TestApprovedWeekLocksEntries(t *testing.T) {
+	testStores(t, func(t *testing.T, store TaskStore) {
+		sheets := store.(TimesheetStore)
+		task, err := store.CreateTask(models.CreateTaskRequest{Title: "Timesheet work"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry, err := store.AddTimeEntry(task.ID, "alice", date(9, 0), date(11, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.AddTimeEntry(task.ID, "bob", date(13, 0), date(14, 0)); err != nil {
+			t.Fatal(err)
+		}
+
+		week := models.WeekOf(date(9, 0))
+		change := func(status string) (*models.Timesheet, error) {
+			return sheets.ChangeTimesheet(models.TimesheetChange{User: "alice", Week: week, Status: status, Reviewer: "lee", Comment: "ok"})
+		}
+
+		sheet, err := sheets.GetTimesheet("alice", week)
+		if err != nil {
+			t.Fatal(err)
+		}
+		filled := []models.Timesheet{*sheet}
+		if err := FillTimesheets(store, filled, true); err != nil {
+			t.Fatal(err)
+		}
+		if filled[0].Status != models.TimesheetOpen || filled[0].TotalSeconds != 7200 || len(filled[0].Entries) != 1 {
+			t.Errorf("unsubmitted timesheet = %+v", filled[0])
+		}
+		var moveErr *models.TimesheetError
+		if _, err := change(models.TimesheetApproved); !errors.As(err, &moveErr) {
+			t.Fatalf("approving an open week: got %v, want *TimesheetError", err)
+		}
+
+		if _, err := change(models.TimesheetSubmitted); err != nil {
+			t.Fatal(err)
+		}
+		sheet, err = change(models.TimesheetApproved)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sheet.Status != models.TimesheetApproved || sheet.ReviewedBy != "lee" || sheet.ReviewedAt == nil || sheet.SubmittedAt == nil {
+			t.Errorf("approved timesheet = %+v", sheet)
+		}
+
+		if _, err := store.AddTimeEntry(task.ID, "alice", date(15, 0), date(16, 0)); !errors.Is(err, ErrWeekLocked) {
+			t.Errorf("adding to an approved week: got %v, want ErrWeekLocked", err)
+		}
+		if _, err := store.UpdateTimeEntry(entry.ID, date(9, 0), date(12, 0)); !errors.Is(err, ErrWeekLocked) {
+			t.Errorf("changing an entry in an approved week: got %v, want ErrWeekLocked", err)
+		}
+		if _, err := store.DeleteTimeEntry(entry.ID); !errors.Is(err, ErrWeekLocked) {
+			t.Errorf("deleting an entry in an approved week: got %v, want ErrWeekLocked", err)
+		}
+		// Only alice's week is approved
+		if _, err := store.AddTimeEntry(task.ID, "bob", date(15, 0), date(16, 0)); err != nil {
+			t.Errorf("adding to another user's week: %v", err)
+		}
+
+		approved, err := sheets.GetTimesheets("", models.TimesheetApproved)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(approved) != 1 || approved[0].User != "alice" || approved[0].WeekStart != week {
+			t.Errorf("approved timesheets = %+v", approved)
+		}
+
+		if _, err := change(models.TimesheetRejected); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.DeleteTimeEntry(entry.ID); err != nil {
+			t.Errorf("deleting an entry after rejecting the week: %v", err)
+		}
+		sheet, err = change(models.TimesheetSubmitted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sheet.Comment != "" || sheet.ReviewedBy != "" || sheet.ReviewedAt != nil {
+			t.Errorf("resubmitted timesheet = %+v", sheet)
+		}
+	})
+}
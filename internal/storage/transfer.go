@@ -0,0 +1,370 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+	"github.com/lib/pq"
+)
+
+// Export copies the tasks outside the trash and their finished time
+// entries, oldest task first.
+func Export(store TaskStore) (*models.Export, error) {
+	tasks, err := store.GetTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	export := &models.Export{ExportedAt: time.Now(), Tasks: []models.ExportedTask{}}
+	for i := len(tasks) - 1; i >= 0; i-- {
+		entries, err := store.GetTimeEntries(tasks[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("time entries of task %s: %w", tasks[i].ID, err)
+		}
+		finished := []models.TimeEntry{}
+		for _, entry := range entries {
+			if entry.EndTime != nil {
+				finished = append(finished, entry)
+			}
+		}
+		export.Tasks = append(export.Tasks, models.ExportedTask{Task: tasks[i], TimeEntries: finished})
+	}
+	return export, nil
+}
+
+// Import adds the tasks of an export to a store under new IDs, parents
+// before their subtasks, and then links the dependencies among them.
+//
+// A task the store already has, because it has the same ID or the same
+// title and creation time, isn't added again; only the time entries it
+// lacks are, and so are entries with another start, end or user. Parents
+// and blockers outside the export are kept if the store has them and
+// dropped otherwise. Dependencies that would close a cycle are skipped.
+//
+// Import isn't transactional: each task is added on its own, so when one
+// fails the tasks and entries added before it stay, and the result returned
+// with the error says which. Check the export with Export.Validate first to
+// catch bad input before anything is written.
+func Import(store TaskStore, export *models.Export) (*models.ImportResult, error) {
+	importer, ok := store.(ImportStore)
+	if !ok {
+		return nil, errors.New("this store cannot import tasks")
+	}
+
+	existing, err := store.GetTasks()
+	if err != nil {
+		return nil, err
+	}
+	live := make(map[string]*models.Task, len(existing))
+	byIdentity := make(map[string]string, len(existing))
+	for i := range existing {
+		live[existing[i].ID] = &existing[i]
+		byIdentity[taskIdentity(existing[i])] = existing[i].ID
+	}
+
+	exported := make(map[string]*models.ExportedTask, len(export.Tasks))
+	for i := range export.Tasks {
+		exported[export.Tasks[i].ID] = &export.Tasks[i]
+	}
+
+	result := &models.ImportResult{IDs: make(map[string]string), Created: []string{}, Updated: []string{}}
+
+	var add func(task *models.ExportedTask, visiting map[string]bool) error
+	add = func(task *models.ExportedTask, visiting map[string]bool) error {
+		if _, done := result.IDs[task.ID]; done {
+			return nil
+		}
+		if visiting[task.ID] {
+			return fmt.Errorf("task %s is its own ancestor", task.ID)
+		}
+		visiting[task.ID] = true
+
+		if id, ok := duplicateOf(task.Task, live, byIdentity); ok {
+			result.IDs[task.ID] = id
+			result.TasksSkipped++
+			added, err := addMissingEntries(store, id, task.TimeEntries, result)
+			if err != nil {
+				return err
+			}
+			if added {
+				result.Updated = append(result.Updated, id)
+			}
+			return nil
+		}
+
+		copied := task.Task
+		if parent, ok := exported[copied.ParentID]; ok {
+			if err := add(parent, visiting); err != nil {
+				return err
+			}
+			copied.ParentID = result.IDs[parent.ID]
+		} else if live[copied.ParentID] == nil {
+			copied.ParentID = ""
+		}
+
+		created, err := importer.ImportTask(copied, task.TimeEntries)
+		if err != nil {
+			return fmt.Errorf("importing task %q: %w", task.Title, err)
+		}
+		result.IDs[task.ID] = created.ID
+		result.Created = append(result.Created, created.ID)
+		result.TasksCreated++
+		for _, entry := range task.TimeEntries {
+			if entry.EndTime != nil {
+				result.EntriesCreated++
+			}
+		}
+		return nil
+	}
+
+	for i := range export.Tasks {
+		if err := add(&export.Tasks[i], make(map[string]bool)); err != nil {
+			return result, err
+		}
+	}
+
+	for _, task := range export.Tasks {
+		id := result.IDs[task.ID]
+		for _, blocker := range task.BlockedBy {
+			blockerID, ok := result.IDs[blocker]
+			if !ok && live[blocker] != nil {
+				blockerID = blocker
+			}
+			if blockerID == "" || (live[id] != nil && containsString(live[id].BlockedBy, blockerID)) {
+				continue
+			}
+
+			_, err := store.AddDependency(id, blockerID)
+			if errors.Is(err, ErrDependencyCycle) {
+				continue
+			}
+			if err != nil {
+				return result, err
+			}
+			result.DependenciesAdded++
+		}
+	}
+
+	return result, nil
+}
+
+// taskIdentity is what tells two copies of a task apart from different
+// tasks across stores. Creation times are compared to the second, since
+// that is what survives a CSV round trip.
+func taskIdentity(task models.Task) string {
+	return task.CreatedAt.UTC().Truncate(time.Second).Format(time.RFC3339) + " " + task.Title
+}
+
+// duplicateOf returns the ID of the live task an exported task is a copy
+// of, if any.
+func duplicateOf(task models.Task, live map[string]*models.Task, byIdentity map[string]string) (string, bool) {
+	if live[task.ID] != nil {
+		return task.ID, true
+	}
+	if task.CreatedAt.IsZero() {
+		return "", false
+	}
+	id, ok := byIdentity[taskIdentity(task)]
+	return id, ok
+}
+
+// addMissingEntries adds the finished entries a task doesn't have yet and
+// reports whether there were any.
+func addMissingEntries(store TaskStore, taskID string, entries []models.TimeEntry, result *models.ImportResult) (bool, error) {
+	current, err := store.GetTimeEntries(taskID)
+	if err != nil {
+		return false, err
+	}
+	have := make(map[string]bool, len(current))
+	for _, entry := range current {
+		if entry.EndTime != nil {
+			have[entryIdentity(entry)] = true
+		}
+	}
+
+	added := false
+	for _, entry := range entries {
+		if entry.EndTime == nil {
+			continue
+		}
+		if have[entryIdentity(entry)] {
+			result.EntriesSkipped++
+			continue
+		}
+		if _, err := store.AddTimeEntry(taskID, entry.User, entry.StartTime, *entry.EndTime); err != nil {
+			return added, err
+		}
+		have[entryIdentity(entry)] = true
+		result.EntriesCreated++
+		added = true
+	}
+	return added, nil
+}
+
+func entryIdentity(entry models.TimeEntry) string {
+	return fmt.Sprintf("%d %d %s", entry.StartTime.Unix(), entry.EndTime.Unix(), entry.User)
+}
+
+// ImportTask keeps the part of the task's total time its entries don't
+// account for as untracked time, as LocalStore.ImportTask does.
+func (s *PostgresStore) ImportTask(task models.Task, entries []models.TimeEntry) (*models.Task, error) {
+	if err := s.checkParent("", task.ParentID); err != nil {
+		return nil, err
+	}
+	projectID, project, err := s.resolveProject("", task.Project)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id string
+	err = tx.QueryRow(`
+	INSERT INTO tasks (title, project, status, description, priority, due_date, estimate_seconds, tags, parent_id, project_id, created_at, untracked_seconds)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	RETURNING id
+	`, task.Title, project, importedStatus(task), task.Description, task.Priority, nullParam(task.DueDate),
+		task.EstimateSeconds, pq.Array(models.NormalizeTags(task.Tags)), nullParam(task.ParentID),
+		nullParam(projectID), orNow(task.CreatedAt), untrackedSeconds(task, entries),
+	).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.EndTime == nil {
+			continue
+		}
+		if !entry.EndTime.After(entry.StartTime) {
+			return nil, ErrInvalidEntry
+		}
+		if err := checkWeekOpen(tx, entry.User, entry.StartTime); err != nil {
+			return nil, err
+		}
+		_, err := tx.Exec(`
+		INSERT INTO time_entries (task_id, user_name, start_time, end_time, duration_seconds, non_billable, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, id, entry.User, entry.StartTime, entry.EndTime,
+			int(entry.EndTime.Sub(entry.StartTime).Seconds()), entry.NonBillable, orNow(entry.CreatedAt))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := recalculateTotal(tx, id); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetTask(id)
+}
+
+// ImportTask keeps the task's total time when its entries add up to less,
+// as they do for tasks tracked before entries were recorded.
+func (s *LocalStore) ImportTask(task models.Task, entries []models.TimeEntry) (*models.Task, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkParent(file.Tasks, "", task.ParentID); err != nil {
+		return nil, err
+	}
+	projectID, project, err := findProject(file.Projects, "", task.Project)
+	if err != nil {
+		return nil, err
+	}
+
+	imported := localTask{Task: models.Task{
+		ID:              generateID(),
+		Title:           task.Title,
+		Project:         project,
+		ProjectID:       projectID,
+		Status:          importedStatus(task),
+		Description:     task.Description,
+		Priority:        task.Priority,
+		DueDate:         task.DueDate,
+		EstimateSeconds: task.EstimateSeconds,
+		Tags:            models.NormalizeTags(task.Tags),
+		ParentID:        task.ParentID,
+		CreatedAt:       orNow(task.CreatedAt),
+		IsPersonal:      s.personal,
+		Version:         1,
+	}}
+	for _, entry := range entries {
+		if entry.EndTime == nil {
+			continue
+		}
+		if !entry.EndTime.After(entry.StartTime) {
+			return nil, ErrInvalidEntry
+		}
+		if weekApproved(file.Timesheets, entry.User, entry.StartTime) {
+			return nil, ErrWeekLocked
+		}
+		end := *entry.EndTime
+		imported.TimeEntries = append(imported.TimeEntries, models.TimeEntry{
+			ID:              generateID(),
+			TaskID:          imported.ID,
+			User:            entry.User,
+			StartTime:       entry.StartTime,
+			EndTime:         &end,
+			DurationSeconds: int(end.Sub(entry.StartTime).Seconds()),
+			NonBillable:     entry.NonBillable,
+			CreatedAt:       orNow(entry.CreatedAt),
+		})
+	}
+	sort.SliceStable(imported.TimeEntries, func(i, j int) bool {
+		return imported.TimeEntries[i].StartTime.Before(imported.TimeEntries[j].StartTime)
+	})
+	imported.TotalTimeSeconds = untrackedSeconds(task, entries) + sumDurations(imported.TimeEntries)
+
+	file.Tasks = append([]localTask{imported}, file.Tasks...)
+	if err := s.saveFile(file); err != nil {
+		return nil, err
+	}
+
+	return rolledUp(file.Tasks, imported.ID), nil
+}
+
+// untrackedSeconds is the part of an exported task's total time that its
+// finished entries don't account for, as for tasks tracked before entries
+// were recorded.
+func untrackedSeconds(task models.Task, entries []models.TimeEntry) int {
+	tracked := 0
+	for _, entry := range entries {
+		if entry.EndTime != nil {
+			tracked += int(entry.EndTime.Sub(entry.StartTime).Seconds())
+		}
+	}
+	if task.TotalTimeSeconds < tracked {
+		return 0
+	}
+	return task.TotalTimeSeconds - tracked
+}
+
+func importedStatus(task models.Task) string {
+	if task.Status == "" {
+		return "todo"
+	}
+	return task.Status
+}
+
+func orNow(t time.Time) time.Time {
+	if t.IsZero() {
+		return time.Now()
+	}
+	return t
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+func TestImportKeepsUntrackedTime(t *testing.T) {
+	testStores(t, func(t *testing.T, store TaskStore) {
+		start := time.Date(2026, 9, 1, 9, 0, 0, 0, time.UTC)
+		end := start.Add(time.Hour)
+		exported := models.Task{Title: "Tracked before entries", TotalTimeSeconds: 3 * 3600}
+		entries := []models.TimeEntry{{User: "alice", StartTime: start, EndTime: &end}}
+
+		task, err := store.(ImportStore).ImportTask(exported, entries)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if task.TotalTimeSeconds != 3*3600 {
+			t.Fatalf("imported total = %d, want %d", task.TotalTimeSeconds, 3*3600)
+		}
+
+		// The untracked two hours stay when entries change
+		if _, err := store.AddTimeEntry(task.ID, "alice", end, end.Add(30*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+		task, err = store.GetTask(task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if task.TotalTimeSeconds != 3*3600+1800 {
+			t.Fatalf("total after adding half an hour = %d, want %d", task.TotalTimeSeconds, 3*3600+1800)
+		}
+
+		// Entries adding up to more than the total make up all of it
+		exported.TotalTimeSeconds = 600
+		task, err = store.(ImportStore).ImportTask(exported, entries)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if task.TotalTimeSeconds != 3600 {
+			t.Fatalf("imported total = %d, want 3600", task.TotalTimeSeconds)
+		}
+	})
+}
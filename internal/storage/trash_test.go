@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+)
+
+func TestTrashKeepsTimeEntries(t *testing.T) {
+	testStores(t, func(t *testing.T, store TaskStore) {
+		task, err := store.CreateTask(models.CreateTaskRequest{Title: "Old idea"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.AddTimeEntry(task.ID, "alice", date(9, 0), date(10, 0)); err != nil {
+			t.Fatal(err)
+		}
+		task, err = store.GetTask(task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.RestoreTask(task.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("restoring a live task: got %v, want ErrNotFound", err)
+		}
+
+		if err := store.DeleteTask(task.ID, task.Version); err != nil {
+			t.Fatal(err)
+		}
+		tasks, err := store.GetTasks()
+		if err != nil {
+			t.Fatal(err)
+		}
+		trash, err := store.GetDeletedTasks()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tasks) != 0 || len(trash) != 1 || trash[0].DeletedAt == nil {
+			t.Fatalf("after deleting: tasks %+v, trash %+v", tasks, trash)
+		}
+
+		restored, err := store.RestoreTask(task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if restored.DeletedAt != nil || restored.TotalTimeSeconds != 3600 || restored.Version <= task.Version {
+			t.Errorf("restored task = %+v", restored)
+		}
+		entries, err := store.GetTimeEntries(task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Errorf("restored task has %d entries, want 1", len(entries))
+		}
+	})
+}
+
+func TestPurgeDeletedTasks(t *testing.T) {
+	testStores(t, func(t *testing.T, store TaskStore) {
+		task, err := store.CreateTask(models.CreateTaskRequest{Title: "Gone for good"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.DeleteTask(task.ID, task.Version); err != nil {
+			t.Fatal(err)
+		}
+
+		// Nothing went to the trash before an hour ago
+		if purged, err := store.PurgeDeletedTasks(time.Now().Add(-time.Hour)); err != nil || purged != 0 {
+			t.Fatalf("purging older tasks: %d, %v", purged, err)
+		}
+		if purged, err := store.PurgeDeletedTasks(time.Now().Add(time.Hour)); err != nil || purged != 1 {
+			t.Fatalf("purging everything: %d, %v", purged, err)
+		}
+		if _, err := store.RestoreTask(task.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("restoring a purged task: got %v, want ErrNotFound", err)
+		}
+	})
+}
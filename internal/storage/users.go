@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ifrunruhin12/tasktime/internal/models"
+	"github.com/lib/pq"
+)
+
+// tokenPrefix starts every API token, so one is easy to spot in a config
+// file or a leaked log.
+const tokenPrefix = "tt_"
+
+// localUser is how a user is kept in the JSON file, with the hashes of its
+// API tokens.
+type localUser struct {
+	models.User
+	TokenHashes []string `json:"token_hashes,omitempty"`
+}
+
+// newToken returns a random API token and the hash that is stored for it.
+func newToken() (token, hash string, err error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	token = tokenPrefix + hex.EncodeToString(secret)
+	return token, hashToken(token), nil
+}
+
+// hashToken is what gets stored instead of a token. Tokens are long and
+// random, so a plain SHA-256 is enough; there is nothing to guess.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *PostgresStore) CreateUser(name string) (*models.User, error) {
+	var user models.User
+	err := s.db.QueryRow(`
+	INSERT INTO users (name) VALUES ($1)
+	RETURNING id, name, is_lead, created_at
+	`, strings.TrimSpace(name)).Scan(&user.ID, &user.Name, &user.Lead, &user.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" { // unique_violation
+			return nil, ErrDuplicateUser
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *PostgresStore) GetUsers() ([]models.User, error) {
+	rows, err := s.db.Query("SELECT id, name, is_lead, created_at FROM users ORDER BY LOWER(name)")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Name, &user.Lead, &user.CreatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (s *PostgresStore) CreateToken(userName string) (string, error) {
+	token, hash, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	result, err := s.db.Exec(`
+	INSERT INTO api_tokens (user_id, token_hash)
+	SELECT id, $2 FROM users WHERE LOWER(name) = LOWER($1)
+	`, strings.TrimSpace(userName), hash)
+	if err != nil {
+		return "", err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return "", err
+	} else if n == 0 {
+		return "", ErrNotFound
+	}
+
+	return token, nil
+}
+
+func (s *PostgresStore) AuthenticateToken(token string) (*models.User, error) {
+	var user models.User
+	err := s.db.QueryRow(`
+	SELECT u.id, u.name, u.is_lead, u.created_at
+	FROM api_tokens t JOIN users u ON u.id = t.user_id
+	WHERE t.token_hash = $1
+	`, hashToken(token)).Scan(&user.ID, &user.Name, &user.Lead, &user.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *PostgresStore) SetLead(userName string, lead bool) (*models.User, error) {
+	var user models.User
+	err := s.db.QueryRow(`
+	UPDATE users SET is_lead = $2 WHERE LOWER(name) = LOWER($1)
+	RETURNING id, name, is_lead, created_at
+	`, strings.TrimSpace(userName), lead).Scan(&user.ID, &user.Name, &user.Lead, &user.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (s *LocalStore) CreateUser(name string) (*models.User, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	for _, user := range file.Users {
+		if strings.EqualFold(user.Name, name) {
+			return nil, ErrDuplicateUser
+		}
+	}
+
+	user := models.User{ID: generateID(), Name: name, CreatedAt: time.Now()}
+	file.Users = append(file.Users, localUser{User: user})
+	if err := s.saveFile(file); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (s *LocalStore) GetUsers() ([]models.User, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	users := []models.User{}
+	for _, user := range file.Users {
+		users = append(users, user.User)
+	}
+	sort.SliceStable(users, func(i, j int) bool {
+		return strings.ToLower(users[i].Name) < strings.ToLower(users[j].Name)
+	})
+
+	return users, nil
+}
+
+func (s *LocalStore) CreateToken(userName string) (string, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return "", err
+	}
+
+	for i, user := range file.Users {
+		if strings.EqualFold(user.Name, strings.TrimSpace(userName)) {
+			token, hash, err := newToken()
+			if err != nil {
+				return "", err
+			}
+			file.Users[i].TokenHashes = append(file.Users[i].TokenHashes, hash)
+			if err := s.saveFile(file); err != nil {
+				return "", err
+			}
+			return token, nil
+		}
+	}
+
+	return "", ErrNotFound
+}
+
+func (s *LocalStore) AuthenticateToken(token string) (*models.User, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	hash := []byte(hashToken(token))
+	for _, user := range file.Users {
+		for _, stored := range user.TokenHashes {
+			if subtle.ConstantTimeCompare([]byte(stored), hash) == 1 {
+				return &user.User, nil
+			}
+		}
+	}
+
+	return nil, ErrInvalidToken
+}
+
+func (s *LocalStore) SetLead(userName string, lead bool) (*models.User, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := s.loadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, user := range file.Users {
+		if strings.EqualFold(user.Name, strings.TrimSpace(userName)) {
+			file.Users[i].Lead = lead
+			if err := s.saveFile(file); err != nil {
+				return nil, err
+			}
+			return &file.Users[i].User, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}